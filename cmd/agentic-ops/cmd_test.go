@@ -2,12 +2,18 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/htekdev/agentic-ops-cli/internal/concurrency"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
@@ -417,7 +423,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runWorkflow(tmpDir, "test")
+	err = runWorkflow(tmpDir, "test", runOptions{})
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -468,7 +474,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON)
+	err = runMatchingWorkflows(tmpDir, eventJSON, runOptions{})
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -519,7 +525,7 @@ steps:
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON)
+	err = runMatchingWorkflows(tmpDir, eventJSON, runOptions{})
 
 	_ = w.Close()
 	os.Stdout = oldStdout
@@ -538,6 +544,797 @@ steps:
 	}
 }
 
+// TestRunMatchingWorkflowsTriggerConditionError tests that a broken tool trigger if: denies
+func TestRunMatchingWorkflowsTriggerConditionError(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "agentic-ops-iferr-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workflowContent := `name: broken-condition
+on:
+  tool:
+    name: edit
+    if: ${{ notAFunction(event.tool.args.path) }}
+steps:
+  - name: Check
+    run: echo "checking edit"
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "broken.yml"), []byte(workflowContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	eventJSON := `{"tool":{"name":"edit","args":{"path":"test.go"}}}`
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runMatchingWorkflows(tmpDir, eventJSON, runOptions{})
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+
+	if err != nil {
+		t.Errorf("runMatchingWorkflows returned error: %v", err)
+	}
+
+	var result schema.WorkflowResult
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if result.PermissionDecision != "deny" {
+		t.Errorf("Expected deny for trigger condition error, got %s", result.PermissionDecision)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "unknown function") {
+		t.Errorf("Expected reason to mention the expression error, got: %s", result.PermissionDecisionReason)
+	}
+}
+
+// TestRunWithRawInputHookTypeOverride tests that --hook-type selects postToolUse workflows
+func TestRunWithRawInputHookTypeOverride(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "agentic-ops-posthook-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Workflow that only fires after edits and always fails, so a deny proves it ran
+	workflowContent := `name: after-edit
+on:
+  hooks:
+    types: [postToolUse]
+    tools: [edit]
+steps:
+  - name: Report
+    if: ${{ event.tool.result.type == 'success' }}
+    run: exit 1
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "after-edit.yml"), []byte(workflowContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(input, hookType string) schema.WorkflowResult {
+		t.Helper()
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := runWithRawInput(tmpDir, input, hookType, runOptions{})
+
+		_ = w.Close()
+		os.Stdout = oldStdout
+
+		if err != nil {
+			t.Fatalf("runWithRawInput returned error: %v", err)
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(r)
+		var result schema.WorkflowResult
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse output %q: %v", buf.String(), err)
+		}
+		return result
+	}
+
+	input := `{"toolName":"edit","toolArgs":{"path":"a.go"},"toolResult":{"resultType":"success"},"cwd":"` + filepath.ToSlash(tmpDir) + `"}`
+
+	if result := run(input, ""); result.PermissionDecision != "deny" {
+		t.Errorf("Expected postToolUse workflow to run for input with toolResult, got %s", result.PermissionDecision)
+	}
+	if result := run(input, "preToolUse"); result.PermissionDecision != "allow" {
+		t.Errorf("Expected --hook-type preToolUse to skip postToolUse workflow, got %s", result.PermissionDecision)
+	}
+}
+
+// TestRunWithRawInputLargeDirectories tests that deleting or moving a directory with
+// too many files to list still triggers workflows protecting files inside it
+func TestRunWithRawInputLargeDirectories(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "xdg"))
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workflow := `name: protect-secrets
+on:
+  file:
+    types: [delete, rename]
+    paths: ["config/secrets/**"]
+steps:
+  - name: block
+    run: exit 1
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "protect.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"config", "other"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 150; i++ {
+			if err := os.WriteFile(filepath.Join(tmpDir, dir, fmt.Sprintf("%03d.yml", i)), []byte("x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "config", "secrets"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "config", "secrets", "key.pem"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"rm -rf config", "deny"},
+		{"mv config cfg2", "deny"},
+		{"rm -rf *", "deny"},
+		{"rm -rf other", "allow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			input := `{"toolName":"bash","toolArgs":` + string(args) + `,"cwd":` + strconv.Quote(filepath.ToSlash(tmpDir)) + `}`
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := runWithRawInput(tmpDir, input, "", runOptions{})
+			_ = w.Close()
+			os.Stdout = oldStdout
+			if err != nil {
+				t.Fatalf("runWithRawInput returned error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			_, _ = buf.ReadFrom(r)
+			var result schema.WorkflowResult
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse output %q: %v", buf.String(), err)
+			}
+			if result.PermissionDecision != tt.want {
+				t.Errorf("Expected %s for %q, got %s: %s", tt.want, tt.command, result.PermissionDecision, result.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+// TestEvaluateWorkflowsAggregatesDenials tests that every matching workflow is reported
+func TestEvaluateWorkflowsAggregatesDenials(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "agentic-ops-aggregate-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows := map[string]string{
+		"a-lint.yml": `name: lint
+on:
+  tool:
+    name: edit
+steps:
+  - name: eslint
+    run: exit 1
+`,
+		"b-format.yml": `name: format
+on:
+  tool:
+    name: edit
+steps:
+  - name: prettier
+    run: echo ok
+`,
+		"c-test.yml": `name: test
+on:
+  tool:
+    name: edit
+steps:
+  - name: unit tests
+    run: sleep 1; exit 1
+`,
+	}
+	for name, content := range workflows {
+		if err := os.WriteFile(filepath.Join(workflowDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "a.go"}}}
+
+	result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+	if err != nil {
+		t.Fatalf("evaluateWorkflows returned error: %v", err)
+	}
+	defer func() {
+		for _, wf := range result.Workflows {
+			if wf.LogFile != "" {
+				_ = os.Remove(wf.LogFile)
+			}
+		}
+	}()
+
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	if len(result.Workflows) != 3 {
+		t.Fatalf("Expected 3 workflow summaries, got %d", len(result.Workflows))
+	}
+	for _, want := range []string{"2 of 3 workflows blocked", "eslint", "unit tests"} {
+		if !strings.Contains(result.PermissionDecisionReason, want) {
+			t.Errorf("Expected reason to contain %q, got: %s", want, result.PermissionDecisionReason)
+		}
+	}
+	if result.Workflows[1].Name != "format" || result.Workflows[1].PermissionDecision != "allow" {
+		t.Errorf("Expected format workflow to allow, got %+v", result.Workflows[1])
+	}
+
+	// With fail-fast, the first denial cancels the slower workflows
+	result, err = evaluateWorkflows(tmpDir, evt, runOptions{failFast: true})
+	if err != nil {
+		t.Fatalf("evaluateWorkflows returned error: %v", err)
+	}
+	if result.LogFile != "" {
+		_ = os.Remove(result.LogFile)
+	}
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny with fail-fast, got %s", result.PermissionDecision)
+	}
+	if len(result.Workflows) != 0 || len(result.FailedSteps) != 1 || result.FailedSteps[0] != "eslint" {
+		t.Errorf("Expected only the first workflow to run with fail-fast, got %+v", result)
+	}
+}
+
+// TestEvaluateWorkflowsConcurrency tests that independent workflows run in parallel
+// while workflows sharing a concurrency group respect its max-parallel
+func TestEvaluateWorkflowsConcurrency(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tests := []struct {
+		name        string
+		concurrency string
+		minDuration time.Duration
+		maxDuration time.Duration
+	}{
+		{
+			name:        "no group runs in parallel",
+			concurrency: "",
+			maxDuration: 1400 * time.Millisecond,
+		},
+		{
+			name:        "shared group is serialized",
+			concurrency: "concurrency:\n  group: checks-${{ event.tool.name }}\n",
+			minDuration: 1400 * time.Millisecond,
+		},
+		{
+			name:        "shared group with max-parallel",
+			concurrency: "concurrency:\n  group: checks\n  max-parallel: 3\n",
+			maxDuration: 1400 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+			if err := os.MkdirAll(workflowDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"one", "two", "three"} {
+				content := "name: " + name + "\n" + tt.concurrency + `on:
+  tool:
+    name: edit
+steps:
+  - name: slow check
+    shell: bash
+    run: sleep 0.5
+`
+				if err := os.WriteFile(filepath.Join(workflowDir, name+".yml"), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit"}}
+			start := time.Now()
+			result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatalf("evaluateWorkflows returned error: %v", err)
+			}
+
+			if result.PermissionDecision != "allow" {
+				t.Fatalf("Expected allow, got %s: %s", result.PermissionDecision, result.PermissionDecisionReason)
+			}
+			if len(result.Workflows) != 3 {
+				t.Fatalf("Expected 3 workflow summaries, got %d", len(result.Workflows))
+			}
+			// Summaries follow workflow file order regardless of completion order
+			for i, want := range []string{"one", "three", "two"} {
+				if result.Workflows[i].Name != want {
+					t.Errorf("Expected workflow %d to be %s, got %s", i, want, result.Workflows[i].Name)
+				}
+			}
+			if tt.minDuration > 0 && elapsed < tt.minDuration {
+				t.Errorf("Expected workflows to be serialized (>= %v), took %v", tt.minDuration, elapsed)
+			}
+			if tt.maxDuration > 0 && elapsed > tt.maxDuration {
+				t.Errorf("Expected workflows to run in parallel (<= %v), took %v", tt.maxDuration, elapsed)
+			}
+		})
+	}
+}
+
+// TestEvaluateWorkflowsConcurrencyTimeout tests waiting on a group held by another process
+func TestEvaluateWorkflowsConcurrencyTimeout(t *testing.T) {
+	tests := []struct {
+		name             string
+		blocking         string
+		expectedDecision string
+	}{
+		{name: "blocking workflow denies", blocking: "true", expectedDecision: "deny"},
+		{name: "non-blocking workflow allows", blocking: "false", expectedDecision: "allow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+			if err := os.MkdirAll(workflowDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			content := `name: go-test
+blocking: ` + tt.blocking + `
+concurrency:
+  group: go-test
+  timeout: 1
+on:
+  tool:
+    name: edit
+steps:
+  - name: never runs
+    run: echo ok
+`
+			if err := os.WriteFile(filepath.Join(workflowDir, "go-test.yml"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Another agentic-ops process holds the only slot
+			other := concurrency.NewFileGroup(concurrency.LockDir(tmpDir))
+			lock, err := other.Acquire(context.Background(), "go-test", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer other.Release(lock)
+
+			evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit"}}
+			result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+			if err != nil {
+				t.Fatalf("evaluateWorkflows returned error: %v", err)
+			}
+			if result.PermissionDecision != tt.expectedDecision {
+				t.Fatalf("Expected %s, got %s", tt.expectedDecision, result.PermissionDecision)
+			}
+			if tt.expectedDecision == "deny" && !strings.Contains(result.PermissionDecisionReason, "timed out after 1s waiting for concurrency group 'go-test'") {
+				t.Errorf("Expected timeout reason, got: %s", result.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+// TestEvaluateWorkflowsInvalidConcurrencyGroup tests that a group expression error denies
+func TestEvaluateWorkflowsInvalidConcurrencyGroup(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `name: broken-group
+concurrency:
+  group: ${{ event.tool.name == }}
+on:
+  tool:
+    name: edit
+steps:
+  - name: never runs
+    run: echo ok
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "broken.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit"}}
+	result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+	if err != nil {
+		t.Fatalf("evaluateWorkflows returned error: %v", err)
+	}
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "invalid concurrency group") {
+		t.Errorf("Expected concurrency group error, got: %s", result.PermissionDecisionReason)
+	}
+}
+
+// TestEvaluateWorkflowsVars tests that vars.yml feeds trigger conditions and steps
+// and is not itself treated as a workflow
+func TestEvaluateWorkflowsVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"vars.yml": `protected: go.mod
+max_lines: 3
+`,
+		"protect.yml": `name: protect
+on:
+  tool:
+    name: edit
+    if: event.tool.args.path == vars.protected
+steps:
+  - name: check limit
+    run: test "${{ vars.max_lines }}" -gt 100
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workflowDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		decision string
+	}{
+		{"go.mod", "deny"},
+		{"main.go", "allow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": tt.path}}}
+			result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+			if err != nil {
+				t.Fatalf("evaluateWorkflows returned error: %v", err)
+			}
+			if result.LogFile != "" {
+				_ = os.Remove(result.LogFile)
+			}
+			if result.PermissionDecision != tt.decision {
+				t.Errorf("Expected %s, got %s: %s", tt.decision, result.PermissionDecision, result.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+// TestEvaluateWorkflowsSecrets tests that secrets are masked in denial reasons and
+// that workflows interpolating secrets into commands are rejected
+func TestEvaluateWorkflowsSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tests := []struct {
+		name     string
+		workflow string
+		reason   string
+	}{
+		{
+			"masked output",
+			`name: check-token
+on:
+  tool:
+    name: edit
+    if: secrets.API_TOKEN != ''
+steps:
+  - name: print
+    run: echo "token $TOKEN" && exit 1
+    env:
+      TOKEN: ${{ secrets.API_TOKEN }}
+`,
+			"token ***",
+		},
+		{
+			"leaky command",
+			`name: leaky
+on:
+  tool:
+    name: edit
+steps:
+  - name: print
+    run: echo "${{ secrets.API_TOKEN }}"
+`,
+			"Workflow 'leaky' blocked: workflow may leak secrets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+			t.Setenv("TEST_SECRET_API_TOKEN", "s3cr3t-value")
+			workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+			if err := os.MkdirAll(workflowDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(workflowDir, "wf.yml"), []byte(tt.workflow), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "main.go"}}}
+			result, err := evaluateWorkflows(tmpDir, evt, runOptions{secretsPrefix: "TEST_SECRET_"})
+			if err != nil {
+				t.Fatalf("evaluateWorkflows returned error: %v", err)
+			}
+			if result.LogFile != "" {
+				_ = os.Remove(result.LogFile)
+			}
+			if result.PermissionDecision != "deny" {
+				t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+			}
+			if !strings.Contains(result.PermissionDecisionReason, tt.reason) {
+				t.Errorf("Expected reason containing %q, got: %s", tt.reason, result.PermissionDecisionReason)
+			}
+			if strings.Contains(result.PermissionDecisionReason, "s3cr3t-value") {
+				t.Errorf("Expected secret to be masked, got: %s", result.PermissionDecisionReason)
+			}
+		})
+	}
+}
+
+// TestEvaluateWorkflowsRunnerAndGit tests that trigger conditions and steps see the
+// runner and git contexts
+func TestEvaluateWorkflowsRunnerAndGit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workflow := `name: host
+on:
+  tool:
+    name: edit
+    if: runner.version == '` + version + `' && runner.os != ''
+steps:
+  - name: report
+    run: echo "branch=${{ git.branch }}" && exit 1
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "host.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	evt := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "main.go"}}}
+	result, err := evaluateWorkflows(tmpDir, evt, runOptions{})
+	if err != nil {
+		t.Fatalf("evaluateWorkflows returned error: %v", err)
+	}
+	if result.LogFile != "" {
+		_ = os.Remove(result.LogFile)
+	}
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected the workflow to match and deny, got %s: %s", result.PermissionDecision, result.PermissionDecisionReason)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "branch=") {
+		t.Errorf("Expected step output in reason, got: %s", result.PermissionDecisionReason)
+	}
+}
+
+// TestEvaluateEvents tests that one hook input describing several git operations
+// runs the workflows for each of them and combines the decisions
+func TestEvaluateEvents(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	workflows := map[string]string{
+		"a-audit.yml": `name: audit
+on:
+  tool:
+    name: bash
+steps:
+  - name: count
+    run: echo run >> runs.txt
+`,
+		"b-commit.yml": `name: commit-check
+on:
+  commit:
+    branches: [main]
+steps:
+  - name: message
+    run: |
+      test "${{ event.commit.message }}" = "fix: x"
+`,
+		"c-push.yml": `name: push-guard
+on:
+  push:
+    branches: [main]
+steps:
+  - name: no tags
+    run: test "${{ startsWith(event.push.ref, 'refs/tags/') }}" = "false"
+`,
+	}
+	for name, content := range workflows {
+		if err := os.WriteFile(filepath.Join(workflowDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tool := &schema.ToolEvent{Name: "bash", Args: map[string]interface{}{"command": "git commit -m 'fix: x' && git push origin main v1.0.0"}}
+	hook := &schema.HookEvent{Type: "preToolUse", Tool: tool, Cwd: tmpDir}
+	events := []*schema.Event{
+		{Tool: tool, Hook: hook, Cwd: tmpDir, Commit: &schema.CommitEvent{Message: "fix: x", Branch: "main"}},
+		{Tool: tool, Hook: hook, Cwd: tmpDir, Push: &schema.PushEvent{Ref: "refs/heads/main"}},
+		{Tool: tool, Hook: hook, Cwd: tmpDir, Push: &schema.PushEvent{Ref: "refs/tags/v1.0.0"}},
+	}
+
+	result, err := evaluateEvents(tmpDir, events, runOptions{})
+	if err != nil {
+		t.Fatalf("evaluateEvents returned error: %v", err)
+	}
+	defer func() {
+		for _, wf := range result.Workflows {
+			if wf.LogFile != "" {
+				_ = os.Remove(wf.LogFile)
+			}
+		}
+	}()
+
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected the tag push to be denied, got %s", result.PermissionDecision)
+	}
+	want := []struct{ name, decision string }{
+		{"audit", "allow"},
+		{"commit-check", "allow"},
+		{"push-guard (push refs/heads/main)", "allow"},
+		{"push-guard (push refs/tags/v1.0.0)", "deny"},
+	}
+	if len(result.Workflows) != len(want) {
+		t.Fatalf("Expected %d workflow summaries, got %+v", len(want), result.Workflows)
+	}
+	for i, w := range want {
+		got := result.Workflows[i]
+		if got.Name != w.name || got.PermissionDecision != w.decision {
+			t.Errorf("Expected summary %d to be %s %s, got %s %s", i, w.name, w.decision, got.Name, got.PermissionDecision)
+		}
+	}
+
+	// The tool trigger matches every event but its workflow runs only once
+	runs, err := os.ReadFile(filepath.Join(tmpDir, "runs.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read runs file: %v", err)
+	}
+	if got := strings.Count(string(runs), "run"); got != 1 {
+		t.Errorf("Expected the audit workflow to run once, got %d", got)
+	}
+}
+
+// TestEvaluateEventsDedupesFiles tests that a file named several times in one
+// command runs a file workflow once for it
+func TestEvaluateEventsDedupesFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	workflow := `name: file-audit
+on:
+  file:
+    paths: ["src/**"]
+steps:
+  - name: count
+    run: echo "${{ event.file.path }}" >> runs.txt
+`
+	if err := os.WriteFile(filepath.Join(workflowDir, "audit.yml"), []byte(workflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := &schema.ToolEvent{Name: "bash", Args: map[string]interface{}{"command": "rm src/a.go; rm -f src/a.go src/b.go"}}
+	hook := &schema.HookEvent{Type: "preToolUse", Tool: tool, Cwd: tmpDir}
+	var events []*schema.Event
+	for _, path := range []string{"src/a.go", "src/a.go", "src/b.go"} {
+		events = append(events, &schema.Event{Tool: tool, Hook: hook, Cwd: tmpDir, File: &schema.FileEvent{Path: path, Action: "delete"}})
+	}
+
+	result, err := evaluateEvents(tmpDir, events, runOptions{})
+	if err != nil {
+		t.Fatalf("evaluateEvents returned error: %v", err)
+	}
+	defer func() {
+		for _, wf := range result.Workflows {
+			if wf.LogFile != "" {
+				_ = os.Remove(wf.LogFile)
+			}
+		}
+	}()
+
+	runs, err := os.ReadFile(filepath.Join(tmpDir, "runs.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read runs file: %v", err)
+	}
+	if got := string(runs); got != "src/a.go\nsrc/b.go\n" {
+		t.Errorf("Expected one run per file, got %q", got)
+	}
+}
+
 // TestRunMatchingWorkflowsEmptyDir tests when workflow dir has no workflows
 func TestRunMatchingWorkflowsEmptyDir(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "agentic-ops-empty-*")
@@ -557,7 +1354,7 @@ func TestRunMatchingWorkflowsEmptyDir(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err = runMatchingWorkflows(tmpDir, eventJSON)
+	err = runMatchingWorkflows(tmpDir, eventJSON, runOptions{})
 
 	_ = w.Close()
 	os.Stdout = oldStdout
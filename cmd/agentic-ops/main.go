@@ -3,23 +3,33 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/htekdev/agentic-ops-cli/internal/concurrency"
 	"github.com/htekdev/agentic-ops-cli/internal/discover"
 	"github.com/htekdev/agentic-ops-cli/internal/event"
+	"github.com/htekdev/agentic-ops-cli/internal/expression"
 	"github.com/htekdev/agentic-ops-cli/internal/runner"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
+	"github.com/htekdev/agentic-ops-cli/internal/secrets"
 	"github.com/htekdev/agentic-ops-cli/internal/trigger"
+	"github.com/htekdev/agentic-ops-cli/internal/vars"
 	"github.com/spf13/cobra"
 )
 
 var version = "0.1.0"
 
+// defaultConcurrencyTimeout is how long, in seconds, a workflow waits for a slot
+// in its concurrency group before giving up
+const defaultConcurrencyTimeout = 60
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -81,7 +91,7 @@ var discoverCmd = &cobra.Command{
 var validateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Validate workflow files",
-	Long:  `Validates workflow YAML files against the schema.`,
+	Long:  `Validates workflow YAML files against the schema and checks their expressions.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dir, _ := cmd.Flags().GetString("dir")
 		file, _ := cmd.Flags().GetString("file")
@@ -98,10 +108,10 @@ var validateCmd = &cobra.Command{
 		var result *schema.ValidationResult
 		if file != "" {
 			fmt.Printf("Validating file: %s\n", file)
-			result = schema.ValidateWorkflow(file)
+			result = schema.ValidateWorkflow(file, expression.CheckWorkflow)
 		} else {
 			fmt.Printf("Validating workflows in: %s\n", dir)
-			result = schema.ValidateWorkflowsInDir(dir)
+			result = schema.ValidateWorkflowsInDir(dir, expression.CheckWorkflow)
 		}
 
 		// Print results
@@ -119,7 +129,8 @@ var validateCmd = &cobra.Command{
 			fmt.Printf("✗ %s\n", err.File)
 			fmt.Printf("  Error: %s\n", err.Message)
 			for _, detail := range err.Details {
-				fmt.Printf("    - %s\n", detail)
+				// Indent continuation lines, such as expression pointers, under the detail
+				fmt.Printf("    - %s\n", strings.ReplaceAll(detail, "\n", "\n      "))
 			}
 		}
 
@@ -136,6 +147,9 @@ var runCmd = &cobra.Command{
 
 Use --raw to pass raw Copilot hook input (toolName, toolArgs, cwd) and let the CLI
 detect the event type automatically. This is the preferred mode for hook scripts.
+A command running several git operations, such as git commit && git push, produces
+an event for each; workflows run for every event they match and the decisions are
+combined.
 
 Use --event to pass a pre-built event JSON (legacy mode).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -143,6 +157,10 @@ Use --event to pass a pre-built event JSON (legacy mode).`,
 		workflow, _ := cmd.Flags().GetString("workflow")
 		dir, _ := cmd.Flags().GetString("dir")
 		raw, _ := cmd.Flags().GetBool("raw")
+		hookType, _ := cmd.Flags().GetString("hook-type")
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		secretsPrefix, _ := cmd.Flags().GetString("secrets-prefix")
+		opts := runOptions{failFast: failFast, secretsPrefix: secretsPrefix}
 
 		if dir == "" {
 			var err error
@@ -154,16 +172,16 @@ Use --event to pass a pre-built event JSON (legacy mode).`,
 
 		// If workflow is specified, load and run it
 		if workflow != "" {
-			return runWorkflow(dir, workflow)
+			return runWorkflow(dir, workflow, opts)
 		}
 
 		// If --raw flag is set, use the new event detection
 		if raw {
-			return runWithRawInput(dir, eventStr)
+			return runWithRawInput(dir, eventStr, hookType, opts)
 		}
 
 		// Legacy mode: pre-built event JSON
-		return runMatchingWorkflows(dir, eventStr)
+		return runMatchingWorkflows(dir, eventStr, opts)
 	},
 }
 
@@ -200,10 +218,13 @@ func init() {
 	runCmd.Flags().StringP("workflow", "w", "", "Specific workflow to run")
 	runCmd.Flags().StringP("dir", "d", "", "Directory to search (default: current directory)")
 	runCmd.Flags().BoolP("raw", "r", false, "Accept raw hook input and auto-detect event type")
+	runCmd.Flags().Bool("fail-fast", false, "Stop running workflows after the first one denies")
+	runCmd.Flags().String("hook-type", "", "Hook type for --raw input (preToolUse, postToolUse); overrides the input's hookType")
+	runCmd.Flags().String("secrets-prefix", secrets.DefaultPrefix, "Prefix of environment variables loaded into the secrets context (empty to disable)")
 }
 
 // runWorkflow loads and executes a specific workflow
-func runWorkflow(dir, workflowName string) error {
+func runWorkflow(dir, workflowName string, opts runOptions) error {
 	// Try to find the workflow file
 	path, found := findWorkflowFile(dir, workflowName)
 	if !found {
@@ -215,18 +236,30 @@ func runWorkflow(dir, workflowName string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load workflow: %w", err)
 	}
+	if err := expression.CompileWorkflow(wf); err != nil {
+		return fmt.Errorf("invalid expression in workflow: %w", err)
+	}
+	if leaks := expression.SecretLeaks(wf); len(leaks) > 0 {
+		return fmt.Errorf("workflow may leak secrets: %s", strings.Join(leaks, "; "))
+	}
+	shared, err := loadSharedContexts(dir, opts)
+	if err != nil {
+		return err
+	}
+	shared.loadGit(dir, wf)
 
 	// Execute the workflow
 	ctx := context.Background()
-	r := runner.NewRunner(wf, nil, dir)
+	r := shared.apply(runner.NewRunner(wf, nil, dir))
 	result := r.RunWithBlocking(ctx)
 
 	// Output the result as JSON
 	return outputWorkflowResult(result)
 }
 
-// runWithRawInput handles raw Copilot hook input and auto-detects event type
-func runWithRawInput(dir, inputStr string) error {
+// runWithRawInput handles raw Copilot hook input and auto-detects event type.
+// hookType, if set, overrides the hook type given in the input.
+func runWithRawInput(dir, inputStr, hookType string, opts runOptions) error {
 	// Read from stdin if "-"
 	var input []byte
 	var err error
@@ -246,32 +279,116 @@ func runWithRawInput(dir, inputStr string) error {
 	}
 
 	// Use the event detector to parse and build the event
+	var raw event.RawHookInput
+	if err := json.Unmarshal(input, &raw); err != nil {
+		return fmt.Errorf("failed to detect event: %w", err)
+	}
+	if hookType != "" {
+		raw.HookType = hookType
+	}
+
 	detector := event.NewDetector(nil) // nil = use real git provider
-	evt, err := detector.DetectFromRawInput(input)
+	events, err := detector.DetectAll(&raw)
 	if err != nil {
 		return fmt.Errorf("failed to detect event: %w", err)
 	}
 
 	// Override cwd if dir is specified
-	if dir != "" && evt.Cwd == "" {
-		evt.Cwd = dir
-	}
-	if evt.Cwd == "" {
-		evt.Cwd = dir
+	for _, evt := range events {
+		if evt.Cwd == "" {
+			evt.Cwd = dir
+		}
 	}
 
 	// Discover and run matching workflows
-	return runMatchingWorkflowsWithEvent(dir, evt)
+	result, err := evaluateEvents(dir, events, opts)
+	if err != nil {
+		return err
+	}
+	return outputWorkflowResult(result)
+}
+
+// runOptions controls how matching workflows are executed
+type runOptions struct {
+	failFast      bool   // Stop running workflows after the first denial
+	secretsPrefix string // Prefix of environment variables loaded as secrets
+}
+
+// sharedContexts holds the expression contexts that are the same for every workflow
+type sharedContexts struct {
+	vars    map[string]interface{}
+	secrets map[string]string
+	runner  map[string]interface{}
+	git     map[string]interface{} // Nil until a workflow refers to it
+}
+
+// loadSharedContexts loads the vars and secrets contexts for a repository
+func loadSharedContexts(dir string, opts runOptions) (*sharedContexts, error) {
+	variables, err := vars.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vars: %w", err)
+	}
+
+	values, err := secrets.Load(dir, opts.secretsPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+	if path := secrets.RepoFile(dir); len(values) > 0 && secrets.IsTracked(dir, path) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is committed to git; add it to .gitignore and rotate its secrets\n", path)
+	}
+	if short := secrets.ShortSecrets(values); len(short) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: secrets shorter than %d characters are not masked: %s\n", secrets.MinMaskLength, strings.Join(short, ", "))
+	}
+
+	return &sharedContexts{vars: variables, secrets: values, runner: expression.RunnerContext(version)}, nil
+}
+
+// loadGit fills the git context the first time a workflow refers to it, since
+// gathering it runs several git commands
+func (s *sharedContexts) loadGit(dir string, wf *schema.Workflow) {
+	if s.git == nil && expression.WorkflowUsesContext(wf, "git") {
+		s.git = event.NewDetector(nil).GitExpressionContext(dir)
+	}
+}
+
+// apply sets the shared contexts on a runner
+func (s *sharedContexts) apply(r *runner.Runner) *runner.Runner {
+	return r.WithVars(s.vars).WithSecrets(s.secrets).WithRunnerContext(s.runner).WithGit(s.git)
 }
 
 // runMatchingWorkflowsWithEvent runs workflows with a pre-built event
-func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
+func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event, opts runOptions) error {
+	result, err := evaluateWorkflows(dir, evt, opts)
+	if err != nil {
+		return err
+	}
+	return outputWorkflowResult(result)
+}
+
+// evaluateWorkflows discovers, matches and runs every workflow for an event and
+// combines their decisions. All matching workflows run unless opts.failFast is set,
+// in which case evaluation stops at the first denial.
+func evaluateWorkflows(dir string, evt *schema.Event, opts runOptions) (*schema.WorkflowResult, error) {
+	return evaluateEvents(dir, []*schema.Event{evt}, opts)
+}
+
+// workflowRun is a matching workflow and the events it runs for, in order
+type workflowRun struct {
+	wf     *schema.Workflow
+	events []*schema.Event
+}
+
+// evaluateEvents is evaluateWorkflows for the several events one hook input can
+// describe, such as a commit and a push. A workflow runs once for each event it
+// matches and all decisions are combined. Every event carries the same tool and
+// hook details, so events after the first are matched on their git details alone;
+// otherwise a tool or hooks trigger would run its workflow once per event.
+func evaluateEvents(dir string, events []*schema.Event, opts runOptions) (*schema.WorkflowResult, error) {
 	// Discover workflows
 	workflowDir := filepath.Join(dir, ".github", "agent-workflows")
 	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
 		// No workflows directory, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+		return schema.NewAllowResult(), nil
 	}
 
 	// Find all workflow files
@@ -284,69 +401,298 @@ func runMatchingWorkflowsWithEvent(dir string, evt *schema.Event) error {
 			return nil
 		}
 		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yml" || ext == ".yaml" {
+		if (ext == ".yml" || ext == ".yaml") && !vars.IsVarsFile(dir, path) {
 			workflowFiles = append(workflowFiles, path)
 		}
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to scan workflows: %w", err)
+		return nil, fmt.Errorf("failed to scan workflows: %w", err)
 	}
 
-	if len(workflowFiles) == 0 {
-		// No workflows found, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+	shared, err := loadSharedContexts(dir, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	// Load and match workflows
-	var matchingWorkflows []*schema.Workflow
+	var summaries []schema.WorkflowSummary
+	var runs []workflowRun
 	for _, path := range workflowFiles {
 		wf, err := schema.LoadWorkflow(path)
 		if err != nil {
 			// Skip invalid workflows
 			continue
 		}
+		// Parse expressions once up front. Bad expressions still fail where they
+		// are evaluated, so a broken policy denies rather than being skipped.
+		if err := expression.CompileWorkflow(wf); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: workflow '%s': %v\n", wf.Name, err)
+		}
 
-		// Check if workflow matches the event
-		matcher := trigger.NewMatcher(wf)
-		if matcher.Match(evt) {
-			matchingWorkflows = append(matchingWorkflows, wf)
+		// Check which events the workflow matches
+		shared.loadGit(dir, wf)
+		run := workflowRun{wf: wf}
+		queued := make(map[string]bool)
+		for i, evt := range events {
+			candidate := evt
+			if i > 0 {
+				candidate = gitDetailsOnly(evt)
+			}
+			matched, denial := matchWorkflow(dir, wf, candidate, shared)
+			if denial != nil {
+				summaries = append(summaries, schema.NewWorkflowSummary(wf.Name, denial))
+				if opts.failFast {
+					return schema.CombineResults(summaries), nil
+				}
+				run.events = nil
+				break
+			}
+			if matched {
+				// A file named more than once in a command, as in rm a; rm -f a, runs
+				// the workflow only once
+				if evt.File != nil {
+					key := describeEvent(evt)
+					if queued[key] {
+						continue
+					}
+					queued[key] = true
+				}
+				run.events = append(run.events, evt)
+			}
+		}
+		if len(run.events) > 0 {
+			runs = append(runs, run)
 		}
 	}
 
-	if len(matchingWorkflows) == 0 {
-		// No matching workflows, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
+	summaries = append(summaries, runWorkflows(dir, shared, runs, opts)...)
+
+	// No workflows ran (none found or none matched) yields an allow
+	return schema.CombineResults(summaries), nil
+}
+
+// runWorkflows executes the matching workflows concurrently. Workflows that share an
+// evaluated concurrency group are limited to the group's max-parallel (default 1).
+// A workflow matching several events runs for them one after another, so it never
+// waits on or cancels itself through its own concurrency group. Summaries are
+// returned in workflow order regardless of completion order, so output is
+// deterministic. With fail-fast, the first denial cancels the remaining workflows
+// and is the only summary returned.
+func runWorkflows(dir string, shared *sharedContexts, runs []workflowRun, opts runOptions) []schema.WorkflowSummary {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Lock files let separate agentic-ops processes share concurrency groups
+	groups := concurrency.NewFileGroup(concurrency.LockDir(dir))
+	results := make([][]schema.WorkflowSummary, len(runs))
+
+	var mu sync.Mutex
+	var firstDenial *schema.WorkflowSummary
+
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		go func(i int, run workflowRun) {
+			defer wg.Done()
+
+			for _, evt := range run.events {
+				result := runGroupedWorkflow(ctx, groups, dir, evt, shared, run.wf)
+				if result == nil {
+					continue
+				}
+				name := run.wf.Name
+				if len(run.events) > 1 {
+					name = fmt.Sprintf("%s (%s)", name, describeEvent(evt))
+				}
+				summary := schema.NewWorkflowSummary(name, result)
+				results[i] = append(results[i], summary)
+
+				if opts.failFast && result.PermissionDecision == "deny" {
+					mu.Lock()
+					if firstDenial == nil && ctx.Err() == nil {
+						firstDenial = &summary
+						cancel()
+					}
+					mu.Unlock()
+					return
+				}
+			}
+		}(i, run)
 	}
+	wg.Wait()
 
-	// Run matching workflows
-	ctx := context.Background()
-	var finalResult *schema.WorkflowResult
+	if firstDenial != nil {
+		return []schema.WorkflowSummary{*firstDenial}
+	}
+
+	var summaries []schema.WorkflowSummary
+	for _, runSummaries := range results {
+		summaries = append(summaries, runSummaries...)
+	}
+	return summaries
+}
+
+// gitDetailsOnly returns a copy of an event without its tool and hook details
+func gitDetailsOnly(evt *schema.Event) *schema.Event {
+	stripped := *evt
+	stripped.Tool = nil
+	stripped.Hook = nil
+	return &stripped
+}
 
-	for _, wf := range matchingWorkflows {
-		r := runner.NewRunner(wf, evt, dir)
-		result := r.RunWithBlocking(ctx)
+// describeEvent names the git operation an event is for, to tell apart the runs
+// of a workflow that matched several events
+func describeEvent(evt *schema.Event) string {
+	switch {
+	case evt.Commit != nil:
+		return "commit"
+	case evt.Push != nil:
+		return "push " + evt.Push.Ref
+	case evt.File != nil && evt.File.OldPath != "":
+		return evt.File.Action + " " + evt.File.OldPath + " -> " + evt.File.Path
+	case evt.File != nil:
+		return evt.File.Action + " " + evt.File.Path
+	}
+	return "tool"
+}
+
+// runGroupedWorkflow runs a single workflow once a slot in its concurrency group is free.
+// It returns nil if the workflow was skipped or cancelled before it started.
+func runGroupedWorkflow(ctx context.Context, groups *concurrency.FileGroup, dir string, evt *schema.Event, shared *sharedContexts, wf *schema.Workflow) *schema.WorkflowResult {
+	cfg, err := resolveConcurrency(dir, wf, evt, shared)
+	if err != nil {
+		return concurrencyErrorResult(wf, err)
+	}
+	var lock *concurrency.Lock
+	if cfg != nil {
+		if cfg.CancelInProgress {
+			// Runs already in the group are checking stale state; stop them so ours can start
+			if err := groups.CancelInProgress(cfg.Group); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: workflow '%s': %v\n", wf.Name, err)
+			}
+		}
 
-		// If any workflow denies, the final result is deny
-		if result.PermissionDecision == "deny" {
-			return outputWorkflowResult(result)
+		waitCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+		lock, err = groups.Acquire(waitCtx, cfg.Group, cfg.MaxParallel)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				// Cancelled by fail-fast before a slot became available
+				return nil
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				return concurrencyTimeoutResult(wf, cfg)
+			}
+			return concurrencyErrorResult(wf, err)
 		}
+		defer groups.Release(lock)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	runCtx := ctx
+	if cfg != nil {
+		// A newer run in the group may cancel this one
+		var stop context.CancelFunc
+		runCtx, stop = groups.Watch(ctx, lock)
+		defer stop()
+	}
 
-		// Keep the last allow result
-		finalResult = result
+	result := shared.apply(runner.NewRunner(wf, evt, dir)).RunWithBlocking(runCtx)
+	if cfg != nil && result.Cancelled && ctx.Err() == nil {
+		result.PermissionDecisionReason = fmt.Sprintf("Workflow '%s' cancelled: superseded by a newer run in concurrency group '%s'", wf.Name, cfg.Group)
 	}
+	return result
+}
 
-	if finalResult == nil {
-		finalResult = schema.NewAllowResult()
+// resolveConcurrency evaluates a workflow's concurrency group against the event and
+// fills in defaults. A nil config means the workflow is not limited.
+func resolveConcurrency(dir string, wf *schema.Workflow, evt *schema.Event, shared *sharedContexts) (*schema.ConcurrencyConfig, error) {
+	if wf.Concurrency == nil || wf.Concurrency.Group == "" {
+		return nil, nil
+	}
+
+	ctx := expression.NewContextForEvent(evt)
+	ctx.Env = wf.Env
+	ctx.Vars = shared.vars
+	ctx.Secrets = shared.secrets
+	ctx.Runner = shared.runner
+	ctx.Git = shared.git
+	ctx.WorkingDir = dir
+	group, err := ctx.EvaluateString(wf.Concurrency.Group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid concurrency group: %w", err)
 	}
 
-	return outputWorkflowResult(finalResult)
+	cfg := &schema.ConcurrencyConfig{
+		Group:       group,
+		MaxParallel: wf.Concurrency.MaxParallel,
+		Timeout:     wf.Concurrency.Timeout,
+	}
+	if cfg.MaxParallel <= 0 {
+		cfg.MaxParallel = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultConcurrencyTimeout
+	}
+	return cfg, nil
+}
+
+// concurrencyTimeoutResult is used when no slot in the group freed up in time.
+// Blocking workflows deny since their checks never ran; non-blocking workflows allow.
+func concurrencyTimeoutResult(wf *schema.Workflow, cfg *schema.ConcurrencyConfig) *schema.WorkflowResult {
+	msg := fmt.Sprintf("timed out after %ds waiting for concurrency group '%s'", cfg.Timeout, cfg.Group)
+	if wf.IsBlocking() {
+		return schema.NewDenyResult(fmt.Sprintf("Workflow '%s' blocked: %s", wf.Name, msg))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: workflow '%s' skipped (non-blocking): %s\n", wf.Name, msg)
+	result := schema.NewAllowResult()
+	result.PermissionDecisionReason = fmt.Sprintf("Workflow '%s' skipped: %s", wf.Name, msg)
+	return result
+}
+
+// concurrencyErrorResult mirrors matchWorkflow: blocking workflows deny when their
+// concurrency group cannot be evaluated or locked, non-blocking workflows are skipped.
+func concurrencyErrorResult(wf *schema.Workflow, err error) *schema.WorkflowResult {
+	if wf.IsBlocking() {
+		return schema.NewDenyResult(fmt.Sprintf("Workflow '%s' blocked: %v", wf.Name, err))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: workflow '%s' skipped (non-blocking): %v\n", wf.Name, err)
+	return nil
+}
+
+// matchWorkflow checks whether a workflow's triggers match the event.
+// If a trigger condition cannot be evaluated, or a matching workflow could echo
+// secrets into its decision reason, blocking workflows deny (so a broken policy
+// never silently lets events through) and non-blocking workflows are skipped.
+// File functions in conditions resolve against dir, where the steps run.
+func matchWorkflow(dir string, wf *schema.Workflow, evt *schema.Event, shared *sharedContexts) (bool, *schema.WorkflowResult) {
+	matched, err := trigger.NewMatcher(wf).
+		WithWorkingDir(dir).
+		WithVars(shared.vars).
+		WithSecrets(shared.secrets).
+		WithRunnerContext(shared.runner).
+		WithGit(shared.git).
+		MatchWithError(evt)
+	if err == nil && matched {
+		if leaks := expression.SecretLeaks(wf); len(leaks) > 0 {
+			err = fmt.Errorf("workflow may leak secrets: %s", strings.Join(leaks, "; "))
+		}
+	}
+	if err != nil {
+		if wf.IsBlocking() {
+			return false, schema.NewDenyResult(fmt.Sprintf("Workflow '%s' blocked: %v", wf.Name, err))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: workflow '%s' skipped (non-blocking): %v\n", wf.Name, err)
+		return false, nil
+	}
+	return matched, nil
 }
 
 // runMatchingWorkflows discovers and runs all matching workflows
-func runMatchingWorkflows(dir, eventStr string) error {
+func runMatchingWorkflows(dir, eventStr string, opts runOptions) error {
 	// Parse the event
 	var eventData map[string]interface{}
 	
@@ -372,83 +718,7 @@ func runMatchingWorkflows(dir, eventStr string) error {
 	// Convert to Event struct
 	event := parseEventData(eventData)
 	
-	// Discover workflows
-	workflowDir := filepath.Join(dir, ".github", "agent-workflows")
-	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
-		// No workflows directory, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
-	}
-	
-	// Find all workflow files
-	var workflowFiles []string
-	err := filepath.Walk(workflowDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yml" || ext == ".yaml" {
-			workflowFiles = append(workflowFiles, path)
-		}
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("failed to scan workflows: %w", err)
-	}
-	
-	if len(workflowFiles) == 0 {
-		// No workflows found, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
-	}
-	
-	// Load and match workflows
-	var matchingWorkflows []*schema.Workflow
-	for _, path := range workflowFiles {
-		wf, err := schema.LoadWorkflow(path)
-		if err != nil {
-			// Skip invalid workflows
-			continue
-		}
-		
-		// Check if workflow matches the event
-		matcher := trigger.NewMatcher(wf)
-		if matcher.Match(event) {
-			matchingWorkflows = append(matchingWorkflows, wf)
-		}
-	}
-	
-	if len(matchingWorkflows) == 0 {
-		// No matching workflows, allow by default
-		result := schema.NewAllowResult()
-		return outputWorkflowResult(result)
-	}
-	
-	// Run matching workflows
-	ctx := context.Background()
-	var finalResult *schema.WorkflowResult
-	
-	for _, wf := range matchingWorkflows {
-		r := runner.NewRunner(wf, event, dir)
-		result := r.RunWithBlocking(ctx)
-		
-		// If any workflow denies, the final result is deny
-		if result.PermissionDecision == "deny" {
-			return outputWorkflowResult(result)
-		}
-		
-		// Keep the last allow result
-		finalResult = result
-	}
-	
-	if finalResult == nil {
-		finalResult = schema.NewAllowResult()
-	}
-	
-	return outputWorkflowResult(finalResult)
+	return runMatchingWorkflowsWithEvent(dir, event, opts)
 }
 
 // parseEventData converts raw event data to a schema.Event
@@ -472,6 +742,9 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 			if args, ok := toolData["args"].(map[string]interface{}); ok {
 				event.Hook.Tool.Args = args
 			}
+			if resultData, ok := toolData["result"].(map[string]interface{}); ok {
+				event.Hook.Tool.Result = parseToolResultData(resultData)
+			}
 		}
 	}
 	
@@ -487,6 +760,9 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if hookType, ok := toolData["hook_type"].(string); ok {
 			event.Tool.HookType = hookType
 		}
+		if resultData, ok := toolData["result"].(map[string]interface{}); ok {
+			event.Tool.Result = parseToolResultData(resultData)
+		}
 	}
 	
 	// Parse file event
@@ -501,34 +777,17 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if c, ok := fileData["content"].(string); ok {
 			event.File.Content = c
 		}
+		if o, ok := fileData["old_path"].(string); ok {
+			event.File.OldPath = o
+		}
+		if t, ok := fileData["tree"].(bool); ok {
+			event.File.Tree = t
+		}
 	}
 	
 	// Parse commit event
 	if commitData, ok := data["commit"].(map[string]interface{}); ok {
-		event.Commit = &schema.CommitEvent{}
-		if sha, ok := commitData["sha"].(string); ok {
-			event.Commit.SHA = sha
-		}
-		if msg, ok := commitData["message"].(string); ok {
-			event.Commit.Message = msg
-		}
-		if author, ok := commitData["author"].(string); ok {
-			event.Commit.Author = author
-		}
-		if files, ok := commitData["files"].([]interface{}); ok {
-			for _, f := range files {
-				if fm, ok := f.(map[string]interface{}); ok {
-					fs := schema.FileStatus{}
-					if p, ok := fm["path"].(string); ok {
-						fs.Path = p
-					}
-					if s, ok := fm["status"].(string); ok {
-						fs.Status = s
-					}
-					event.Commit.Files = append(event.Commit.Files, fs)
-				}
-			}
-		}
+		event.Commit = parseCommitData(commitData)
 	}
 	
 	// Parse push event
@@ -543,6 +802,13 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 		if after, ok := pushData["after"].(string); ok {
 			event.Push.After = after
 		}
+		if commits, ok := pushData["commits"].([]interface{}); ok {
+			for _, c := range commits {
+				if cm, ok := c.(map[string]interface{}); ok {
+					event.Push.Commits = append(event.Push.Commits, *parseCommitData(cm))
+				}
+			}
+		}
 	}
 	
 	// Parse top-level cwd and timestamp
@@ -556,6 +822,66 @@ func parseEventData(data map[string]interface{}) *schema.Event {
 	return event
 }
 
+// parseToolResultData converts raw tool result data to a schema.ToolResult
+func parseToolResultData(resultData map[string]interface{}) *schema.ToolResult {
+	result := &schema.ToolResult{}
+	if t, ok := resultData["type"].(string); ok {
+		result.Type = t
+	}
+	if output, ok := resultData["output"].(string); ok {
+		result.Output = output
+	}
+	if code, ok := resultData["exit_code"].(float64); ok {
+		exitCode := int(code)
+		result.ExitCode = &exitCode
+	}
+	return result
+}
+
+// parseCommitData converts raw commit data to a schema.CommitEvent
+func parseCommitData(commitData map[string]interface{}) *schema.CommitEvent {
+	commit := &schema.CommitEvent{}
+	if sha, ok := commitData["sha"].(string); ok {
+		commit.SHA = sha
+	}
+	if msg, ok := commitData["message"].(string); ok {
+		commit.Message = msg
+	}
+	if author, ok := commitData["author"].(string); ok {
+		commit.Author = author
+	}
+	if branch, ok := commitData["branch"].(string); ok {
+		commit.Branch = branch
+	}
+	if amend, ok := commitData["amend"].(bool); ok {
+		commit.Amend = amend
+	}
+	if fixup, ok := commitData["fixup"].(bool); ok {
+		commit.Fixup = fixup
+	}
+	if allowEmpty, ok := commitData["allow_empty"].(bool); ok {
+		commit.AllowEmpty = allowEmpty
+	}
+	if noVerify, ok := commitData["no_verify"].(bool); ok {
+		commit.NoVerify = noVerify
+	}
+	if files, ok := commitData["files"].([]interface{}); ok {
+		for _, f := range files {
+			if fm, ok := f.(map[string]interface{}); ok {
+				fs := schema.FileStatus{}
+				if p, ok := fm["path"].(string); ok {
+					fs.Path = p
+				}
+				if s, ok := fm["status"].(string); ok {
+					fs.Status = s
+				}
+				commit.Files = append(commit.Files, fs)
+			}
+		}
+	}
+	return commit
+}
+
 // discoverWorkflows finds all workflow files in a directory
 func discoverWorkflows(dir string) ([]discover.WorkflowFile, error) {
 	return discover.Discover(dir)
@@ -565,6 +891,9 @@ func discoverWorkflows(dir string) ([]discover.WorkflowFile, error) {
 func findWorkflowFile(dir, workflowName string) (string, bool) {
 	for _, ext := range []string{".yml", ".yaml"} {
 		path := fmt.Sprintf("%s/.github/agent-workflows/%s%s", dir, workflowName, ext)
+		if vars.IsVarsFile(dir, path) {
+			continue
+		}
 		if _, err := os.Stat(path); err == nil {
 			return path, true
 		}
@@ -581,46 +910,3 @@ func outputWorkflowResult(result *schema.WorkflowResult) error {
 	fmt.Println(string(jsonBytes))
 	return nil
 }
-
-// Git command detection helpers
-//
-// These patterns are designed to match git commands at the start of a command line
-// or after command separators (&&, ||, ;), but NOT inside quoted strings like echo "git commit"
-
-var gitCommitPattern = regexp.MustCompile(`(?:^|&&|\|\||;|&)\s*git\s+(commit|ci)\b`)
-var gitPushPattern = regexp.MustCompile(`(?:^|&&|\|\||;|&)\s*git\s+push\b`)
-var commitMessagePattern = regexp.MustCompile(`-m\s+["']([^"']+)["']`)
-var tagPushPattern = regexp.MustCompile(`\bgit\s+push\s+\S+\s+(v[\d.]+)`)
-
-// isGitCommitCommand checks if a shell command contains a git commit
-// It avoids false positives from git commands inside echo strings
-func isGitCommitCommand(command string) bool {
-	return gitCommitPattern.MatchString(command)
-}
-
-// isGitPushCommand checks if a shell command contains a git push
-// It avoids false positives from git commands inside echo strings
-func isGitPushCommand(command string) bool {
-	return gitPushPattern.MatchString(command)
-}
-
-// extractCommitMessage extracts the commit message from a git commit command
-func extractCommitMessage(command string) string {
-	matches := commitMessagePattern.FindStringSubmatch(command)
-	if len(matches) >= 2 {
-		return matches[1]
-	}
-	return ""
-}
-
-// extractPushRef determines the ref being pushed
-func extractPushRef(command string, currentBranch string) string {
-	// Check if pushing a tag
-	matches := tagPushPattern.FindStringSubmatch(command)
-	if len(matches) >= 2 {
-		return "refs/tags/" + matches[1]
-	}
-	
-	// Default to current branch
-	return "refs/heads/" + currentBranch
-}
@@ -104,12 +104,37 @@ func TestParseEventData_FileEvent(t *testing.T) {
 	}
 }
 
+func TestParseEventData_RenameEvent(t *testing.T) {
+	data := map[string]interface{}{
+		"file": map[string]interface{}{
+			"path":     "docs/new.md",
+			"action":   "rename",
+			"old_path": "docs/old.md",
+		},
+	}
+
+	event := parseEventData(data)
+
+	if event.File == nil {
+		t.Fatal("Expected File to be set")
+	}
+	if event.File.Action != "rename" {
+		t.Errorf("Expected File.Action = 'rename', got '%s'", event.File.Action)
+	}
+	if event.File.OldPath != "docs/old.md" {
+		t.Errorf("Expected File.OldPath = 'docs/old.md', got '%s'", event.File.OldPath)
+	}
+}
+
 func TestParseEventData_CommitEvent(t *testing.T) {
 	data := map[string]interface{}{
 		"commit": map[string]interface{}{
-			"sha":     "abc123def456",
-			"message": "feat: add new feature",
-			"author":  "test@example.com",
+			"sha":       "abc123def456",
+			"message":   "feat: add new feature",
+			"author":    "test@example.com",
+			"branch":    "feature/login",
+			"amend":     true,
+			"no_verify": true,
 			"files": []interface{}{
 				map[string]interface{}{
 					"path":   "src/feature.go",
@@ -137,6 +162,12 @@ func TestParseEventData_CommitEvent(t *testing.T) {
 	if event.Commit.Author != "test@example.com" {
 		t.Errorf("Expected Commit.Author = 'test@example.com', got '%s'", event.Commit.Author)
 	}
+	if event.Commit.Branch != "feature/login" {
+		t.Errorf("Expected Commit.Branch = 'feature/login', got '%s'", event.Commit.Branch)
+	}
+	if !event.Commit.Amend || !event.Commit.NoVerify || event.Commit.Fixup || event.Commit.AllowEmpty {
+		t.Errorf("Expected only Amend and NoVerify to be set, got %+v", event.Commit)
+	}
 	if len(event.Commit.Files) != 2 {
 		t.Fatalf("Expected 2 files, got %d", len(event.Commit.Files))
 	}
@@ -294,5 +325,15 @@ steps:
 	if found {
 		t.Error("Expected to not find workflow 'nonexistent'")
 	}
+
+	// The variables file is not a workflow, whichever extension it has
+	for _, name := range []string{"vars.yml", "vars.yaml"} {
+		if err := os.WriteFile(filepath.Join(workflowDir, name), []byte("threshold: 3\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, found := findWorkflowFile(tmpDir, "vars"); found {
+		t.Error("Expected not to find the variables file as workflow 'vars'")
+	}
 }
 
@@ -0,0 +1,383 @@
+package concurrency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultPollInterval is how often FileGroup retries while waiting for a slot
+const DefaultPollInterval = 50 * time.Millisecond
+
+// staleWriteGrace is how long a lock file may exist without a PID, or a reclaim
+// claim may be left behind, before it is considered abandoned
+const staleWriteGrace = 10 * time.Second
+
+// unsafeLockChars matches characters that are not kept in lock file names
+var unsafeLockChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// FileGroup manages concurrency groups with lock files so that separate
+// agentic-ops processes share the same slots. Each slot is a file naming its
+// holder by PID, process start time and a random nonce; slots whose holder is no
+// longer running are reclaimed.
+type FileGroup struct {
+	dir          string
+	pollInterval time.Duration
+}
+
+// Lock is a slot held in a concurrency group, returned by Acquire and given back
+// to Release
+type Lock struct {
+	path  string
+	owner string // Lock file content identifying this holder
+}
+
+// NewFileGroup creates a file-backed concurrency group manager storing locks in dir
+func NewFileGroup(dir string) *FileGroup {
+	return &FileGroup{
+		dir:          dir,
+		pollInterval: DefaultPollInterval,
+	}
+}
+
+// LockDir returns the directory used for lock files of the repository at workDir.
+// Locks live in .git/agentic-ops/locks when workDir has a .git directory, otherwise
+// in a per-directory folder under the system temp dir.
+func LockDir(workDir string) string {
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs
+	}
+
+	gitDir := filepath.Join(workDir, ".git")
+	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
+		return filepath.Join(gitDir, "agentic-ops", "locks")
+	}
+
+	sum := sha256.Sum256([]byte(workDir))
+	return filepath.Join(os.TempDir(), "agentic-ops-locks", hex.EncodeToString(sum[:])[:16])
+}
+
+// Acquire acquires a slot in the named concurrency group, polling until a slot
+// is free or the context is done. maxParallel <= 0 means unlimited, and returns a
+// nil lock.
+func (g *FileGroup) Acquire(ctx context.Context, name string, maxParallel int) (*Lock, error) {
+	if maxParallel <= 0 {
+		return nil, nil // No limit
+	}
+
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	owner, err := newOwner()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path, err := g.tryAcquire(name, maxParallel, owner)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			return &Lock{path: path, owner: owner}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(g.pollInterval):
+		}
+	}
+}
+
+// Release releases a slot returned by Acquire. A nil lock is ignored, and a slot
+// that has since passed to another holder is left alone.
+func (g *FileGroup) Release(lock *Lock) {
+	if lock == nil {
+		return
+	}
+	if lock.targetedBy(cancelMarker(lock.path)) {
+		_ = os.Remove(cancelMarker(lock.path))
+	}
+	if data, err := os.ReadFile(lock.path); err == nil && string(data) == lock.owner {
+		_ = os.Remove(lock.path)
+	}
+}
+
+// CancelInProgress asks other processes holding slots in the named group to cancel
+// their runs. Slots held by this process are left alone. Holders notice the request
+// through Watch and release their slots once their steps have stopped.
+func (g *FileGroup) CancelInProgress(name string) error {
+	entries, err := os.ReadDir(g.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read lock directory: %w", err)
+	}
+
+	base := lockFileBase(name)
+	for _, entry := range entries {
+		if !isSlotLockFile(entry.Name(), base) {
+			continue
+		}
+		path := filepath.Join(g.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue // Released in the meantime
+		}
+		pid, _, ok := parseOwner(data)
+		if !ok || pid == os.Getpid() {
+			continue
+		}
+		// The marker names the holder so a later holder of the same slot ignores it
+		if err := os.WriteFile(cancelMarker(path), data, 0644); err != nil {
+			return fmt.Errorf("failed to request cancellation: %w", err)
+		}
+	}
+	return nil
+}
+
+// Watch returns a context that is cancelled when another process requests
+// cancellation of the run holding lock. A nil lock is never cancelled.
+func (g *FileGroup) Watch(ctx context.Context, lock *Lock) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if lock == nil {
+		return ctx, cancel
+	}
+	go func() {
+		ticker := time.NewTicker(g.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if lock.targetedBy(cancelMarker(lock.path)) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, cancel
+}
+
+// cancelMarker returns the path of the cancellation marker for a slot lock file
+func cancelMarker(lockPath string) string {
+	return lockPath + ".cancel"
+}
+
+// targetedBy reports whether a cancellation marker names this holder
+func (l *Lock) targetedBy(marker string) bool {
+	data, err := os.ReadFile(marker)
+	return err == nil && string(data) == l.owner
+}
+
+// isSlotLockFile reports whether a file name is a slot lock ("<base>.<slot>.lock")
+func isSlotLockFile(fileName, base string) bool {
+	rest, ok := strings.CutPrefix(fileName, base+".")
+	if !ok {
+		return false
+	}
+	slot, ok := strings.CutSuffix(rest, ".lock")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(slot)
+	return err == nil
+}
+
+// tryAcquire attempts to take any free slot once. It returns the lock file path,
+// or an empty path if every slot is held by a running process.
+func (g *FileGroup) tryAcquire(name string, maxParallel int, owner string) (string, error) {
+	base := lockFileBase(name)
+	for slot := 0; slot < maxParallel; slot++ {
+		path := filepath.Join(g.dir, fmt.Sprintf("%s.%d.lock", base, slot))
+
+		ok, err := createLockFile(path, owner)
+		if err == nil && !ok {
+			// Slot is taken; take it over if its holder is gone
+			ok, err = reclaimStaleLock(path, owner)
+		}
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			removeLeftoverMarker(path, owner)
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// removeLeftoverMarker clears a cancellation marker addressed to a previous
+// holder of a slot, which is left behind if that holder never released it
+func removeLeftoverMarker(lockPath, owner string) {
+	marker := cancelMarker(lockPath)
+	if data, err := os.ReadFile(marker); err == nil && string(data) != owner {
+		_ = os.Remove(marker)
+	}
+}
+
+// lockFileBase turns a group name into a safe, collision-free file name prefix
+func lockFileBase(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	safe := strings.Trim(unsafeLockChars.ReplaceAllString(name, "_"), "_.")
+	if len(safe) > 48 {
+		safe = safe[:48]
+	}
+	return safe + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// newOwner returns the lock file content identifying a new holder: the PID and
+// start time of this process, which together survive PID reuse, and a random
+// nonce that tells apart slots held by the same process
+func newOwner() (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to create lock owner: %w", err)
+	}
+	start := processStartTime(os.Getpid())
+	if start == "" {
+		start = "-"
+	}
+	return fmt.Sprintf("%d %s %s\n", os.Getpid(), start, hex.EncodeToString(nonce)), nil
+}
+
+// parseOwner reads the PID and process start time from lock file content. The
+// start time is "" when it was not recorded.
+func parseOwner(data []byte) (pid int, start string, ok bool) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, "", false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, "", false
+	}
+	if len(fields) > 1 && fields[1] != "-" {
+		start = fields[1]
+	}
+	return pid, start, true
+}
+
+// createLockFile exclusively creates a lock file holding owner.
+// It returns false if the file already exists.
+func createLockFile(path, owner string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	_, werr := f.WriteString(owner)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(path)
+		return false, fmt.Errorf("failed to write lock file %s", path)
+	}
+	return true, nil
+}
+
+// reclaimStaleLock takes over a lock file whose holder is gone by renaming a new
+// lock file over it in one step. Processes racing to reclaim the same stale lock
+// first create a claim file named after its content; only the one that creates it
+// may replace the lock, and it checks the lock is still the stale one first.
+func reclaimStaleLock(path, owner string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !isStale(path, data) {
+		// A lock released in the meantime is retried on the next poll
+		return false, nil
+	}
+
+	sum := sha256.Sum256(data)
+	claim := fmt.Sprintf("%s.claim-%s", path, hex.EncodeToString(sum[:])[:12])
+	ok, err := createLockFile(claim, owner)
+	if err != nil || !ok {
+		if info, statErr := os.Stat(claim); statErr == nil && time.Since(info.ModTime()) > staleWriteGrace {
+			// The process that made the claim died before finishing
+			_ = os.Remove(claim)
+		}
+		return false, err
+	}
+	defer func() { _ = os.Remove(claim) }()
+
+	if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, data) {
+		return false, nil
+	}
+	next := claim + ".new"
+	if err := os.WriteFile(next, []byte(owner), 0644); err != nil {
+		return false, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	if err := os.Rename(next, path); err != nil {
+		_ = os.Remove(next)
+		return false, fmt.Errorf("failed to replace stale lock file: %w", err)
+	}
+	return true, nil
+}
+
+// isStale reports whether the holder named in a lock file no longer exists.
+// Files without a PID are only stale once they are older than staleWriteGrace,
+// since the holder may still be writing it.
+func isStale(path string, data []byte) bool {
+	pid, start, ok := parseOwner(data)
+	if !ok {
+		info, err := os.Stat(path)
+		return err == nil && time.Since(info.ModTime()) >= staleWriteGrace
+	}
+	if !processAlive(pid) {
+		return true
+	}
+	// A live process with a different start time reused the holder's PID
+	current := processStartTime(pid)
+	return start != "" && current != "" && current != start
+}
+
+// processStartTime returns when a process started, in clock ticks since boot as
+// reported by /proc, or "" where that is not available
+func processStartTime(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The command name in parentheses may contain spaces; starttime is the 20th
+	// field after it
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 20 {
+		return ""
+	}
+	return fields[19]
+}
+
+// processAlive reports whether a process with the given PID is running
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens a handle on Windows and fails if the process is gone
+		return true
+	}
+	err = proc.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
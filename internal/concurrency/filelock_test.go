@@ -0,0 +1,381 @@
+package concurrency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileGroup(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Separate instances stand in for separate processes sharing the lock dir
+	a := NewFileGroup(dir)
+	b := NewFileGroup(dir)
+
+	lockA, err := a.Acquire(ctx, "go test", 2)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := b.Acquire(ctx, "go test", 2); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// Third acquire should block, so we use a timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	_, err = NewFileGroup(dir).Acquire(timeoutCtx, "go test", 2)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Third Acquire() should timeout, got error = %v", err)
+	}
+
+	// Release one
+	a.Release(lockA)
+
+	if _, err := NewFileGroup(dir).Acquire(ctx, "go test", 2); err != nil {
+		t.Errorf("Acquire() after release error = %v", err)
+	}
+}
+
+func TestFileGroupWaitsForRelease(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	holder := NewFileGroup(dir)
+	lock, err := holder.Acquire(ctx, "group", 1)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		holder.Release(lock)
+	}()
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := NewFileGroup(dir).Acquire(timeoutCtx, "group", 1); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected Acquire() to wait for release, took %v", elapsed)
+	}
+}
+
+func TestFileGroupUnlimited(t *testing.T) {
+	dir := t.TempDir()
+	g := NewFileGroup(dir)
+
+	for i := 0; i < 10; i++ {
+		lock, err := g.Acquire(context.Background(), "unlimited", 0)
+		if err != nil || lock != nil {
+			t.Errorf("Expected no lock and no error, got %v, %v", lock, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no lock files for unlimited group, got %d", len(entries))
+	}
+}
+
+func TestFileGroupStaleLock(t *testing.T) {
+	dir := t.TempDir()
+
+	// Start and reap a process so its PID is known not to be running
+	cmd := exec.Command("go", "version")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	deadPID := cmd.Process.Pid
+
+	tests := []struct {
+		name    string
+		content string
+		modTime time.Time
+		stale   bool
+	}{
+		{
+			name:    "holder process gone",
+			content: fmt.Sprintf("%d\n", deadPID),
+			stale:   true,
+		},
+		{
+			name:    "holder process running",
+			content: fmt.Sprintf("%d\n", os.Getpid()),
+			stale:   false,
+		},
+		{
+			name:    "holder process running with start time",
+			content: fmt.Sprintf("%d %s abc\n", os.Getpid(), processStartTime(os.Getpid())),
+			stale:   false,
+		},
+		{
+			name:    "holder PID reused",
+			content: fmt.Sprintf("%d 1 abc\n", os.Getpid()),
+			stale:   processStartTime(os.Getpid()) != "",
+		},
+		{
+			name:    "being written",
+			content: "",
+			stale:   false,
+		},
+		{
+			name:    "never written",
+			content: "",
+			modTime: time.Now().Add(-time.Minute),
+			stale:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := strings.ReplaceAll(tt.name, " ", "-")
+			path := filepath.Join(dir, fmt.Sprintf("%s.0.lock", lockFileBase(group)))
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.modTime.IsZero() {
+				if err := os.Chtimes(path, tt.modTime, tt.modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			g := NewFileGroup(dir)
+			lock, err := g.Acquire(ctx, group, 1)
+			if tt.stale && err != nil {
+				t.Errorf("Expected stale lock to be reclaimed, got error = %v", err)
+			}
+			if !tt.stale && err != context.DeadlineExceeded {
+				t.Errorf("Expected lock to be respected, got error = %v", err)
+			}
+
+			if tt.stale {
+				data, _ := os.ReadFile(path)
+				if string(data) != lock.owner || !strings.HasPrefix(string(data), fmt.Sprint(os.Getpid())+" ") {
+					t.Errorf("Expected lock file to name us, got %q", data)
+				}
+				g.Release(lock)
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Error("Expected Release() to remove the lock file")
+				}
+			}
+		})
+	}
+}
+
+func TestLockFileBase(t *testing.T) {
+	a := lockFileBase("lint-${{ event.file.path }}")
+	b := lockFileBase("lint-${{ event.file.path }}")
+	c := lockFileBase("lint/${{ event.file.path }}")
+
+	if a != b {
+		t.Errorf("Expected stable lock names, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("Expected distinct groups to get distinct lock names, got %q", a)
+	}
+	if strings.ContainsAny(a, "/\\${} ") {
+		t.Errorf("Expected lock name without unsafe characters, got %q", a)
+	}
+}
+
+func TestLockDir(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := LockDir(repo), filepath.Join(repo, ".git", "agentic-ops", "locks"); got != want {
+		t.Errorf("LockDir() = %q, want %q", got, want)
+	}
+
+	plain := t.TempDir()
+	got := LockDir(plain)
+	if !strings.HasPrefix(got, filepath.Join(os.TempDir(), "agentic-ops-locks")) {
+		t.Errorf("LockDir() = %q, want a path under the temp dir", got)
+	}
+	if got == LockDir(t.TempDir()) {
+		t.Error("Expected different directories to get different lock dirs")
+	}
+}
+
+func TestFileGroupCancelInProgress(t *testing.T) {
+	dir := t.TempDir()
+	base := lockFileBase("lint")
+
+	// Slot 0 is held by another process, slot 1 by this one
+	other := filepath.Join(dir, base+".0.lock")
+	own := filepath.Join(dir, base+".1.lock")
+	if err := os.WriteFile(other, []byte(fmt.Sprintf("%d\n", os.Getppid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(own, []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewFileGroup(dir).CancelInProgress("lint"); err != nil {
+		t.Fatalf("CancelInProgress() error = %v", err)
+	}
+
+	data, err := os.ReadFile(cancelMarker(other))
+	if err != nil {
+		t.Fatalf("Expected cancellation marker for other process: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != fmt.Sprint(os.Getppid()) {
+		t.Errorf("Expected marker to name the holder, got %q", data)
+	}
+	if _, err := os.Stat(cancelMarker(own)); !os.IsNotExist(err) {
+		t.Error("Expected no cancellation marker for this process's own slot")
+	}
+
+	// A new holder of the slot clears the marker addressed to the previous holder
+	if err := os.Remove(other); err != nil {
+		t.Fatal(err)
+	}
+	g := NewFileGroup(dir)
+	if _, err := g.Acquire(context.Background(), "lint", 1); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if _, err := os.Stat(cancelMarker(other)); !os.IsNotExist(err) {
+		t.Error("Expected leftover cancellation marker to be removed")
+	}
+}
+
+func TestFileGroupWatch(t *testing.T) {
+	dir := t.TempDir()
+	g := NewFileGroup(dir)
+	lock, err := g.Acquire(context.Background(), "lint", 1)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	ctx, stop := g.Watch(context.Background(), lock)
+	defer stop()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("Expected context not to be cancelled without a request")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A marker addressed to an earlier holder of the slot is ignored
+	path := filepath.Join(dir, lockFileBase("lint")+".0.lock")
+	if err := os.WriteFile(cancelMarker(path), []byte(fmt.Sprintf("%d - earlier\n", os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+		t.Fatal("Expected context not to be cancelled by a marker for another holder")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Another process requests cancellation of our slot
+	if err := os.WriteFile(cancelMarker(path), []byte(lock.owner), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected context to be cancelled after a cancellation request")
+	}
+
+	g.Release(lock)
+	if _, err := os.Stat(cancelMarker(path)); !os.IsNotExist(err) {
+		t.Error("Expected Release() to remove the cancellation marker")
+	}
+}
+
+func TestFileGroupReleaseHandle(t *testing.T) {
+	dir := t.TempDir()
+	g := NewFileGroup(dir)
+	ctx := context.Background()
+
+	first, err := g.Acquire(ctx, "lint", 2)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	second, err := g.Acquire(ctx, "lint", 2)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// Releasing the first lock leaves the second one held
+	g.Release(first)
+	if _, err := os.Stat(first.path); !os.IsNotExist(err) {
+		t.Error("Expected Release() to remove the released lock file")
+	}
+	if _, err := os.Stat(second.path); err != nil {
+		t.Errorf("Expected the other lock file to stay, got %v", err)
+	}
+
+	// A slot that has passed to another holder is not removed
+	if err := os.WriteFile(second.path, []byte("1 - other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g.Release(second)
+	if _, err := os.Stat(second.path); err != nil {
+		t.Errorf("Expected another holder's lock file to stay, got %v", err)
+	}
+}
+
+func TestReclaimStaleLockRace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "group.0.lock")
+	if err := os.WriteFile(path, []byte("0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Many processes notice the same stale lock at once; exactly one takes it over
+	const contenders = 16
+	results := make(chan string, contenders)
+	var start sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < contenders; i++ {
+		owner := fmt.Sprintf("%d - contender%d\n", os.Getpid(), i)
+		go func() {
+			start.Wait()
+			ok, err := reclaimStaleLock(path, owner)
+			if err != nil {
+				t.Errorf("reclaimStaleLock() error = %v", err)
+			}
+			if ok {
+				results <- owner
+			} else {
+				results <- ""
+			}
+		}()
+	}
+	start.Done()
+
+	var winners []string
+	for i := 0; i < contenders; i++ {
+		if owner := <-results; owner != "" {
+			winners = append(winners, owner)
+		}
+	}
+	if len(winners) != 1 {
+		t.Fatalf("Expected exactly one process to reclaim the lock, got %d", len(winners))
+	}
+	if data, _ := os.ReadFile(path); string(data) != winners[0] {
+		t.Errorf("Expected the lock file to name the winner %q, got %q", winners[0], data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the lock file to remain, got %d files", len(entries))
+	}
+}
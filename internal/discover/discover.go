@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/htekdev/agentic-ops-cli/internal/vars"
 )
 
 const (
@@ -45,6 +47,11 @@ func Discover(rootDir string) ([]WorkflowFile, error) {
 			return nil
 		}
 
+		// The shared variables file lives alongside workflows but is not one
+		if vars.IsVarsFile(rootDir, path) {
+			return nil
+		}
+
 		// Get relative path
 		relPath, err := filepath.Rel(rootDir, path)
 		if err != nil {
@@ -90,6 +97,9 @@ func DiscoverByGlob(rootDir string, pattern string) ([]WorkflowFile, error) {
 		if ext != ".yml" && ext != ".yaml" {
 			continue
 		}
+		if vars.IsVarsFile(rootDir, path) {
+			continue
+		}
 
 		relPath, err := filepath.Rel(rootDir, path)
 		if err != nil {
@@ -51,6 +51,35 @@ func TestDiscover(t *testing.T) {
 	}
 }
 
+func TestDiscoverSkipsVarsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"lint.yml", "vars.yml"} {
+		if err := os.WriteFile(filepath.Join(workflowDir, f), []byte("name: test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workflows, err := Discover(tmpDir)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "lint" {
+		t.Errorf("Discover() = %v, want only the lint workflow", workflows)
+	}
+
+	workflows, err = DiscoverByGlob(tmpDir, "*.yml")
+	if err != nil {
+		t.Fatalf("DiscoverByGlob() error = %v", err)
+	}
+	if len(workflows) != 1 || workflows[0].Name != "lint" {
+		t.Errorf("DiscoverByGlob() = %v, want only the lint workflow", workflows)
+	}
+}
+
 func TestDiscoverEmptyDir(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -5,17 +5,35 @@ package event
 
 import (
 	"encoding/json"
-	"regexp"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/htekdev/agentic-ops-cli/internal/expression"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
+// Hook types supported by the detector
+const (
+	HookTypePreToolUse  = "preToolUse"
+	HookTypePostToolUse = "postToolUse"
+)
+
 // RawHookInput represents the raw input from a Copilot hook
 type RawHookInput struct {
-	ToolName string          `json:"toolName"`
-	ToolArgs json.RawMessage `json:"toolArgs"`
-	Cwd      string          `json:"cwd"`
+	HookType   string          `json:"hookType,omitempty"` // Defaults to preToolUse, or postToolUse when ToolResult is set
+	ToolName   string          `json:"toolName"`
+	ToolArgs   json.RawMessage `json:"toolArgs"`
+	ToolResult *RawToolResult  `json:"toolResult,omitempty"`
+	Cwd        string          `json:"cwd"`
+}
+
+// RawToolResult represents the tool result payload of a postToolUse hook
+type RawToolResult struct {
+	ResultType       string `json:"resultType"`
+	TextResultForLlm string `json:"textResultForLlm"`
+	ExitCode         *int   `json:"exitCode,omitempty"`
 }
 
 // ToolArgs represents parsed tool arguments
@@ -27,6 +45,7 @@ type ToolArgs struct {
 	FileText string `json:"file_text"`
 	OldStr   string `json:"old_str"`
 	NewStr   string `json:"new_str"`
+	NewPath  string `json:"new_path"`
 }
 
 // GitContext provides git repository context gathered at runtime
@@ -40,6 +59,32 @@ type GitContext struct {
 	Behind       int
 }
 
+// PendingCommit describes how a command changes the index before it commits
+type PendingCommit struct {
+	Adds      []PendingAdd // git add commands that run before the commit, in order
+	All       bool         // git commit -a stages every modified tracked file
+	Pathspecs []string     // git commit <pathspec> commits only the current content of these paths
+	Include   bool         // git commit -i <pathspec> commits the paths along with what is already staged
+}
+
+// PendingAdd is a git add that runs before a commit
+type PendingAdd struct {
+	Dir  string   // Directory git add runs in
+	Args []string // Arguments after "add"
+}
+
+// stagesFiles reports whether the index changes before the commit is recorded
+func (p PendingCommit) stagesFiles() bool {
+	return len(p.Adds) > 0 || p.All || len(p.Pathspecs) > 0
+}
+
+// PushRefspec is one ref a git push updates
+type PushRefspec struct {
+	Remote string // Remote named on the command line, "" for the default
+	Src    string // Local revision pushed; "" deletes Dst
+	Dst    string // Full name of the ref updated on the remote, e.g. refs/heads/main
+}
+
 // Detector detects and builds events from raw hook input
 type Detector struct {
 	gitProvider GitProvider
@@ -48,11 +93,18 @@ type Detector struct {
 // GitProvider interface for gathering git context (allows mocking in tests)
 type GitProvider interface {
 	GetBranch(cwd string) string
+	GetRepoRoot(cwd string) string
 	GetAuthor(cwd string) string
 	GetStagedFiles(cwd string) []schema.FileStatus
-	GetPendingFiles(cwd string, command string) []schema.FileStatus
+	GetPendingFiles(cwd string, pending PendingCommit) []schema.FileStatus
 	GetRemote(cwd string) string
 	GetAheadBehind(cwd string) (ahead, behind int)
+	GetHeadSHA(cwd string) string
+	GetCommitMessage(cwd string, rev string) string
+	GetOutgoingCommits(cwd string, push PushRefspec) (before, after string, commits []schema.CommitEvent) // nil commits when unknown
+	ResolveRef(cwd string, name string) string
+	ListRefs(cwd string, prefix string) []string
+	GetAliases(cwd string) map[string]string
 }
 
 // NewDetector creates a new event detector
@@ -63,6 +115,19 @@ func NewDetector(gitProvider GitProvider) *Detector {
 	return &Detector{gitProvider: gitProvider}
 }
 
+// GitExpressionContext returns the `git` expression context for the repository at cwd
+func (d *Detector) GitExpressionContext(cwd string) map[string]interface{} {
+	ahead, behind := d.gitProvider.GetAheadBehind(cwd)
+	return expression.GitContext(expression.GitInfo{
+		Branch: d.gitProvider.GetBranch(cwd),
+		Remote: d.gitProvider.GetRemote(cwd),
+		Ahead:  ahead,
+		Behind: behind,
+		SHA:    d.gitProvider.GetHeadSHA(cwd),
+		Author: d.gitProvider.GetAuthor(cwd),
+	})
+}
+
 // DetectFromRawInput parses raw hook input and returns a structured event
 func (d *Detector) DetectFromRawInput(input []byte) (*schema.Event, error) {
 	var raw RawHookInput
@@ -73,8 +138,26 @@ func (d *Detector) DetectFromRawInput(input []byte) (*schema.Event, error) {
 	return d.Detect(&raw)
 }
 
-// Detect determines the event type and builds the appropriate event structure
+// Detect determines the event type and builds the appropriate event structure.
+// When a command runs several git operations, only the first event is returned;
+// use DetectAll to get all of them.
 func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
+	events, err := d.DetectAll(raw)
+	if err != nil {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// DetectAll builds every event a hook input describes, in the order they happen.
+// A shell command yields one event per commit and per pushed ref, so policies for
+// `git commit -m x && git push` see both. There is always at least one event.
+func (d *Detector) DetectAll(raw *RawHookInput) ([]*schema.Event, error) {
+	hookType, err := resolveHookType(raw)
+	if err != nil {
+		return nil, err
+	}
+
 	event := &schema.Event{
 		Cwd: raw.Cwd,
 	}
@@ -110,185 +193,384 @@ func (d *Detector) Detect(raw *RawHookInput) (*schema.Event, error) {
 	event.Tool = &schema.ToolEvent{
 		Name:     raw.ToolName,
 		Args:     toolArgs,
-		HookType: "preToolUse",
+		HookType: hookType,
+	}
+	if raw.ToolResult != nil {
+		event.Tool.Result = &schema.ToolResult{
+			Type:     raw.ToolResult.ResultType,
+			Output:   raw.ToolResult.TextResultForLlm,
+			ExitCode: raw.ToolResult.ExitCode,
+		}
+	}
+	event.Hook = &schema.HookEvent{
+		Type: hookType,
+		Tool: event.Tool,
+		Cwd:  raw.Cwd,
+	}
+
+	// File, commit and push events describe pending changes, so they are only
+	// detected before the tool runs
+	if hookType != HookTypePreToolUse {
+		return []*schema.Event{event}, nil
 	}
 
 	// Detect specific event types based on tool and command
 	switch raw.ToolName {
 	case "powershell", "bash", "shell", "terminal":
-		d.detectShellEvent(event, command, raw.Cwd)
+		if events := d.detectShellEvents(event, command, raw.Cwd, DialectForTool(raw.ToolName)); len(events) > 0 {
+			return events, nil
+		}
 	case "create":
-		d.detectCreateEvent(event, &args)
+		d.detectCreateEvent(event, &args, raw.Cwd)
 	case "edit":
-		d.detectEditEvent(event, &args)
+		d.detectEditEvent(event, &args, raw.Cwd)
+	case "delete":
+		d.detectDeleteEvent(event, &args, raw.Cwd)
+	case "rename", "move":
+		d.detectRenameEvent(event, &args, raw.Cwd)
 	}
 
-	return event, nil
+	return []*schema.Event{event}, nil
 }
 
-// detectShellEvent handles shell/terminal commands
-func (d *Detector) detectShellEvent(event *schema.Event, command, cwd string) {
-	// Check for git commit
-	if IsGitCommitCommand(command) {
-		d.buildCommitEvent(event, command, cwd)
-		return
-	}
-
-	// Check for git push
-	if IsGitPushCommand(command) {
-		d.buildPushEvent(event, command, cwd)
-		return
+// resolveHookType returns the hook type for raw input, inferring postToolUse from a tool result
+func resolveHookType(raw *RawHookInput) (string, error) {
+	switch raw.HookType {
+	case HookTypePreToolUse, HookTypePostToolUse:
+		return raw.HookType, nil
+	case "":
+		if raw.ToolResult != nil {
+			return HookTypePostToolUse, nil
+		}
+		return HookTypePreToolUse, nil
+	default:
+		return "", fmt.Errorf("unsupported hook type: %s (expected %s or %s)", raw.HookType, HookTypePreToolUse, HookTypePostToolUse)
 	}
 }
 
-// buildCommitEvent builds a commit event from a git commit command
-func (d *Detector) buildCommitEvent(event *schema.Event, command, cwd string) {
-	// Get staged files
-	stagedFiles := d.gitProvider.GetStagedFiles(cwd)
+// detectShellEvents builds an event for each commit and push a shell command runs,
+// and for each file it deletes or renames. Each event is a copy of base, so they
+// all carry the tool and hook details.
+func (d *Detector) detectShellEvents(base *schema.Event, command, cwd string, dialect Dialect) []*schema.Event {
+	var events []*schema.Event
+	var ops []GitOperation
+	for _, cmd := range ParseCommandLine(command, dialect) {
+		gitOp, ok := gitOperation(cmd, dialect)
+		if !ok {
+			events = append(events, d.buildFileEvents(base, cwd, fileOperations(cmd, dialect))...)
+			continue
+		}
 
-	// Check if git add is in the command chain
-	if IsGitAddCommand(command) {
-		// Get pending files that would be added
-		pendingFiles := d.gitProvider.GetPendingFiles(cwd, command)
-		stagedFiles = mergeFiles(stagedFiles, pendingFiles)
+		for _, op := range d.resolveAlias(gitOp, cwd, map[string]bool{}) {
+			switch op.Subcommand {
+			case "commit":
+				event := *base
+				event.Commit = d.buildCommitEvent(cwd, ops, op)
+				events = append(events, &event)
+			case "push":
+				events = append(events, d.buildPushEvents(base, cwd, ops, op)...)
+			case "rm", "mv":
+				events = append(events, d.buildFileEvents(base, cwd, gitFileOperations(op))...)
+			}
+			ops = append(ops, op)
+		}
 	}
+	return events
+}
+
+// resolveAlias returns the git operations an operation runs once git aliases are
+// expanded. An alias of a subcommand runs it with the alias's arguments before the
+// ones given. An alias starting with "!" runs a shell command from the top of the
+// repository, with the arguments given added to its last command. seen holds the
+// aliases already expanded, so aliases that refer to each other stop.
+func (d *Detector) resolveAlias(op GitOperation, cwd string, seen map[string]bool) []GitOperation {
+	aliases := d.gitProvider.GetAliases(op.WorkDir(cwd))
+	for {
+		value, ok := aliases[op.Subcommand]
+		if !ok || seen[op.Subcommand] {
+			return []GitOperation{op}
+		}
+		seen[op.Subcommand] = true
 
-	event.Commit = &schema.CommitEvent{
-		SHA:     "pending",
-		Message: ExtractCommitMessage(command),
-		Author:  d.gitProvider.GetAuthor(cwd),
-		Files:   stagedFiles,
+		if script, ok := strings.CutPrefix(value, "!"); ok {
+			return d.shellAliasOperations(op, script, cwd, seen)
+		}
+		commands := ParseCommandLine(value, DialectPOSIX)
+		if len(commands) == 0 {
+			return []GitOperation{op}
+		}
+		words := commands[0].Args
+		op.Subcommand = words[0]
+		op.Args = append(append([]string{}, words[1:]...), op.Args...)
 	}
 }
 
-// buildPushEvent builds a push event from a git push command
-func (d *Detector) buildPushEvent(event *schema.Event, command, cwd string) {
-	branch := d.gitProvider.GetBranch(cwd)
+// shellAliasOperations returns the git operations the shell command of an alias
+// runs
+func (d *Detector) shellAliasOperations(op GitOperation, script, cwd string, seen map[string]bool) []GitOperation {
+	dir := op.Dir
+	if root := d.gitProvider.GetRepoRoot(op.WorkDir(cwd)); root != "" {
+		dir = root
+	}
 
-	event.Push = &schema.PushEvent{
-		Ref:    ExtractPushRef(command, branch),
-		Before: "",
-		After:  "",
+	commands := ParseCommandLine(script, DialectPOSIX)
+	if len(commands) > 0 {
+		last := &commands[len(commands)-1]
+		last.Args = append(last.Args, op.Args...)
 	}
-}
 
-// detectCreateEvent handles file creation
-func (d *Detector) detectCreateEvent(event *schema.Event, args *ToolArgs) {
-	event.File = &schema.FileEvent{
-		Path:    args.Path,
-		Action:  "create",
-		Content: args.FileText,
+	var ops []GitOperation
+	for _, cmd := range commands {
+		cmd.Dir = joinDir(dir, cmd.Dir)
+		if inner, ok := gitOperation(cmd, DialectPOSIX); ok {
+			ops = append(ops, d.resolveAlias(inner, cwd, seen)...)
+		}
 	}
+	return ops
 }
 
-// detectEditEvent handles file edits
-func (d *Detector) detectEditEvent(event *schema.Event, args *ToolArgs) {
-	event.File = &schema.FileEvent{
-		Path:   args.Path,
-		Action: "edit",
+// buildFileEvents builds a file event for each file the operations delete or rename
+func (d *Detector) buildFileEvents(base *schema.Event, cwd string, ops []FileOperation) []*schema.Event {
+	var events []*schema.Event
+	repoPath := d.repoPaths(cwd)
+	for _, op := range ops {
+		for _, file := range expandFileOperation(op, cwd) {
+			event := *base
+			event.File = &schema.FileEvent{
+				Path:    repoPath(file.Path),
+				Action:  file.Action,
+				OldPath: repoPath(file.OldPath),
+				Tree:    file.Tree,
+			}
+			events = append(events, &event)
+		}
 	}
+	return events
 }
 
-// Git command detection patterns
-var (
-	// Matches git commit at start or after command separators, handles flags like -C, --no-pager
-	gitCommitPattern = regexp.MustCompile(`(?:^|&&|\|\||;)\s*git\b.*\bcommit\b`)
-
-	// Matches git push at start or after command separators
-	gitPushPattern = regexp.MustCompile(`(?:^|&&|\|\||;)\s*git\b.*\bpush\b`)
-
-	// Matches git add at start or after command separators
-	gitAddPattern = regexp.MustCompile(`(?:^|&&|\|\||;)\s*git\b.*\badd\b`)
+// buildCommitEvent builds a commit event from a git commit operation. before holds
+// the operations that run ahead of it in the same command line.
+func (d *Detector) buildCommitEvent(cwd string, before []GitOperation, op GitOperation) *schema.CommitEvent {
+	dir := op.WorkDir(cwd)
+	opts := parseCommitOptions(op.Args)
+
+	// Files staged by git add in the command chain aren't staged yet when the hook
+	// fires. Adds before an earlier commit went into that commit instead, and adds
+	// in another repository stage into its own index.
+	root := d.gitProvider.GetRepoRoot(dir)
+	pending := PendingCommit{All: opts.all, Pathspecs: opts.pathspecs, Include: opts.include}
+	for _, earlier := range before {
+		if earlier.Subcommand != "commit" && earlier.Subcommand != "add" {
+			continue
+		}
+		if d.gitProvider.GetRepoRoot(earlier.WorkDir(cwd)) != root {
+			continue
+		}
+		if earlier.Subcommand == "commit" {
+			pending.Adds = nil
+		} else {
+			pending.Adds = append(pending.Adds, PendingAdd{Dir: earlier.WorkDir(cwd), Args: earlier.Args})
+		}
+	}
 
-	// Extracts commit message from -m flag
-	commitMessagePattern = regexp.MustCompile(`-m\s+["']([^"']+)["']|-m\s+(\S+)`)
+	var files []schema.FileStatus
+	if pending.stagesFiles() {
+		files = d.gitProvider.GetPendingFiles(dir, pending)
+	} else {
+		files = d.gitProvider.GetStagedFiles(dir)
+	}
 
-	// Extracts tag from git push command
-	tagPushPattern = regexp.MustCompile(`git\s+push\s+\S+\s+(v[\d.]+|refs/tags/\S+)`)
+	return &schema.CommitEvent{
+		SHA:        "pending",
+		Message:    d.commitMessage(opts, op.Input, dir),
+		Author:     d.gitProvider.GetAuthor(dir),
+		Branch:     d.gitProvider.GetBranch(dir),
+		Files:      files,
+		Amend:      opts.amend,
+		Fixup:      opts.fixup != "",
+		AllowEmpty: opts.allowEmpty,
+		NoVerify:   opts.noVerify,
+	}
+}
 
-	// Extracts files from git add command
-	gitAddFilesPattern = regexp.MustCompile(`git\s+add\s+(.+?)(?:&&|\|\||;|$)`)
-)
+// commitMessage returns the message a commit will have, reading it from a file or
+// an earlier commit when it is not given on the command line. An amend without a
+// new message keeps the message of the commit being amended.
+func (d *Detector) commitMessage(opts commitOptions, input, dir string) string {
+	if message, ok := opts.inlineMessage(input); ok {
+		return message
+	}
 
-// IsGitCommitCommand checks if a shell command contains a git commit
-func IsGitCommitCommand(command string) bool {
-	// Also match if command starts with git (no separator before)
-	if strings.HasPrefix(strings.TrimSpace(command), "git") {
-		if regexp.MustCompile(`^git\b.*\bcommit\b`).MatchString(strings.TrimSpace(command)) {
-			return true
+	switch {
+	case opts.fixup != "" || opts.squash != "":
+		return d.fixupMessage(opts, dir)
+	case opts.file != "":
+		path := opts.file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return ""
 		}
+		return cleanupMessage(string(content))
+	case opts.reuse != "":
+		return d.gitProvider.GetCommitMessage(dir, opts.reuse)
+	case opts.amend:
+		return d.gitProvider.GetCommitMessage(dir, "HEAD")
 	}
-	return gitCommitPattern.MatchString(command)
+	return ""
 }
 
-// IsGitPushCommand checks if a shell command contains a git push
-func IsGitPushCommand(command string) bool {
-	if strings.HasPrefix(strings.TrimSpace(command), "git") {
-		if regexp.MustCompile(`^git\b.*\bpush\b`).MatchString(strings.TrimSpace(command)) {
-			return true
+// fixupMessage builds the message git gives --fixup and --squash commits: the
+// subject of the target commit after "fixup! ", "amend! " or "squash! ", followed
+// by any -m paragraphs. amend: and reword: fixups carry the target's message as
+// their body unless a new one is given.
+func (d *Detector) fixupMessage(opts commitOptions, dir string) string {
+	prefix, target := "squash! ", opts.squash
+	if opts.fixup != "" {
+		prefix, target = "fixup! ", opts.fixup
+		if kind, rev, found := strings.Cut(opts.fixup, ":"); found && (kind == "amend" || kind == "reword") {
+			prefix, target = "amend! ", rev
 		}
 	}
-	return gitPushPattern.MatchString(command)
+
+	original := d.gitProvider.GetCommitMessage(dir, target)
+	subject, _, _ := strings.Cut(original, "\n")
+	paragraphs := []string{prefix + subject}
+	switch {
+	case len(opts.messages) > 0:
+		paragraphs = append(paragraphs, opts.messages...)
+	case prefix == "amend! ":
+		paragraphs = append(paragraphs, original)
+	}
+	return cleanupMessage(strings.Join(paragraphs, "\n\n"))
 }
 
-// IsGitAddCommand checks if a shell command contains a git add
-func IsGitAddCommand(command string) bool {
-	if strings.HasPrefix(strings.TrimSpace(command), "git") {
-		if regexp.MustCompile(`^git\b.*\badd\b`).MatchString(strings.TrimSpace(command)) {
-			return true
+// buildPushEvents builds a push event for each ref a git push operation updates.
+// ops holds the operations that run ahead of it in the same command line.
+func (d *Detector) buildPushEvents(base *schema.Event, cwd string, ops []GitOperation, op GitOperation) []*schema.Event {
+	dir := op.WorkDir(cwd)
+	branch := d.gitProvider.GetBranch(dir)
+
+	// Commits made earlier in the command line don't exist yet when the hook fires,
+	// so they are added to pushes of the branch they are made on, newest first
+	var pending []schema.CommitEvent
+	for i, earlier := range ops {
+		if earlier.Subcommand == "commit" && earlier.WorkDir(cwd) == dir {
+			pending = append([]schema.CommitEvent{*d.buildCommitEvent(cwd, ops[:i], earlier)}, pending...)
 		}
 	}
-	return gitAddPattern.MatchString(command)
-}
 
-// ExtractCommitMessage extracts the commit message from a git commit command
-func ExtractCommitMessage(command string) string {
-	matches := commitMessagePattern.FindStringSubmatch(command)
-	if len(matches) >= 2 {
-		if matches[1] != "" {
-			return matches[1]
+	var events []*schema.Event
+	resolveRef := func(name string) string { return d.gitProvider.ResolveRef(dir, name) }
+	listRefs := func(prefix string) []string { return d.gitProvider.ListRefs(dir, prefix) }
+	for _, spec := range pushRefspecs(op.Args, branch, resolveRef, listRefs) {
+		before, after, commits := d.gitProvider.GetOutgoingCommits(dir, spec)
+		unknown := commits == nil
+		if len(pending) > 0 && pushesBranch(spec, branch) {
+			commits = append(append([]schema.CommitEvent{}, pending...), commits...)
 		}
-		if len(matches) >= 3 && matches[2] != "" {
-			return matches[2]
+		event := *base
+		event.Push = &schema.PushEvent{
+			Ref:            spec.Dst,
+			Before:         before,
+			After:          after,
+			Commits:        commits,
+			CommitsUnknown: unknown,
 		}
+		events = append(events, &event)
 	}
-	return ""
+	return events
 }
 
-// ExtractPushRef determines the ref being pushed
-func ExtractPushRef(command string, currentBranch string) string {
-	// Check if pushing a tag
-	matches := tagPushPattern.FindStringSubmatch(command)
-	if len(matches) >= 2 {
-		tag := matches[1]
-		if !strings.HasPrefix(tag, "refs/") {
-			return "refs/tags/" + tag
-		}
-		return tag
+// pushesBranch reports whether a refspec pushes the current branch
+func pushesBranch(spec PushRefspec, branch string) bool {
+	switch spec.Src {
+	case "":
+		return false
+	case "HEAD":
+		return true
+	}
+	return branch != "" && (spec.Src == branch || spec.Src == "refs/heads/"+branch)
+}
+
+// detectCreateEvent handles file creation
+func (d *Detector) detectCreateEvent(event *schema.Event, args *ToolArgs, cwd string) {
+	repoPath := d.repoPaths(cwd)
+	event.File = &schema.FileEvent{
+		Path:    repoPath(args.Path),
+		Action:  "create",
+		Content: args.FileText,
+	}
+}
+
+// detectEditEvent handles file edits
+func (d *Detector) detectEditEvent(event *schema.Event, args *ToolArgs, cwd string) {
+	repoPath := d.repoPaths(cwd)
+	event.File = &schema.FileEvent{
+		Path:   repoPath(args.Path),
+		Action: "edit",
 	}
+}
 
-	// Default to current branch
-	if currentBranch != "" {
-		return "refs/heads/" + currentBranch
+// detectDeleteEvent handles file deletion
+func (d *Detector) detectDeleteEvent(event *schema.Event, args *ToolArgs, cwd string) {
+	repoPath := d.repoPaths(cwd)
+	event.File = &schema.FileEvent{
+		Path:   repoPath(args.Path),
+		Action: "delete",
 	}
-	return "refs/heads/main"
 }
 
-// ExtractGitAddFiles extracts file patterns from a git add command
-func ExtractGitAddFiles(command string) []string {
-	matches := gitAddFilesPattern.FindStringSubmatch(command)
-	if len(matches) >= 2 {
-		// Split by spaces, excluding flags
-		parts := strings.Fields(matches[1])
-		var files []string
-		for _, p := range parts {
-			if !strings.HasPrefix(p, "-") {
-				files = append(files, p)
-			}
+// detectRenameEvent handles renaming or moving a file from path to new_path
+func (d *Detector) detectRenameEvent(event *schema.Event, args *ToolArgs, cwd string) {
+	repoPath := d.repoPaths(cwd)
+	event.File = &schema.FileEvent{
+		Path:    repoPath(args.NewPath),
+		Action:  "rename",
+		OldPath: repoPath(args.Path),
+	}
+}
+
+// repoPaths returns a function that makes paths relative to the root of the
+// repository containing cwd, so path filters see the same path whichever
+// directory a command runs in. Relative paths are resolved against cwd first.
+// Outside a repository paths are made relative to cwd, and paths outside the
+// repository stay absolute. Paths use forward slashes on every platform, like the
+// globs of path filters.
+func (d *Detector) repoPaths(cwd string) func(path string) string {
+	if cwd == "" {
+		return filepath.ToSlash
+	}
+
+	// git reports the root with symlinks resolved, so cwd's place in the repository
+	// is found the same way
+	prefix := ""
+	if root := d.gitProvider.GetRepoRoot(cwd); root != "" {
+		dir := cwd
+		if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(root, dir); err == nil && !isOutside(rel) {
+			prefix = rel
+		}
+	}
+
+	return func(path string) string {
+		if path == "" {
+			return ""
+		}
+		abs := absPath(path, cwd)
+		rel, err := filepath.Rel(cwd, abs)
+		if err != nil {
+			return filepath.ToSlash(abs)
+		}
+		if rel = filepath.Join(prefix, rel); isOutside(rel) {
+			return filepath.ToSlash(abs)
 		}
-		return files
+		return filepath.ToSlash(rel)
 	}
-	return nil
 }
 
 // mergeFiles merges two file lists, deduplicating by path
@@ -2,6 +2,10 @@ package event
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
@@ -25,7 +29,7 @@ func TestIsGitCommitCommand(t *testing.T) {
 		{"commit in chain", "git add . && git commit -m 'msg'", true},
 		{"commit after or", "git status || git commit", true},
 		{"commit after semicolon", "echo done; git commit -m 'msg'", true},
-		{"commit ci alias", "git ci -m 'msg'", false}, // ci is not always an alias
+		{"commit ci alias", "git ci -m 'msg'", false}, // Aliases need the repository; see TestDetectAll
 		{"chained with add", "git add -A && git commit -m 'test'", true},
 		{"triple chain", "npm test && git add . && git commit -m 'test'", true},
 
@@ -130,8 +134,16 @@ func TestExtractCommitMessage(t *testing.T) {
 		{"multiword no quotes", "git commit -m fix-bug", "fix-bug"},
 		{"amend with message", `git commit --amend -m "updated"`, "updated"},
 		{"no message flag", "git commit", ""},
-		{"empty message", `git commit -m ""`, `""`}, // Edge case: returns the quotes
+		{"empty message", `git commit -m ""`, ""},
 		{"chained command", `git add . && git commit -m "test"`, "test"},
+		{"multiple -m", `git commit -m "feat: x" -m "Longer body." -m "Refs: #1"`, "feat: x\n\nLonger body.\n\nRefs: #1"},
+		{"long option", `git commit --message="fix: y"`, "fix: y"},
+		{"attached value", `git commit -m'fix: z'`, "fix: z"},
+		{"flag cluster", `git commit -am "all"`, "all"},
+		{"heredoc substitution", "git commit -m \"$(cat <<'EOF'\nfeat: add\n\nDetails here.\nEOF\n)\"", "feat: add\n\nDetails here."},
+		{"message on stdin", "git commit -F - <<EOF\nfix: stdin\n\n\nbody   \nEOF", "fix: stdin\n\nbody"},
+		{"message from file", "git commit -F msg.txt", ""}, // Needs the repository; see TestCommitMessageSources
+		{"amend without message", "git commit --amend --no-edit", ""},
 	}
 
 	for _, tt := range tests {
@@ -155,13 +167,27 @@ func TestExtractPushRef(t *testing.T) {
 		{"simple push", "git push", "main", "refs/heads/main"},
 		{"push tag", "git push origin v1.0.0", "main", "refs/tags/v1.0.0"},
 		{"push tag with prefix", "git push origin refs/tags/v2.0.0", "main", "refs/tags/v2.0.0"},
-		{"push no branch", "git push", "", "refs/heads/main"},
+		{"push no branch", "git push", "", ""},
+		{"push HEAD with no branch", "git push origin HEAD", "", ""},
 		{"push with branch", "git push origin feature", "feature", "refs/heads/feature"},
+		{"branch named like a version", "git push origin v2", "main", "refs/heads/v2"},
+		{"tag not named like a version", "git push origin release-2026", "main", "refs/tags/release-2026"},
+		{"unknown name", "git push origin unknown", "main", "refs/heads/unknown"},
+		{"delete", "git push origin --delete feature", "main", "refs/heads/feature"},
+		{"tags without refs to list", "git push --tags", "main", ""},
 	}
 
+	refs := map[string]string{
+		"v1.0.0":       "refs/tags/v1.0.0",
+		"feature":      "refs/heads/feature",
+		"v2":           "refs/heads/v2",
+		"release-2026": "refs/tags/release-2026",
+	}
+	resolveRef := func(name string) string { return refs[name] }
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := ExtractPushRef(tt.command, tt.currentBranch)
+			got := ExtractPushRef(tt.command, tt.currentBranch, resolveRef)
 			if got != tt.want {
 				t.Errorf("ExtractPushRef(%q, %q) = %q, want %q", tt.command, tt.currentBranch, got, tt.want)
 			}
@@ -169,6 +195,46 @@ func TestExtractPushRef(t *testing.T) {
 	}
 }
 
+func TestPushRefspecs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []PushRefspec
+	}{
+		{nil, []PushRefspec{{Src: "HEAD", Dst: "refs/heads/feature"}}},
+		{[]string{"upstream"}, []PushRefspec{{Remote: "upstream", Src: "HEAD", Dst: "refs/heads/feature"}}},
+		{[]string{"origin", "other:main"}, []PushRefspec{{Remote: "origin", Src: "other", Dst: "refs/heads/main"}}},
+		{[]string{"-f", "origin", "+HEAD:release", "main"}, []PushRefspec{{Remote: "origin", Src: "HEAD", Dst: "refs/heads/release"}, {Remote: "origin", Src: "main", Dst: "refs/heads/main"}}},
+		{[]string{"origin", ":old"}, []PushRefspec{{Remote: "origin", Dst: "refs/heads/old"}}},
+		{[]string{"origin", ":v0.9"}, []PushRefspec{{Remote: "origin", Dst: "refs/tags/v0.9"}}},
+		{[]string{"origin", "v1.0:v1.0-final"}, []PushRefspec{{Remote: "origin", Src: "v1.0", Dst: "refs/tags/v1.0-final"}}},
+		{[]string{"origin", "v1.0:refs/heads/release"}, []PushRefspec{{Remote: "origin", Src: "v1.0", Dst: "refs/heads/release"}}},
+		{[]string{"origin", "--delete", "feature", "v0.9"}, []PushRefspec{{Remote: "origin", Dst: "refs/heads/feature"}, {Remote: "origin", Dst: "refs/tags/v0.9"}}},
+		{[]string{"-d", "origin", "old"}, []PushRefspec{{Remote: "origin", Dst: "refs/heads/old"}}},
+		{[]string{"-fd", "origin", "old"}, []PushRefspec{{Remote: "origin", Dst: "refs/heads/old"}}},
+		{[]string{"-odeploy", "origin", "main"}, []PushRefspec{{Remote: "origin", Src: "main", Dst: "refs/heads/main"}}},
+		{[]string{"--tags"}, []PushRefspec{{Src: "refs/tags/v0.9", Dst: "refs/tags/v0.9"}, {Src: "refs/tags/v1.0", Dst: "refs/tags/v1.0"}}},
+		{[]string{"origin", "main", "--tags"}, []PushRefspec{
+			{Remote: "origin", Src: "main", Dst: "refs/heads/main"},
+			{Remote: "origin", Src: "refs/tags/v0.9", Dst: "refs/tags/v0.9"},
+			{Remote: "origin", Src: "refs/tags/v1.0", Dst: "refs/tags/v1.0"},
+		}},
+		{[]string{"--all", "origin"}, []PushRefspec{{Remote: "origin", Src: "refs/heads/main", Dst: "refs/heads/main"}}},
+		{[]string{"--mirror", "backup"}, []PushRefspec{
+			{Remote: "backup", Src: "refs/heads/main", Dst: "refs/heads/main"},
+			{Remote: "backup", Src: "refs/tags/v0.9", Dst: "refs/tags/v0.9"},
+			{Remote: "backup", Src: "refs/tags/v1.0", Dst: "refs/tags/v1.0"},
+		}},
+	}
+
+	mock := &MockGitProvider{Refs: map[string]string{"v0.9": "refs/tags/v0.9", "v1.0": "refs/tags/v1.0", "main": "refs/heads/main"}}
+	for _, tt := range tests {
+		got := pushRefspecs(tt.args, "feature", func(name string) string { return mock.Refs[name] }, func(prefix string) []string { return mock.ListRefs("", prefix) })
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expected pushRefspecs(%q) to be %+v, got %+v", tt.args, tt.want, got)
+		}
+	}
+}
+
 // TestExtractGitAddFiles tests git add file pattern extraction
 func TestExtractGitAddFiles(t *testing.T) {
 	tests := []struct {
@@ -214,6 +280,11 @@ func TestDetector(t *testing.T) {
 		},
 		Remote: "origin",
 		Ahead:  2,
+		Before: "aaa111",
+		After:  "bbb222",
+		OutgoingCommits: []schema.CommitEvent{
+			{SHA: "bbb222", Message: "add migration", Files: []schema.FileStatus{{Path: "migrations/001.sql", Status: "added"}}},
+		},
 	}
 
 	detector := NewDetector(mock)
@@ -239,6 +310,9 @@ func TestDetector(t *testing.T) {
 		if evt.Commit.Author != "test@example.com" {
 			t.Errorf("Author = %q, want %q", evt.Commit.Author, "test@example.com")
 		}
+		if evt.Commit.Branch != "main" {
+			t.Errorf("Branch = %q, want %q", evt.Commit.Branch, "main")
+		}
 		if len(evt.Commit.Files) != 1 {
 			t.Errorf("Files count = %d, want 1", len(evt.Commit.Files))
 		}
@@ -283,6 +357,12 @@ func TestDetector(t *testing.T) {
 		if evt.Push.Ref != "refs/heads/main" {
 			t.Errorf("Ref = %q, want %q", evt.Push.Ref, "refs/heads/main")
 		}
+		if evt.Push.Before != "aaa111" || evt.Push.After != "bbb222" {
+			t.Errorf("Before/After = %q/%q, want aaa111/bbb222", evt.Push.Before, evt.Push.After)
+		}
+		if len(evt.Push.Commits) != 1 || evt.Push.Commits[0].Files[0].Path != "migrations/001.sql" {
+			t.Errorf("Commits = %+v, want one commit touching migrations/001.sql", evt.Push.Commits)
+		}
 	})
 
 	t.Run("file create detection", func(t *testing.T) {
@@ -331,6 +411,25 @@ func TestDetector(t *testing.T) {
 		}
 	})
 
+	t.Run("file delete and rename detection", func(t *testing.T) {
+		evt, err := detector.DetectFromRawInput([]byte(`{"toolName": "delete", "toolArgs": {"path": "src/old.ts"}, "cwd": "/test/repo"}`))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.File == nil || evt.File.Path != "src/old.ts" || evt.File.Action != "delete" {
+			t.Errorf("Expected delete of src/old.ts, got %+v", evt.File)
+		}
+
+		evt, err = detector.DetectFromRawInput([]byte(`{"toolName": "rename", "toolArgs": {"path": "src/a.ts", "new_path": "src/b.ts"}, "cwd": "/test/repo"}`))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		want := &schema.FileEvent{Path: "src/b.ts", Action: "rename", OldPath: "src/a.ts"}
+		if !reflect.DeepEqual(evt.File, want) {
+			t.Errorf("Expected %+v, got %+v", want, evt.File)
+		}
+	})
+
 	t.Run("non-git shell command", func(t *testing.T) {
 		input := `{
 			"toolName": "powershell",
@@ -487,3 +586,588 @@ func TestDetectorWithRealInput(t *testing.T) {
 		t.Errorf("Cwd = %q, want Windows path", evt.Cwd)
 	}
 }
+
+// TestDetectHookTypes tests preToolUse/postToolUse detection and tool results
+func TestDetectHookTypes(t *testing.T) {
+	detector := NewDetector(&MockGitProvider{
+		Branch:      "main",
+		StagedFiles: []schema.FileStatus{{Path: "a.go", Status: "modified"}},
+	})
+
+	t.Run("defaults to preToolUse", func(t *testing.T) {
+		evt, err := detector.DetectFromRawInput([]byte(`{"toolName": "edit", "toolArgs": {"path": "a.go"}, "cwd": "/repo"}`))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Hook == nil || evt.Hook.Type != HookTypePreToolUse {
+			t.Fatalf("Expected preToolUse hook event, got %+v", evt.Hook)
+		}
+		if evt.Hook.Tool != evt.Tool {
+			t.Error("Expected hook tool to be the tool event")
+		}
+		if evt.Hook.Cwd != "/repo" {
+			t.Errorf("Hook cwd = %q, want /repo", evt.Hook.Cwd)
+		}
+		if evt.File == nil {
+			t.Error("Expected file event for preToolUse edit")
+		}
+	})
+
+	t.Run("postToolUse inferred from tool result", func(t *testing.T) {
+		input := `{
+			"toolName": "bash",
+			"toolArgs": {"command": "git commit -m 'done'"},
+			"toolResult": {"resultType": "failure", "textResultForLlm": "nothing to commit", "exitCode": 1},
+			"cwd": "/repo"
+		}`
+		evt, err := detector.DetectFromRawInput([]byte(input))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Hook == nil || evt.Hook.Type != HookTypePostToolUse {
+			t.Fatalf("Expected postToolUse hook event, got %+v", evt.Hook)
+		}
+		if evt.Tool.HookType != HookTypePostToolUse {
+			t.Errorf("Tool hook type = %q, want postToolUse", evt.Tool.HookType)
+		}
+		if evt.Tool.Result == nil {
+			t.Fatal("Expected tool result")
+		}
+		if evt.Tool.Result.Type != "failure" || evt.Tool.Result.Output != "nothing to commit" {
+			t.Errorf("Unexpected tool result: %+v", evt.Tool.Result)
+		}
+		if evt.Tool.Result.ExitCode == nil || *evt.Tool.Result.ExitCode != 1 {
+			t.Errorf("Expected exit code 1, got %v", evt.Tool.Result.ExitCode)
+		}
+		if evt.Commit != nil {
+			t.Error("Did not expect commit event for postToolUse")
+		}
+	})
+
+	t.Run("explicit postToolUse", func(t *testing.T) {
+		evt, err := detector.DetectFromRawInput([]byte(`{"hookType": "postToolUse", "toolName": "edit", "toolArgs": {"path": "a.go"}}`))
+		if err != nil {
+			t.Fatalf("DetectFromRawInput failed: %v", err)
+		}
+		if evt.Hook.Type != HookTypePostToolUse {
+			t.Errorf("Hook type = %q, want postToolUse", evt.Hook.Type)
+		}
+		if evt.File != nil {
+			t.Error("Did not expect file event for postToolUse")
+		}
+	})
+
+	t.Run("unsupported hook type", func(t *testing.T) {
+		_, err := detector.DetectFromRawInput([]byte(`{"hookType": "sessionStart", "toolName": "edit"}`))
+		if err == nil {
+			t.Error("Expected error for unsupported hook type")
+		}
+	})
+}
+
+// TestGitExpressionContext tests the git context exposed to expressions
+func TestGitExpressionContext(t *testing.T) {
+	detector := NewDetector(&MockGitProvider{
+		Branch:  "feature/x",
+		Author:  "dev@example.com",
+		Ahead:   3,
+		Behind:  1,
+		HeadSHA: "abc123",
+	})
+
+	got := detector.GitExpressionContext("/repo")
+	want := map[string]interface{}{
+		"branch": "feature/x",
+		"remote": "origin",
+		"ahead":  int64(3),
+		"behind": int64(1),
+		"sha":    "abc123",
+		"author": "dev@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GitExpressionContext() = %v, want %v", got, want)
+	}
+}
+
+// dirRecordingProvider records the directories git context is gathered from
+type dirRecordingProvider struct {
+	MockGitProvider
+	dirs []string
+}
+
+func (p *dirRecordingProvider) GetBranch(cwd string) string {
+	p.dirs = append(p.dirs, cwd)
+	return p.MockGitProvider.GetBranch(cwd)
+}
+
+func TestDetectShellEventParsesCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		toolName  string
+		command   string
+		wantEvent string // "commit", "push" or ""
+		wantDir   string
+		wantMsg   string
+	}{
+		{"cd before commit", "bash", "cd sub && git commit -m 'in sub'", "commit", "/repo/sub", "in sub"},
+		{"-C before push", "bash", "git -C ../other push origin main", "push", "/other", ""},
+		{"quoted git commit", "bash", `echo "git commit -m x"`, "", "", ""},
+		{"heredoc mentions push", "bash", "cat <<EOF\ngit push\nEOF", "", "", ""},
+		{"commit in pipeline", "bash", "git status | grep x; git commit -m 'later'", "commit", "/repo", "later"},
+		{"powershell quoting", "powershell", "Set-Location sub; git commit -m \"say ``hi``\"", "commit", "/repo/sub", "say `hi`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &dirRecordingProvider{MockGitProvider: MockGitProvider{Branch: "main"}}
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			evt, err := NewDetector(provider).Detect(&RawHookInput{ToolName: tt.toolName, ToolArgs: args, Cwd: "/repo"})
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+
+			switch tt.wantEvent {
+			case "commit":
+				if evt.Commit == nil {
+					t.Fatal("Expected commit event, got nil")
+				}
+				if evt.Commit.Message != tt.wantMsg {
+					t.Errorf("Expected message %q, got %q", tt.wantMsg, evt.Commit.Message)
+				}
+			case "push":
+				if evt.Push == nil {
+					t.Fatal("Expected push event, got nil")
+				}
+			default:
+				if evt.Commit != nil || evt.Push != nil {
+					t.Fatalf("Expected no git event, got commit %v push %v", evt.Commit, evt.Push)
+				}
+				return
+			}
+			if len(provider.dirs) == 0 || provider.dirs[0] != tt.wantDir {
+				t.Errorf("Expected git context from %q, got %v", tt.wantDir, provider.dirs)
+			}
+		})
+	}
+}
+
+func TestDetectAll(t *testing.T) {
+	mock := &MockGitProvider{
+		Branch: "feature", Author: "dev@example.com", Before: "aaa111", After: "bbb222",
+		Refs:    map[string]string{"v1.2.0": "refs/tags/v1.2.0"},
+		Aliases: map[string]string{"ci": "commit", "pf": "!git push --force", "up": "pf", "self": "self"},
+	}
+	detector := NewDetector(mock)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string // "commit: <message>", "push: <ref>" or "<action>: <path>" for each event, or "tool"
+	}{
+		{"git ci alias", "git ci -m 'test'", []string{"commit: test"}},
+		{"shell alias", "git pf origin main", []string{"push: refs/heads/main"}},
+		{"alias of an alias", "git up", []string{"push: refs/heads/feature"}},
+		{"alias of itself", "git self && git ci -m x", []string{"commit: x"}},
+		{"shell script", "bash -c 'git commit -m wrapped && git push'", []string{"commit: wrapped", "push: refs/heads/feature"}},
+		{"push tags", "git push --tags", []string{"push: refs/tags/v1.2.0"}},
+		{"commit then push", "git commit -m 'fix: x' && git push", []string{"commit: fix: x", "push: refs/heads/feature"}},
+		{"push before commit", "git push; git commit -m later", []string{"push: refs/heads/feature", "commit: later"}},
+		{"several refspecs", "git push origin main v1.2.0 HEAD:refs/heads/release", []string{"push: refs/heads/main", "push: refs/tags/v1.2.0", "push: refs/heads/release"}},
+		{"two commits", "git commit -m one && git commit --amend -m two", []string{"commit: one", "commit: two"}},
+		{"no git operations", "npm test", []string{"tool"}},
+		{"file operations", "git rm -q old.go && git commit -m drop && mv a.txt b.txt", []string{"delete: old.go", "commit: drop", "rename: a.txt -> b.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			events, err := detector.DetectAll(&RawHookInput{ToolName: "bash", ToolArgs: args, Cwd: "/repo"})
+			if err != nil {
+				t.Fatalf("DetectAll() error = %v", err)
+			}
+
+			var got []string
+			for _, evt := range events {
+				if evt.Tool == nil || evt.Tool.Name != "bash" || evt.Hook == nil {
+					t.Errorf("Expected every event to carry the tool and hook, got %+v", evt)
+				}
+				switch {
+				case evt.Commit != nil:
+					got = append(got, "commit: "+evt.Commit.Message)
+				case evt.Push != nil:
+					got = append(got, "push: "+evt.Push.Ref)
+				case evt.File != nil && evt.File.OldPath != "":
+					got = append(got, evt.File.Action+": "+evt.File.OldPath+" -> "+evt.File.Path)
+				case evt.File != nil:
+					got = append(got, evt.File.Action+": "+evt.File.Path)
+				default:
+					got = append(got, "tool")
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected events %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// Detect keeps returning the first event
+	args, _ := json.Marshal(map[string]string{"command": "git commit -m first && git push"})
+	evt, err := detector.Detect(&RawHookInput{ToolName: "bash", ToolArgs: args})
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if evt.Commit == nil || evt.Push != nil {
+		t.Errorf("Expected Detect to return only the commit event, got commit %v push %v", evt.Commit, evt.Push)
+	}
+}
+
+func TestDetectPushCommitsUnknown(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []schema.CommitEvent
+		want    bool
+	}{
+		{"commits listed", []schema.CommitEvent{{SHA: "aaa111"}}, false},
+		{"nothing to push", []schema.CommitEvent{}, false},
+		{"commits not determined", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewDetector(&MockGitProvider{Branch: "feature", OutgoingCommits: tt.commits})
+			evt, err := detector.DetectFromRawInput([]byte(`{"toolName": "bash", "toolArgs": {"command": "git push origin :feature"}, "cwd": "/repo"}`))
+			if err != nil {
+				t.Fatalf("DetectFromRawInput() error = %v", err)
+			}
+			if evt.Push == nil || evt.Push.CommitsUnknown != tt.want {
+				t.Errorf("Expected CommitsUnknown %v, got %+v", tt.want, evt.Push)
+			}
+		})
+	}
+}
+
+func TestDetectPushIncludesEarlierCommits(t *testing.T) {
+	mock := &MockGitProvider{
+		Branch:          "feature",
+		StagedFiles:     []schema.FileStatus{{Path: "src/app.go", Status: "modified"}},
+		PendingFiles:    []schema.FileStatus{{Path: "go.mod", Status: "modified"}},
+		OutgoingCommits: []schema.CommitEvent{{SHA: "aaa111", Files: []schema.FileStatus{{Path: "README.md", Status: "modified"}}}},
+		Refs:            map[string]string{"v1": "refs/tags/v1"},
+	}
+	detector := NewDetector(mock)
+
+	tests := []struct {
+		name    string
+		command string
+		want    []string // Files of each commit in the push, commits separated by "|"
+	}{
+		{"push alone", "git push", []string{"README.md"}},
+		{"commit then push", "git commit -m x && git push", []string{"src/app.go", "README.md"}},
+		{"add, commit then push", "git add go.mod && git commit -m x && git push origin feature", []string{"src/app.go,go.mod", "README.md"}},
+		{"two commits then push", "git commit -m one && git add go.mod && git commit -m two && git push", []string{"src/app.go,go.mod", "src/app.go", "README.md"}},
+		{"push of a tag", "git commit -m x && git push origin v1", []string{"README.md"}},
+		{"commit in another directory", "(cd other && git commit -m x) && git push", []string{"README.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			events, err := detector.DetectAll(&RawHookInput{ToolName: "bash", ToolArgs: args, Cwd: "/repo"})
+			if err != nil {
+				t.Fatalf("DetectAll() error = %v", err)
+			}
+			push := events[len(events)-1].Push
+			if push == nil {
+				t.Fatalf("Expected the last event to be a push, got %+v", events[len(events)-1])
+			}
+
+			var got []string
+			for _, commit := range push.Commits {
+				var files []string
+				for _, f := range commit.Files {
+					files = append(files, f.Path)
+				}
+				got = append(got, strings.Join(files, ","))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected push commit files %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectFileOperations(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"config/prod.yml", "config/dev.yml", "docs/a.md", "docs/guide/b.md", "notes.txt"} {
+		writeFile(t, filepath.Join(dir, path), "x")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	detector := NewDetector(&MockGitProvider{})
+
+	tests := []struct {
+		name    string
+		tool    string
+		command string
+		want    []schema.FileEvent
+	}{
+		{
+			"glob",
+			"bash",
+			"rm -f config/*.yml",
+			[]schema.FileEvent{{Path: "config/dev.yml", Action: "delete"}, {Path: "config/prod.yml", Action: "delete"}},
+		},
+		{
+			"directory",
+			"bash",
+			"cd docs && rm -rf .",
+			[]schema.FileEvent{{Path: "docs/a.md", Action: "delete"}, {Path: "docs/guide/b.md", Action: "delete"}},
+		},
+		{
+			"missing file",
+			"bash",
+			"rm -- -gone.txt",
+			[]schema.FileEvent{{Path: "-gone.txt", Action: "delete"}},
+		},
+		{
+			"rename into directory",
+			"bash",
+			"mv notes.txt archive",
+			[]schema.FileEvent{{Path: "archive/notes.txt", Action: "rename", OldPath: "notes.txt"}},
+		},
+		{
+			"renamed directory",
+			"bash",
+			"git mv docs manual",
+			[]schema.FileEvent{{Path: "manual/a.md", Action: "rename", OldPath: "docs/a.md"}, {Path: "manual/guide/b.md", Action: "rename", OldPath: "docs/guide/b.md"}},
+		},
+		{
+			"powershell",
+			"powershell",
+			"Remove-Item -Path config/prod.yml -Force; Rename-Item docs/a.md -NewName intro.md",
+			[]schema.FileEvent{{Path: "config/prod.yml", Action: "delete"}, {Path: "docs/intro.md", Action: "rename", OldPath: "docs/a.md"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			events, err := detector.DetectAll(&RawHookInput{ToolName: tt.tool, ToolArgs: args, Cwd: dir})
+			if err != nil {
+				t.Fatalf("DetectAll() error = %v", err)
+			}
+
+			var got []schema.FileEvent
+			for _, evt := range events {
+				if evt.File != nil {
+					got = append(got, *evt.File)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectFileOperationsRepoPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"config/prod.yml", "docs/a.md", "docs/guide/b.md", "notes.txt"} {
+		writeFile(t, filepath.Join(dir, path), "x")
+	}
+	outside := filepath.Join(t.TempDir(), "other.txt")
+	detector := NewDetector(&MockGitProvider{RepoRoot: dir})
+	docs := filepath.Join(dir, "docs")
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]string
+		want []schema.FileEvent
+	}{
+		{
+			"relative to a subdirectory",
+			"bash",
+			map[string]string{"command": "rm a.md"},
+			[]schema.FileEvent{{Path: "docs/a.md", Action: "delete"}},
+		},
+		{
+			"absolute path",
+			"bash",
+			map[string]string{"command": "rm " + filepath.Join(dir, "config", "prod.yml")},
+			[]schema.FileEvent{{Path: "config/prod.yml", Action: "delete"}},
+		},
+		{
+			"cd",
+			"bash",
+			map[string]string{"command": "cd guide && rm b.md && cd ../.. && mv notes.txt docs/notes.txt"},
+			[]schema.FileEvent{{Path: "docs/guide/b.md", Action: "delete"}, {Path: "docs/notes.txt", Action: "rename", OldPath: "notes.txt"}},
+		},
+		{
+			"parent directory",
+			"bash",
+			map[string]string{"command": "git rm ../notes.txt"},
+			[]schema.FileEvent{{Path: "notes.txt", Action: "delete"}},
+		},
+		{
+			"outside the repository",
+			"bash",
+			map[string]string{"command": "rm " + outside},
+			[]schema.FileEvent{{Path: outside, Action: "delete"}},
+		},
+		{
+			"delete tool",
+			"delete",
+			map[string]string{"path": filepath.Join(dir, "docs", "a.md")},
+			[]schema.FileEvent{{Path: "docs/a.md", Action: "delete"}},
+		},
+		{
+			"create tool",
+			"create",
+			map[string]string{"path": filepath.Join(dir, "docs", "new.md")},
+			[]schema.FileEvent{{Path: "docs/new.md", Action: "create"}},
+		},
+		{
+			"edit tool",
+			"edit",
+			map[string]string{"path": "guide/b.md"},
+			[]schema.FileEvent{{Path: "docs/guide/b.md", Action: "edit"}},
+		},
+		{
+			"rename tool",
+			"rename",
+			map[string]string{"path": "a.md", "new_path": "../intro.md"},
+			[]schema.FileEvent{{Path: "intro.md", Action: "rename", OldPath: "docs/a.md"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.args)
+			events, err := detector.DetectAll(&RawHookInput{ToolName: tt.tool, ToolArgs: args, Cwd: docs})
+			if err != nil {
+				t.Fatalf("DetectAll() error = %v", err)
+			}
+
+			var got []schema.FileEvent
+			for _, evt := range events {
+				if evt.File != nil {
+					got = append(got, *evt.File)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCommitMessageSources(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "msg.txt"), []byte("docs: from file\n\nbody\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mock := &MockGitProvider{CommitMessages: map[string]string{
+		"HEAD":   "feat: previous\n\nOriginal body.",
+		"abc123": "fix: target commit\n\nTarget body.",
+	}}
+	detector := NewDetector(mock)
+
+	tests := []struct {
+		name    string
+		command string
+		want    schema.CommitEvent
+	}{
+		{"file", "git commit -F msg.txt", schema.CommitEvent{Message: "docs: from file\n\nbody"}},
+		{"file long option", "git commit --file=" + filepath.Join(dir, "msg.txt"), schema.CommitEvent{Message: "docs: from file\n\nbody"}},
+		{"missing file", "git commit -F missing.txt", schema.CommitEvent{}},
+		{"amend keeps message", "git commit --amend --no-edit", schema.CommitEvent{Message: "feat: previous\n\nOriginal body.", Amend: true}},
+		{"amend with new message", "git commit --amend -m 'feat: reworded'", schema.CommitEvent{Message: "feat: reworded", Amend: true}},
+		{"reuse message", "git commit -C abc123", schema.CommitEvent{Message: "fix: target commit\n\nTarget body."}},
+		{"fixup", "git commit --fixup abc123", schema.CommitEvent{Message: "fixup! fix: target commit", Fixup: true}},
+		{"fixup with message", "git commit --fixup=abc123 -m 'extra'", schema.CommitEvent{Message: "fixup! fix: target commit\n\nextra", Fixup: true}},
+		{"amend fixup", "git commit --fixup=amend:abc123", schema.CommitEvent{Message: "amend! fix: target commit\n\nfix: target commit\n\nTarget body.", Fixup: true}},
+		{"squash", "git commit --squash=abc123", schema.CommitEvent{Message: "squash! fix: target commit"}},
+		{"flags", "git commit --allow-empty --no-verify -m wip", schema.CommitEvent{Message: "wip", AllowEmpty: true, NoVerify: true}},
+		{"short no-verify", "git commit -anm wip", schema.CommitEvent{Message: "wip", NoVerify: true}},
+		{"verify overrides", "git commit -n --verify -m wip", schema.CommitEvent{Message: "wip"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			evt, err := detector.Detect(&RawHookInput{ToolName: "bash", ToolArgs: args, Cwd: dir})
+			if err != nil {
+				t.Fatalf("Detect() error = %v", err)
+			}
+			if evt.Commit == nil {
+				t.Fatal("Expected commit event, got nil")
+			}
+			got := *evt.Commit
+			if got.Message != tt.want.Message {
+				t.Errorf("Expected message %q, got %q", tt.want.Message, got.Message)
+			}
+			if got.Amend != tt.want.Amend || got.Fixup != tt.want.Fixup || got.AllowEmpty != tt.want.AllowEmpty || got.NoVerify != tt.want.NoVerify {
+				t.Errorf("Expected flags amend=%v fixup=%v allowEmpty=%v noVerify=%v, got amend=%v fixup=%v allowEmpty=%v noVerify=%v",
+					tt.want.Amend, tt.want.Fixup, tt.want.AllowEmpty, tt.want.NoVerify, got.Amend, got.Fixup, got.AllowEmpty, got.NoVerify)
+			}
+		})
+	}
+}
+
+// pendingRecordingProvider records the pending commits it is asked about
+type pendingRecordingProvider struct {
+	MockGitProvider
+	pending []PendingCommit
+}
+
+func (p *pendingRecordingProvider) GetPendingFiles(cwd string, pending PendingCommit) []schema.FileStatus {
+	p.pending = append(p.pending, pending)
+	return nil
+}
+
+// GetRepoRoot treats each top-level directory as a separate repository
+func (p *pendingRecordingProvider) GetRepoRoot(cwd string) string {
+	top, _, _ := strings.Cut(strings.TrimPrefix(filepath.ToSlash(cwd), "/"), "/")
+	return "/" + top
+}
+
+func TestDetectPendingCommit(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []PendingCommit // One per commit; nil when only staged files are used
+	}{
+		{"staged only", "git commit -m x", nil},
+		{"add then commit", "git add . && cd sub && git add -u && git commit -m x", []PendingCommit{
+			{Adds: []PendingAdd{{Dir: "/repo", Args: []string{"."}}, {Dir: "/repo/sub", Args: []string{"-u"}}}},
+		}},
+		{"commit all", "git commit -am x", []PendingCommit{{All: true}}},
+		{"commit pathspecs", "git commit -m x src/a.go -- -odd-name", []PendingCommit{{Pathspecs: []string{"src/a.go", "-odd-name"}}}},
+		{"commit include", "git commit --include -m x src/a.go", []PendingCommit{{Pathspecs: []string{"src/a.go"}, Include: true}}},
+		{"adds go to the next commit", "git add a && git commit -m one && git add b && git commit -m two", []PendingCommit{
+			{Adds: []PendingAdd{{Dir: "/repo", Args: []string{"a"}}}},
+			{Adds: []PendingAdd{{Dir: "/repo", Args: []string{"b"}}}},
+		}},
+		{"adds in another repository", "git -C /other add . && git add a && git commit -m x", []PendingCommit{
+			{Adds: []PendingAdd{{Dir: "/repo", Args: []string{"a"}}}},
+		}},
+		{"commit in another repository", "git add a && git -C ../other commit -m one && git commit -m two", []PendingCommit{
+			{Adds: []PendingAdd{{Dir: "/repo", Args: []string{"a"}}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &pendingRecordingProvider{}
+			args, _ := json.Marshal(map[string]string{"command": tt.command})
+			if _, err := NewDetector(provider).DetectAll(&RawHookInput{ToolName: "bash", ToolArgs: args, Cwd: "/repo"}); err != nil {
+				t.Fatalf("DetectAll() error = %v", err)
+			}
+			if !reflect.DeepEqual(provider.pending, tt.want) {
+				t.Errorf("Expected pending commits %+v, got %+v", tt.want, provider.pending)
+			}
+		})
+	}
+}
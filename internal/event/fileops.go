@@ -0,0 +1,365 @@
+package event
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileOperation is a file deletion or rename found in a command line. Paths are
+// as written, joined to the directory earlier cd commands moved to, so globs and
+// directories still need expanding against the file system.
+type FileOperation struct {
+	Action  string // "delete" or "rename"
+	Path    string // The deleted path, or the destination of a rename
+	OldPath string // The source of a rename
+	Tree    bool   // Path and OldPath are directories or globs standing for every file below them
+	intoDir bool   // Path is a directory the source is moved into
+}
+
+// ParseFileOperations returns the deletions and renames a command line makes, in
+// order: rm and mv, git rm and git mv, and their PowerShell equivalents
+func ParseFileOperations(line string, dialect Dialect) []FileOperation {
+	var ops []FileOperation
+	for _, cmd := range ParseCommandLine(line, dialect) {
+		if op, ok := gitOperation(cmd, dialect); ok {
+			ops = append(ops, gitFileOperations(op)...)
+			continue
+		}
+		ops = append(ops, fileOperations(cmd, dialect)...)
+	}
+	return ops
+}
+
+// fileOperations returns the deletions and renames a simple command makes
+func fileOperations(cmd SimpleCommand, dialect Dialect) []FileOperation {
+	args := unwrapCommand(cmd.Args, dialect)
+	if len(args) == 0 {
+		return nil
+	}
+
+	var ops []FileOperation
+	if dialect == DialectPowerShell {
+		ops = powerShellFileOperations(commandName(args[0], dialect), args[1:])
+	} else {
+		switch commandName(args[0], dialect) {
+		case "rm", "unlink", "rmdir":
+			ops = deletions(posixOperands(args[1:], nil))
+		case "mv":
+			ops = moves(posixOperands(args[1:], map[string]bool{"-t": true, "-S": true, "--target-directory": true, "--suffix": true}))
+		}
+	}
+	return inDir(ops, cmd.Dir)
+}
+
+// gitFileOperations returns the deletions and renames made by git rm and git mv
+func gitFileOperations(op GitOperation) []FileOperation {
+	var ops []FileOperation
+	switch op.Subcommand {
+	case "rm":
+		ops = deletions(posixOperands(op.Args, map[string]bool{"--pathspec-from-file": true}))
+	case "mv":
+		ops = moves(posixOperands(op.Args, nil))
+	default:
+		return nil
+	}
+	for _, arg := range op.Args {
+		if arg == "--" {
+			break
+		}
+		if arg == "-n" || arg == "--dry-run" {
+			return nil
+		}
+	}
+	return inDir(ops, op.Dir)
+}
+
+// operands are the arguments of a command that are not options
+type operands struct {
+	values map[string]string // Values of options that take one
+	args   []string
+}
+
+// posixOperands splits arguments into option values and operands. Options listed
+// in withValue take the next argument unless given as --name=value.
+func posixOperands(args []string, withValue map[string]bool) operands {
+	result := operands{values: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			result.args = append(result.args, args[i+1:]...)
+			return result
+		case withValue[arg] && i+1 < len(args):
+			i++
+			result.values[arg] = args[i]
+		case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+			name, value, _ := strings.Cut(arg, "=")
+			result.values[name] = value
+		case strings.HasPrefix(arg, "-") && arg != "-":
+		default:
+			result.args = append(result.args, arg)
+		}
+	}
+	return result
+}
+
+// deletions returns a delete operation for each operand
+func deletions(o operands) []FileOperation {
+	ops := make([]FileOperation, 0, len(o.args))
+	for _, path := range o.args {
+		ops = append(ops, FileOperation{Action: "delete", Path: path})
+	}
+	return ops
+}
+
+// moves returns the renames of mv-style operands: sources followed by a
+// destination, or sources moved into a -t/--target-directory directory
+func moves(o operands) []FileOperation {
+	sources, dest := o.args, o.values["-t"]
+	if dest == "" {
+		dest = o.values["--target-directory"]
+	}
+	intoDir := dest != ""
+	if !intoDir {
+		if len(sources) < 2 {
+			return nil
+		}
+		sources, dest = sources[:len(sources)-1], sources[len(sources)-1]
+		intoDir = len(sources) > 1 || strings.HasSuffix(dest, "/")
+	}
+
+	ops := make([]FileOperation, 0, len(sources))
+	for _, source := range sources {
+		ops = append(ops, FileOperation{Action: "rename", Path: dest, OldPath: source, intoDir: intoDir})
+	}
+	return ops
+}
+
+// powerShellValueParameters are parameters of the item cmdlets that take a value
+var powerShellValueParameters = map[string]bool{
+	"-path": true, "-literalpath": true, "-destination": true, "-newname": true,
+	"-filter": true, "-include": true, "-exclude": true, "-credential": true, "-stream": true,
+}
+
+// powerShellFileOperations handles Remove-Item, Move-Item, Rename-Item and their
+// aliases. Parameters may be named or positional, and paths may be comma lists.
+func powerShellFileOperations(name string, args []string) []FileOperation {
+	named := make(map[string][]string)
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, splitList(arg)...)
+			continue
+		}
+		param, value, hasValue := strings.Cut(strings.ToLower(arg), ":")
+		if param == "-whatif" {
+			return nil
+		}
+		if !powerShellValueParameters[param] {
+			continue
+		}
+		if hasValue {
+			value = arg[len(param)+1:]
+		} else if i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		named[param] = append(named[param], splitList(value)...)
+	}
+
+	// value returns the first named parameter given, or else the next positional
+	// argument, as PowerShell binds them
+	value := func(names ...string) []string {
+		for _, n := range names {
+			if values := named[n]; len(values) > 0 {
+				return values
+			}
+		}
+		if len(positional) == 0 {
+			return nil
+		}
+		next := positional[:1]
+		positional = positional[1:]
+		return next
+	}
+
+	switch name {
+	case "remove-item", "rm", "del", "erase", "ri", "rd", "rmdir":
+		paths := append(named["-path"], named["-literalpath"]...)
+		if len(paths) == 0 {
+			paths = positional
+		}
+		return deletions(operands{args: paths})
+
+	case "move-item", "mv", "move", "mi":
+		sources := value("-path", "-literalpath")
+		dest := value("-destination")
+		if len(sources) == 0 || len(dest) != 1 {
+			return nil
+		}
+		ops := make([]FileOperation, 0, len(sources))
+		for _, source := range sources {
+			ops = append(ops, FileOperation{Action: "rename", Path: dest[0], OldPath: source, intoDir: len(sources) > 1})
+		}
+		return ops
+
+	case "rename-item", "ren", "rni":
+		source := value("-path", "-literalpath")
+		newName := value("-newname")
+		if len(source) != 1 || len(newName) != 1 {
+			return nil
+		}
+		dir := source[0][:strings.LastIndexAny(source[0], `/\`)+1]
+		return []FileOperation{{Action: "rename", Path: dir + newName[0], OldPath: source[0]}}
+	}
+	return nil
+}
+
+// splitList splits a PowerShell comma-separated list
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// inDir resolves the paths of operations run from dir, which is relative to the
+// directory the command line started in
+func inDir(ops []FileOperation, dir string) []FileOperation {
+	if dir == "" {
+		return ops
+	}
+	for i := range ops {
+		ops[i].Path = joinDir(dir, ops[i].Path)
+		if ops[i].OldPath != "" {
+			ops[i].OldPath = joinDir(dir, ops[i].OldPath)
+		}
+	}
+	return ops
+}
+
+// maxExpandedFiles is the most files one operation is expanded into. An operation
+// on more, such as rm -rf node_modules, is reported once for the paths as written,
+// marked as a Tree so path filters match anything below them.
+const maxExpandedFiles = 100
+
+// expandFileOperation turns an operation into the files it affects, with paths
+// resolved against cwd: globs are expanded and directories are replaced by the
+// files inside them, so path filters see every file that goes away. Operations
+// affecting more than maxExpandedFiles files are not expanded.
+func expandFileOperation(op FileOperation, cwd string) []FileOperation {
+	if op.Action == "delete" {
+		var expanded []FileOperation
+		for _, match := range expandGlob(absPath(op.Path, cwd)) {
+			files, ok := filesBelow(match, maxExpandedFiles-len(expanded))
+			if !ok {
+				return []FileOperation{{Action: op.Action, Path: absPath(op.Path, cwd), Tree: true}}
+			}
+			for _, file := range files {
+				expanded = append(expanded, FileOperation{Action: op.Action, Path: file})
+			}
+		}
+		return expanded
+	}
+
+	var expanded []FileOperation
+	for _, match := range expandGlob(absPath(op.OldPath, cwd)) {
+		dest := absPath(op.Path, cwd)
+		if op.intoDir || isDir(dest) {
+			dest = filepath.Join(dest, filepath.Base(match))
+		}
+		files, ok := filesBelow(match, maxExpandedFiles-len(expanded))
+		if !ok {
+			return []FileOperation{unexpandedRename(op, cwd)}
+		}
+		for _, file := range files {
+			// Files inside a renamed directory keep their place below it
+			newPath := dest
+			if rel, err := filepath.Rel(match, file); err == nil && rel != "." {
+				newPath = filepath.Join(dest, rel)
+			}
+			expanded = append(expanded, FileOperation{Action: op.Action, Path: newPath, OldPath: file})
+		}
+	}
+	return expanded
+}
+
+// unexpandedRename resolves a rename against cwd without expanding it, moving a
+// single source into its destination directory the way mv does
+func unexpandedRename(op FileOperation, cwd string) FileOperation {
+	source, dest := absPath(op.OldPath, cwd), absPath(op.Path, cwd)
+	if !strings.ContainsAny(source, "*?[") && (op.intoDir || isDir(dest)) {
+		dest = filepath.Join(dest, filepath.Base(source))
+	}
+	return FileOperation{Action: op.Action, Path: dest, OldPath: source, Tree: true}
+}
+
+// expandGlob returns the paths a glob matches, or the path as written when it is
+// not a glob or matches nothing
+func expandGlob(path string) []string {
+	if !strings.ContainsAny(path, "*?[") {
+		return []string{path}
+	}
+	found, err := filepath.Glob(path)
+	if err != nil || len(found) == 0 {
+		return []string{path}
+	}
+	return found
+}
+
+// filesBelow returns every file inside a directory, outside .git, or the path
+// itself when it is not a directory or holds no files. It stops walking and
+// returns false once there are more than limit files.
+func filesBelow(path string, limit int) ([]string, bool) {
+	if limit < 1 {
+		return nil, false
+	}
+	if !isDir(path) {
+		return []string{path}, true
+	}
+	var files []string
+	err := filepath.WalkDir(path, func(p string, entry fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+		case entry.IsDir() && entry.Name() == ".git":
+			return filepath.SkipDir
+		case !entry.IsDir():
+			if files = append(files, p); len(files) > limit {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	if err != nil || len(files) > limit {
+		return nil, false
+	}
+	if len(files) == 0 {
+		return []string{path}, true
+	}
+	return files, true
+}
+
+// absPath resolves path against cwd
+func absPath(path, cwd string) string {
+	if filepath.IsAbs(path) || cwd == "" {
+		return path
+	}
+	return filepath.Join(cwd, path)
+}
+
+// isOutside reports whether a relative path leads out of its base directory
+func isOutside(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,146 @@
+package event
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFileOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		dialect Dialect
+		want    []FileOperation
+	}{
+		{
+			"rm",
+			"rm -rf build dist && unlink link; rmdir -p empty",
+			DialectPOSIX,
+			[]FileOperation{{Action: "delete", Path: "build"}, {Action: "delete", Path: "dist"}, {Action: "delete", Path: "link"}, {Action: "delete", Path: "empty"}},
+		},
+		{
+			"end of options",
+			"rm -- -file",
+			DialectPOSIX,
+			[]FileOperation{{Action: "delete", Path: "-file"}},
+		},
+		{
+			"mv",
+			"mv -f a.txt b.txt",
+			DialectPOSIX,
+			[]FileOperation{{Action: "rename", Path: "b.txt", OldPath: "a.txt"}},
+		},
+		{
+			"mv several sources",
+			"mv a b dest",
+			DialectPOSIX,
+			[]FileOperation{{Action: "rename", Path: "dest", OldPath: "a", intoDir: true}, {Action: "rename", Path: "dest", OldPath: "b", intoDir: true}},
+		},
+		{
+			"mv target directory",
+			"mv -t dest a",
+			DialectPOSIX,
+			[]FileOperation{{Action: "rename", Path: "dest", OldPath: "a", intoDir: true}},
+		},
+		{
+			"wrapped and after cd",
+			"cd src && sudo rm old.go",
+			DialectPOSIX,
+			[]FileOperation{{Action: "delete", Path: "src/old.go"}},
+		},
+		{
+			"git rm and git mv",
+			"git rm --cached secret.env && git -C sub mv a.go b.go",
+			DialectPOSIX,
+			[]FileOperation{{Action: "delete", Path: "secret.env"}, {Action: "rename", Path: "sub/b.go", OldPath: "sub/a.go"}},
+		},
+		{
+			"dry runs",
+			"git rm -n a && git mv --dry-run a b",
+			DialectPOSIX,
+			nil,
+		},
+		{
+			"text that is not a command",
+			"echo 'rm -rf /' && git log -- rm",
+			DialectPOSIX,
+			nil,
+		},
+		{
+			"powershell remove",
+			"Remove-Item -Recurse -Force -LiteralPath build; del a.txt,b.txt; rm c.txt -WhatIf",
+			DialectPowerShell,
+			[]FileOperation{{Action: "delete", Path: "build"}, {Action: "delete", Path: "a.txt"}, {Action: "delete", Path: "b.txt"}},
+		},
+		{
+			"powershell move and rename",
+			"Move-Item -Destination dest -Path a.txt; mv b.txt c.txt; Rename-Item -Path src\\old.ps1 -NewName new.ps1",
+			DialectPowerShell,
+			[]FileOperation{
+				{Action: "rename", Path: "dest", OldPath: "a.txt"},
+				{Action: "rename", Path: "c.txt", OldPath: "b.txt"},
+				{Action: "rename", Path: "src\\new.ps1", OldPath: "src\\old.ps1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseFileOperations(tt.line, tt.dialect)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpandFileOperationLimit(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i <= maxExpandedFiles; i++ {
+		writeFile(t, filepath.Join(dir, "big", fmt.Sprintf("%03d.js", i)), "x")
+	}
+	writeFile(t, filepath.Join(dir, "small", "a.txt"), "x")
+	writeFile(t, filepath.Join(dir, "small", "b.txt"), "x")
+	if err := os.Mkdir(filepath.Join(dir, "dest"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		op   FileOperation
+		want []FileOperation
+	}{
+		{
+			"small directory is expanded",
+			FileOperation{Action: "delete", Path: "small"},
+			[]FileOperation{{Action: "delete", Path: filepath.Join(dir, "small", "a.txt")}, {Action: "delete", Path: filepath.Join(dir, "small", "b.txt")}},
+		},
+		{
+			"large directory delete is one event",
+			FileOperation{Action: "delete", Path: "big"},
+			[]FileOperation{{Action: "delete", Path: filepath.Join(dir, "big"), Tree: true}},
+		},
+		{
+			"large glob delete is one event",
+			FileOperation{Action: "delete", Path: "big/*.js"},
+			[]FileOperation{{Action: "delete", Path: filepath.Join(dir, "big", "*.js"), Tree: true}},
+		},
+		{
+			"large directory rename is one event",
+			FileOperation{Action: "rename", Path: "dest", OldPath: "big"},
+			[]FileOperation{{Action: "rename", Path: filepath.Join(dir, "dest", "big"), OldPath: filepath.Join(dir, "big"), Tree: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandFileOperation(tt.op, dir)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package event
 
 import (
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
@@ -11,7 +13,7 @@ import (
 // RealGitProvider executes actual git commands to gather context
 type RealGitProvider struct{}
 
-// GetBranch returns the current git branch
+// GetBranch returns the current git branch, or "" on a detached HEAD
 func (g *RealGitProvider) GetBranch(cwd string) string {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
 	cmd.Dir = cwd
@@ -19,7 +21,24 @@ func (g *RealGitProvider) GetBranch(cwd string) string {
 	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(string(out))
+	// A detached HEAD is reported as the literal "HEAD", which is not a branch
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+// GetRepoRoot returns the top-level directory of the repository containing cwd, or
+// "" outside a repository
+func (g *RealGitProvider) GetRepoRoot(cwd string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out)))
 }
 
 // GetAuthor returns the git user email
@@ -33,9 +52,10 @@ func (g *RealGitProvider) GetAuthor(cwd string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// GetStagedFiles returns files currently staged for commit
+// GetStagedFiles returns files currently staged for commit. Renames are reported as
+// a delete plus an add so both paths are visible to filters.
 func (g *RealGitProvider) GetStagedFiles(cwd string) []schema.FileStatus {
-	cmd := exec.Command("git", "diff", "--cached", "--name-status")
+	cmd := exec.Command("git", "diff", "--cached", "--no-renames", "--name-status", "-z")
 	cmd.Dir = cwd
 	out, err := cmd.Output()
 	if err != nil {
@@ -44,38 +64,129 @@ func (g *RealGitProvider) GetStagedFiles(cwd string) []schema.FileStatus {
 	return parseGitStatus(string(out))
 }
 
-// GetPendingFiles returns files that would be affected by a git add command
-// This handles the case where "git add . && git commit" is run - the files
-// aren't staged yet when the hook fires
-func (g *RealGitProvider) GetPendingFiles(cwd string, command string) []schema.FileStatus {
-	// Get all modified/untracked files
-	cmd := exec.Command("git", "status", "--porcelain")
-	cmd.Dir = cwd
-	out, err := cmd.Output()
+// GetPendingFiles returns the files a commit will record once the index changes
+// described by pending are made. The hook fires before "git add . && git commit"
+// runs, so git itself replays the staging on a copy of the index; this gives
+// exactly git's view of pathspecs, magic like :(exclude) and .gitignore. The
+// repository is never touched: the blobs git add writes go to a temporary object
+// directory, with the repository's objects available as an alternate, and optional
+// locks are turned off.
+func (g *RealGitProvider) GetPendingFiles(cwd string, pending PendingCommit) []schema.FileStatus {
+	tmpDir, err := os.MkdirTemp("", "agentic-ops-index-*")
 	if err != nil {
 		return nil
 	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
 
-	allFiles := parsePorcelainStatus(string(out))
+	index := filepath.Join(tmpDir, "index")
+	if err := copyIndex(cwd, index); err != nil {
+		return nil
+	}
+	objects, err := gitPath(cwd, "objects")
+	if err != nil {
+		return nil
+	}
+	tmpObjects := filepath.Join(tmpDir, "objects")
+	if err := os.Mkdir(tmpObjects, 0755); err != nil {
+		return nil
+	}
+	git := func(dir string, args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_INDEX_FILE="+index,
+			"GIT_OBJECT_DIRECTORY="+tmpObjects,
+			"GIT_ALTERNATE_OBJECT_DIRECTORIES="+objects,
+			"GIT_OPTIONAL_LOCKS=0",
+		)
+		return cmd.Output()
+	}
 
-	// If command has specific files in git add, filter to those
-	addFiles := ExtractGitAddFiles(command)
-	if len(addFiles) == 0 {
-		// git add . or git add -A - return all files
-		return allFiles
+	// A failing git add stages nothing, just as it would when the command runs
+	for _, add := range pending.Adds {
+		_, _ = git(add.Dir, append([]string{"add"}, nonInteractiveAddArgs(add.Args)...)...)
 	}
+	if pending.All {
+		_, _ = git(cwd, "add", "--update", ":/")
+	}
+
+	// Like GetStagedFiles, renames are reported as a delete plus an add
+	diff := []string{"diff", "--cached", "--no-renames", "--name-status", "-z"}
+	if len(pending.Pathspecs) > 0 {
+		// git commit <pathspec> commits the working tree version of tracked files
+		_, _ = git(cwd, append([]string{"add", "--update", "--"}, pending.Pathspecs...)...)
+		if !pending.Include {
+			// Without --include, changes staged outside the pathspecs are left out
+			diff = append(append(diff, "--"), pending.Pathspecs...)
+		}
+	}
+
+	out, err := git(cwd, diff...)
+	if err != nil {
+		return nil
+	}
+	return parseGitStatus(string(out))
+}
 
-	// Filter to files matching the git add patterns
-	var filtered []schema.FileStatus
-	for _, f := range allFiles {
-		for _, pattern := range addFiles {
-			if matchGitAddPattern(f.Path, pattern, cwd) {
-				filtered = append(filtered, f)
-				break
+// interactiveAddOptions are the git add options that open an editor or prompt
+var interactiveAddOptions = map[string]bool{"--patch": true, "--interactive": true, "--edit": true}
+
+// nonInteractiveAddArgs drops the options of git add that would open an editor or
+// prompt, -p, -i and -e, so replaying it cannot block the hook. The paths they name
+// are staged whole instead, which may report more files than the commit records
+// but never fewer.
+func nonInteractiveAddArgs(args []string) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		switch {
+		case interactiveAddOptions[arg]:
+			continue
+		case len(arg) > 1 && arg[0] == '-' && arg[1] != '-':
+			// Short options may be bundled, as in -Ap
+			if arg = strings.NewReplacer("p", "", "i", "", "e", "").Replace(arg); arg == "-" {
+				continue
 			}
 		}
+		result = append(result, arg)
 	}
-	return filtered
+	return result
+}
+
+// copyIndex copies the repository's index to path. A repository without an index
+// yet leaves path missing, which git reads as an empty index.
+func copyIndex(cwd, path string) error {
+	source, err := gitPath(cwd, "index")
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// gitPath returns the absolute path of a file in the git directory of the
+// repository containing cwd, such as its index
+func gitPath(cwd, name string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", name)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	return path, nil
 }
 
 // GetRemote returns the default remote (usually "origin")
@@ -115,130 +226,192 @@ func (g *RealGitProvider) GetAheadBehind(cwd string) (ahead, behind int) {
 	return ahead, behind
 }
 
-// parseGitStatus parses git diff --name-status output
-func parseGitStatus(output string) []schema.FileStatus {
-	var files []schema.FileStatus
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			status := "modified"
-			switch parts[0] {
-			case "A":
-				status = "added"
-			case "M":
-				status = "modified"
-			case "D":
-				status = "deleted"
-			case "R":
-				status = "renamed"
-			case "C":
-				status = "copied"
-			}
-			files = append(files, schema.FileStatus{
-				Path:   parts[1],
-				Status: status,
-			})
-		}
+// GetHeadSHA returns the SHA of HEAD, or "" before the first commit
+func (g *RealGitProvider) GetHeadSHA(cwd string) string {
+	return revParse(cwd, "HEAD")
+}
+
+// GetCommitMessage returns the full message of a commit, or "" if it does not exist
+func (g *RealGitProvider) GetCommitMessage(cwd string, rev string) string {
+	if strings.HasPrefix(rev, "-") {
+		return ""
 	}
-	return files
+	cmd := exec.Command("git", "log", "-1", "--format=%B", rev, "--")
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(out), "\n")
 }
 
-// parsePorcelainStatus parses git status --porcelain output
-func parsePorcelainStatus(output string) []schema.FileStatus {
-	var files []schema.FileStatus
-	// Don't trim the output - leading spaces are significant in porcelain format!
-	// Just trim trailing newlines
-	output = strings.TrimRight(output, "\n\r")
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if len(line) < 4 {
-			continue
-		}
-		// Porcelain format: XY PATH where XY is 2 chars, then space, then path
-		// Example: " M file.txt" or "M  file.txt" or "?? file.txt"
-		indexStatus := line[0]
-		workTreeStatus := line[1]
-		// The path starts after "XY " (3 characters)
-		path := line[3:]
-
-		// Handle renamed files (format: R  old -> new)
-		if strings.Contains(path, " -> ") {
-			parts := strings.Split(path, " -> ")
-			if len(parts) == 2 {
-				path = parts[1]
-			}
-		}
+// GetOutgoingCommits returns the commits a push of one refspec would send.
+// before is the remote-tracking SHA of the destination (empty when there is none,
+// such as for a new branch or a tag) and after is the SHA of the source. Without a
+// remote-tracking ref, every commit not already on a remote is considered outgoing.
+// A push that deletes the remote ref sends no commits. commits is nil only when
+// the outgoing commits could not be determined.
+func (g *RealGitProvider) GetOutgoingCommits(cwd string, push PushRefspec) (before, after string, commits []schema.CommitEvent) {
+	remote := push.Remote
+	if remote == "" {
+		remote = g.GetRemote(cwd)
+	}
+	tracking := ""
+	if branch, ok := strings.CutPrefix(push.Dst, "refs/heads/"); ok {
+		tracking = "refs/remotes/" + remote + "/" + branch
+		before = revParse(cwd, tracking)
+	}
+	if push.Src == "" {
+		return before, "", []schema.CommitEvent{}
+	}
 
-		status := "modified"
-		// Determine status based on index and work tree
-		if indexStatus == '?' || workTreeStatus == '?' {
-			status = "added" // Untracked = will be added
-		} else if indexStatus == 'A' {
-			status = "added"
-		} else if indexStatus == 'D' || workTreeStatus == 'D' {
-			status = "deleted"
-		} else if indexStatus == 'R' {
-			status = "renamed"
-		} else if indexStatus == 'M' || workTreeStatus == 'M' {
-			status = "modified"
-		}
+	after = revParse(cwd, push.Src+"^{commit}")
+	if after == "" {
+		return before, "", nil
+	}
 
-		files = append(files, schema.FileStatus{
-			Path:   path,
-			Status: status,
-		})
+	rangeArgs := []string{after, "--not", "--remotes"}
+	if before != "" {
+		rangeArgs = []string{after, "--not", tracking}
 	}
-	return files
+
+	// Records are separated by \x1e and fields by \x1f; the NUL-separated name-status list follows the last field.
+	// --no-renames reports renames as a delete plus an add so both paths are visible to filters.
+	args := append([]string{"log", "--no-renames", "--name-status", "-z", "--format=%x1e%H%x1f%ae%x1f%B%x1f"}, rangeArgs...)
+	args = append(args, "--")
+	cmd := exec.Command("git", args...)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return before, after, nil
+	}
+	return before, after, parseGitLog(string(out))
+}
+
+// ResolveRef returns the full name of the local ref name refers to, such as
+// refs/tags/v1.0 for v1.0, or "" if it is not a ref
+func (g *RealGitProvider) ResolveRef(cwd string, name string) string {
+	if strings.HasPrefix(name, "-") {
+		return ""
+	}
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "--symbolic-full-name", name)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
 }
 
-// matchGitAddPattern checks if a file path matches a git add pattern
-func matchGitAddPattern(filePath, pattern, cwd string) bool {
-	// Handle "." - matches everything
-	if pattern == "." {
-		return true
+// ListRefs returns the full names of the local refs that start with prefix
+func (g *RealGitProvider) ListRefs(cwd string, prefix string) []string {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname)", prefix)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
 	}
+	return strings.Fields(string(out))
+}
 
-	// Handle "-A" or "--all" - matches everything
-	if pattern == "-A" || pattern == "--all" {
-		return true
+// GetAliases returns the git aliases defined for the repository at cwd, by name.
+// Aliases named like a built-in subcommand are left out, as git ignores them.
+func (g *RealGitProvider) GetAliases(cwd string) map[string]string {
+	// Entries are separated by NUL, and each name from its value by a newline
+	cmd := exec.Command("git", "config", "-z", "--get-regexp", `^alias\.`)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
 	}
 
-	// Handle glob patterns
-	if strings.Contains(pattern, "*") {
-		matched, err := filepath.Match(pattern, filePath)
-		if err == nil && matched {
-			return true
-		}
-		// Also try matching just the filename
-		matched, err = filepath.Match(pattern, filepath.Base(filePath))
-		if err == nil && matched {
-			return true
+	builtins := make(map[string]bool)
+	list := exec.Command("git", "--list-cmds=builtins")
+	list.Dir = cwd
+	if names, err := list.Output(); err == nil {
+		for _, name := range strings.Fields(string(names)) {
+			builtins[name] = true
 		}
 	}
 
-	// Handle directory patterns
-	if strings.HasSuffix(pattern, "/") || !strings.Contains(pattern, ".") {
-		// Might be a directory
-		if strings.HasPrefix(filePath, pattern) || strings.HasPrefix(filePath, strings.TrimSuffix(pattern, "/")) {
-			return true
+	aliases := make(map[string]string)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		name, ok := strings.CutPrefix(key, "alias.")
+		if !ok || name == "" || builtins[name] {
+			continue
 		}
+		aliases[name] = value
 	}
+	return aliases
+}
 
-	// Exact match
-	if filePath == pattern || filepath.Base(filePath) == pattern {
-		return true
+// revParse resolves a revision to a SHA, returning "" if it cannot be resolved
+func revParse(cwd, rev string) string {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev)
+	cmd.Dir = cwd
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
 	}
+	return strings.TrimSpace(string(out))
+}
 
-	// Path contains pattern
-	if strings.Contains(filePath, pattern) {
-		return true
+// parseGitLog parses the output of the git log format used by GetOutgoingCommits.
+// The result is never nil, so no output means no commits rather than unknown ones.
+func parseGitLog(output string) []schema.CommitEvent {
+	commits := []schema.CommitEvent{}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(record, "\x1f", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		commits = append(commits, schema.CommitEvent{
+			SHA:     strings.TrimSpace(fields[0]),
+			Author:  strings.TrimSpace(fields[1]),
+			Message: strings.TrimSpace(fields[2]),
+			Files:   parseGitStatus(fields[3]),
+		})
 	}
+	return commits
+}
+
+// parseGitStatus parses git diff --name-status -z output. The status and each path
+// end in NUL, so paths are read exactly, whatever characters they contain. Renames
+// and copies list two paths, of which the first is reported.
+func parseGitStatus(output string) []schema.FileStatus {
+	var files []schema.FileStatus
+	fields := strings.Split(output, "\x00")
+	for i := 0; i+1 < len(fields); i++ {
+		// git log puts a newline between the commit and its list
+		code := strings.TrimSpace(fields[i])
+		if code == "" {
+			continue
+		}
+		path := fields[i+1]
+		i++
 
-	return false
+		status := "modified"
+		switch code[0] {
+		case 'A':
+			status = "added"
+		case 'M':
+			status = "modified"
+		case 'D':
+			status = "deleted"
+		case 'R':
+			status = "renamed"
+			i++
+		case 'C':
+			status = "copied"
+			i++
+		}
+		files = append(files, schema.FileStatus{
+			Path:   path,
+			Status: status,
+		})
+	}
+	return files
 }
 
 // parseCount parses a string to int, returning 0 on error
@@ -258,19 +431,31 @@ func parseCount(s string) (int, error) {
 
 // MockGitProvider provides predetermined values for testing
 type MockGitProvider struct {
-	Branch       string
-	Author       string
-	StagedFiles  []schema.FileStatus
-	PendingFiles []schema.FileStatus
-	Remote       string
-	Ahead        int
-	Behind       int
+	Branch          string
+	Author          string
+	StagedFiles     []schema.FileStatus
+	PendingFiles    []schema.FileStatus
+	Remote          string
+	Ahead           int
+	Behind          int
+	HeadSHA         string
+	CommitMessages  map[string]string // Messages by revision
+	Before          string
+	After           string
+	OutgoingCommits []schema.CommitEvent
+	Refs            map[string]string // Full ref names by short name
+	RepoRoot        string
+	Aliases         map[string]string // git alias values by name
 }
 
 func (m *MockGitProvider) GetBranch(cwd string) string {
 	return m.Branch
 }
 
+func (m *MockGitProvider) GetRepoRoot(cwd string) string {
+	return m.RepoRoot
+}
+
 func (m *MockGitProvider) GetAuthor(cwd string) string {
 	return m.Author
 }
@@ -279,8 +464,10 @@ func (m *MockGitProvider) GetStagedFiles(cwd string) []schema.FileStatus {
 	return m.StagedFiles
 }
 
-func (m *MockGitProvider) GetPendingFiles(cwd string, command string) []schema.FileStatus {
-	return m.PendingFiles
+// GetPendingFiles returns the staged files merged with PendingFiles, as if the
+// pending changes staged them
+func (m *MockGitProvider) GetPendingFiles(cwd string, pending PendingCommit) []schema.FileStatus {
+	return mergeFiles(m.StagedFiles, m.PendingFiles)
 }
 
 func (m *MockGitProvider) GetRemote(cwd string) string {
@@ -293,3 +480,37 @@ func (m *MockGitProvider) GetRemote(cwd string) string {
 func (m *MockGitProvider) GetAheadBehind(cwd string) (ahead, behind int) {
 	return m.Ahead, m.Behind
 }
+
+func (m *MockGitProvider) GetHeadSHA(cwd string) string {
+	return m.HeadSHA
+}
+
+func (m *MockGitProvider) GetCommitMessage(cwd string, rev string) string {
+	return m.CommitMessages[rev]
+}
+
+func (m *MockGitProvider) GetOutgoingCommits(cwd string, push PushRefspec) (before, after string, commits []schema.CommitEvent) {
+	return m.Before, m.After, m.OutgoingCommits
+}
+
+func (m *MockGitProvider) ResolveRef(cwd string, name string) string {
+	return m.Refs[name]
+}
+
+// ListRefs returns the full ref names in Refs that start with prefix, sorted
+func (m *MockGitProvider) ListRefs(cwd string, prefix string) []string {
+	seen := make(map[string]bool)
+	var refs []string
+	for _, ref := range m.Refs {
+		if strings.HasPrefix(ref, prefix) && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func (m *MockGitProvider) GetAliases(cwd string) map[string]string {
+	return m.Aliases
+}
@@ -1,12 +1,17 @@
 package event
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
-// TestParseGitStatus tests parsing of git diff --name-status output
+// TestParseGitStatus tests parsing of git diff --name-status -z output
 func TestParseGitStatus(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -20,27 +25,27 @@ func TestParseGitStatus(t *testing.T) {
 		},
 		{
 			name:   "single modified",
-			output: "M\tsrc/app.ts",
+			output: "M\x00src/app.ts\x00",
 			want:   []schema.FileStatus{{Path: "src/app.ts", Status: "modified"}},
 		},
 		{
 			name:   "single added",
-			output: "A\tnew-file.ts",
+			output: "A\x00new-file.ts\x00",
 			want:   []schema.FileStatus{{Path: "new-file.ts", Status: "added"}},
 		},
 		{
 			name:   "single deleted",
-			output: "D\told-file.ts",
+			output: "D\x00old-file.ts\x00",
 			want:   []schema.FileStatus{{Path: "old-file.ts", Status: "deleted"}},
 		},
 		{
 			name:   "renamed",
-			output: "R\told.ts\tnew.ts",
-			want:   []schema.FileStatus{{Path: "old.ts", Status: "renamed"}},
+			output: "R100\x00old.ts\x00new.ts\x00M\x00after.ts\x00",
+			want:   []schema.FileStatus{{Path: "old.ts", Status: "renamed"}, {Path: "after.ts", Status: "modified"}},
 		},
 		{
 			name:   "multiple files",
-			output: "M\tfile1.ts\nA\tfile2.ts\nD\tfile3.ts",
+			output: "M\x00file1.ts\x00A\x00file2.ts\x00D\x00file3.ts\x00",
 			want: []schema.FileStatus{
 				{Path: "file1.ts", Status: "modified"},
 				{Path: "file2.ts", Status: "added"},
@@ -48,8 +53,17 @@ func TestParseGitStatus(t *testing.T) {
 			},
 		},
 		{
-			name:   "with trailing newline",
-			output: "M\tfile.ts\n",
+			name:   "paths with spaces, tabs, quotes and non-ASCII characters",
+			output: "D\x00my file.txt\x00A\x00docs/caf\u00e9.md\x00M\x00a\tb \"c\".md\x00",
+			want: []schema.FileStatus{
+				{Path: "my file.txt", Status: "deleted"},
+				{Path: "docs/caf\u00e9.md", Status: "added"},
+				{Path: "a\tb \"c\".md", Status: "modified"},
+			},
+		},
+		{
+			name:   "after a git log record",
+			output: "\x00\nM\x00file.ts\x00",
 			want:   []schema.FileStatus{{Path: "file.ts", Status: "modified"}},
 		},
 	}
@@ -70,120 +84,6 @@ func TestParseGitStatus(t *testing.T) {
 	}
 }
 
-// TestParsePorcelainStatus tests parsing of git status --porcelain output
-func TestParsePorcelainStatus(t *testing.T) {
-	tests := []struct {
-		name   string
-		output string
-		want   []schema.FileStatus
-	}{
-		{
-			name:   "empty",
-			output: "",
-			want:   nil,
-		},
-		{
-			name:   "modified in working tree",
-			output: " M src/app.ts", // XY format: space=not staged, M=modified in worktree
-			want:   []schema.FileStatus{{Path: "src/app.ts", Status: "modified"}},
-		},
-		{
-			name:   "modified in index",
-			output: "M  src/app.ts", // XY format: M=staged, space=clean worktree
-			want:   []schema.FileStatus{{Path: "src/app.ts", Status: "modified"}},
-		},
-		{
-			name:   "added",
-			output: "A  new-file.ts",
-			want:   []schema.FileStatus{{Path: "new-file.ts", Status: "added"}},
-		},
-		{
-			name:   "deleted",
-			output: "D  old-file.ts",
-			want:   []schema.FileStatus{{Path: "old-file.ts", Status: "deleted"}},
-		},
-		{
-			name:   "untracked",
-			output: "?? untracked.ts",
-			want:   []schema.FileStatus{{Path: "untracked.ts", Status: "added"}},
-		},
-		{
-			name:   "renamed",
-			output: "R  old.ts -> new.ts",
-			want:   []schema.FileStatus{{Path: "new.ts", Status: "renamed"}},
-		},
-		{
-			name:   "multiple files",
-			output: " M file1.ts\nA  file2.ts\n?? file3.ts",
-			want: []schema.FileStatus{
-				{Path: "file1.ts", Status: "modified"},
-				{Path: "file2.ts", Status: "added"},
-				{Path: "file3.ts", Status: "added"},
-			},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parsePorcelainStatus(tt.output)
-			if len(got) != len(tt.want) {
-				t.Errorf("parsePorcelainStatus() returned %d files, want %d", len(got), len(tt.want))
-				return
-			}
-			for i, f := range got {
-				if f.Path != tt.want[i].Path || f.Status != tt.want[i].Status {
-					t.Errorf("parsePorcelainStatus()[%d] = %+v, want %+v", i, f, tt.want[i])
-				}
-			}
-		})
-	}
-}
-
-// TestMatchGitAddPattern tests git add pattern matching
-func TestMatchGitAddPattern(t *testing.T) {
-	tests := []struct {
-		name     string
-		filePath string
-		pattern  string
-		want     bool
-	}{
-		// Dot matches everything
-		{"dot matches any", "src/app.ts", ".", true},
-		{"dot matches nested", "a/b/c/d.ts", ".", true},
-
-		// All flag matches everything
-		{"-A matches any", "src/app.ts", "-A", true},
-		{"--all matches any", "src/app.ts", "--all", true},
-
-		// Exact match
-		{"exact match", "file.ts", "file.ts", true},
-		{"exact no match", "file.ts", "other.ts", false},
-
-		// Glob patterns
-		{"glob *.ts match", "app.ts", "*.ts", true},
-		{"glob *.ts no match", "app.js", "*.ts", false},
-		{"glob src/*.ts match", "src/app.ts", "src/*.ts", true}, // matchGitAddPattern uses string contains
-
-		// Directory patterns
-		{"dir match", "src/app.ts", "src/", true},
-		{"dir match no slash", "src/app.ts", "src", true},
-		{"dir no match", "lib/app.ts", "src/", false},
-
-		// Path contains
-		{"path contains", "src/components/Button.tsx", "components", true},
-		{"path not contains", "src/utils/helper.ts", "components", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := matchGitAddPattern(tt.filePath, tt.pattern, "/test")
-			if got != tt.want {
-				t.Errorf("matchGitAddPattern(%q, %q) = %v, want %v", tt.filePath, tt.pattern, got, tt.want)
-			}
-		})
-	}
-}
-
 // TestMockGitProvider tests the mock provider
 func TestMockGitProvider(t *testing.T) {
 	mock := &MockGitProvider{
@@ -209,7 +109,7 @@ func TestMockGitProvider(t *testing.T) {
 	if len(mock.GetStagedFiles("/any")) != 1 {
 		t.Error("GetStagedFiles mismatch")
 	}
-	if len(mock.GetPendingFiles("/any", "git add .")) != 1 {
+	if len(mock.GetPendingFiles("/any", PendingCommit{All: true})) != 2 {
 		t.Error("GetPendingFiles mismatch")
 	}
 	if mock.GetRemote("/any") != "upstream" {
@@ -241,3 +141,440 @@ func TestMockGitProviderDefaults(t *testing.T) {
 		t.Error("GetStagedFiles should return nil")
 	}
 }
+
+// TestParseGitLog tests parsing of the git log format used for outgoing commits
+func TestParseGitLog(t *testing.T) {
+	output := "\x1eabc123\x1fdev@example.com\x1ffeat: add migration\n\nLonger body\n\x1f\x00\nA\x00migrations/001.sql\x00M\x00README.md\x00" +
+		"\x1edef456\x1fdev@example.com\x1ffix: typo\n\x1f\x00\nM\x00docs/guide.md\x00"
+
+	commits := parseGitLog(output)
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].SHA != "abc123" || commits[0].Author != "dev@example.com" {
+		t.Errorf("Unexpected first commit: %+v", commits[0])
+	}
+	if commits[0].Message != "feat: add migration\n\nLonger body" {
+		t.Errorf("Message = %q", commits[0].Message)
+	}
+	if len(commits[0].Files) != 2 || commits[0].Files[0].Path != "migrations/001.sql" || commits[0].Files[0].Status != "added" {
+		t.Errorf("Unexpected first commit files: %+v", commits[0].Files)
+	}
+	if len(commits[1].Files) != 1 || commits[1].Files[0].Path != "docs/guide.md" {
+		t.Errorf("Unexpected second commit files: %+v", commits[1].Files)
+	}
+
+	if got := parseGitLog(""); got == nil || len(got) != 0 {
+		t.Errorf("Expected no commits for empty output, got %#v", got)
+	}
+}
+
+// TestRealGitProviderGetOutgoingCommits tests outgoing commit detection against a real repository
+func TestRealGitProviderGetOutgoingCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	local := filepath.Join(root, "local")
+	runGit(t, root, "init", "--bare", "-q", remote)
+	runGit(t, root, "init", "-q", local)
+	runGit(t, local, "checkout", "-q", "-b", "main")
+
+	writeFile(t, filepath.Join(local, "README.md"), "hello")
+	runGit(t, local, "add", ".")
+	runGit(t, local, "commit", "-q", "-m", "initial")
+
+	provider := &RealGitProvider{}
+	head := PushRefspec{Src: "HEAD", Dst: "refs/heads/main"}
+
+	// No remote-tracking ref yet: every commit not on a remote is outgoing
+	before, after, commits := provider.GetOutgoingCommits(local, head)
+	if before != "" {
+		t.Errorf("Expected empty before without upstream, got %q", before)
+	}
+	if after == "" {
+		t.Error("Expected after to be HEAD sha")
+	}
+	if len(commits) != 1 || commits[0].Message != "initial" {
+		t.Fatalf("Expected 1 outgoing commit without upstream, got %+v", commits)
+	}
+
+	runGit(t, local, "remote", "add", "origin", remote)
+	runGit(t, local, "push", "-q", "-u", "origin", "main")
+
+	writeFile(t, filepath.Join(local, "migrations", "001.sql"), "create table x;")
+	runGit(t, local, "add", ".")
+	runGit(t, local, "commit", "-q", "-m", "add migration")
+
+	before, after, commits = provider.GetOutgoingCommits(local, head)
+	if before == "" || after == "" || before == after {
+		t.Errorf("Expected distinct before/after shas, got %q..%q", before, after)
+	}
+	if len(commits) != 1 {
+		t.Fatalf("Expected 1 outgoing commit, got %d: %+v", len(commits), commits)
+	}
+	if commits[0].SHA != after {
+		t.Errorf("Expected outgoing commit sha %q, got %q", after, commits[0].SHA)
+	}
+	if len(commits[0].Files) != 1 || commits[0].Files[0].Path != "migrations/001.sql" {
+		t.Errorf("Unexpected outgoing files: %+v", commits[0].Files)
+	}
+
+	// The range follows the refspec, not the current branch
+	runGit(t, local, "checkout", "-q", "-b", "other")
+	writeFile(t, filepath.Join(local, "config", "prod.yml"), "x: 1")
+	runGit(t, local, "add", ".")
+	runGit(t, local, "commit", "-q", "-m", "change config")
+	runGit(t, local, "checkout", "-q", "main")
+	runGit(t, local, "tag", "v1")
+
+	tests := []struct {
+		name  string
+		push  PushRefspec
+		files []string
+	}{
+		{"other branch onto main", PushRefspec{Remote: "origin", Src: "other", Dst: "refs/heads/main"}, []string{"config/prod.yml", "migrations/001.sql"}},
+		{"main from another branch", PushRefspec{Remote: "origin", Src: "main", Dst: "refs/heads/main"}, []string{"migrations/001.sql"}},
+		{"new branch", PushRefspec{Remote: "origin", Src: "other", Dst: "refs/heads/other"}, []string{"config/prod.yml", "migrations/001.sql"}},
+		{"tag", PushRefspec{Remote: "origin", Src: "v1", Dst: "refs/tags/v1"}, []string{"migrations/001.sql"}},
+		{"delete", PushRefspec{Remote: "origin", Dst: "refs/heads/main"}, nil},
+	}
+	runGit(t, local, "checkout", "-q", "other")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, commits := provider.GetOutgoingCommits(local, tt.push)
+			var files []string
+			for _, commit := range commits {
+				for _, f := range commit.Files {
+					files = append(files, f.Path)
+				}
+			}
+			if strings.Join(files, ",") != strings.Join(tt.files, ",") {
+				t.Errorf("Expected files %v, got %v", tt.files, files)
+			}
+		})
+	}
+	// Pushes known to send nothing are told apart from ones that could not be listed
+	if _, _, commits := provider.GetOutgoingCommits(local, PushRefspec{Remote: "origin", Dst: "refs/heads/feature"}); commits == nil || len(commits) != 0 {
+		t.Errorf("Expected no commits for a branch delete, got %#v", commits)
+	}
+	runGit(t, local, "push", "-q", "origin", "other")
+	if _, _, commits := provider.GetOutgoingCommits(local, PushRefspec{Remote: "origin", Src: "other", Dst: "refs/heads/other"}); commits == nil || len(commits) != 0 {
+		t.Errorf("Expected no commits for an up-to-date push, got %#v", commits)
+	}
+	if _, _, commits := provider.GetOutgoingCommits(local, PushRefspec{Remote: "origin", Src: "missing", Dst: "refs/heads/other"}); commits != nil {
+		t.Errorf("Expected unknown commits for an unresolvable source, got %#v", commits)
+	}
+}
+
+// TestRealGitProviderGetPendingFiles tests that staging is replayed by git itself
+func TestRealGitProviderGetPendingFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	writeFile(t, filepath.Join(repo, ".gitignore"), "*.log\n")
+	writeFile(t, filepath.Join(repo, "src", "app.go"), "package app")
+	writeFile(t, filepath.Join(repo, "src", "app_test.go"), "package app")
+	writeFile(t, filepath.Join(repo, "docs", "guide.md"), "guide")
+	writeFile(t, filepath.Join(repo, "README.md"), "hello")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	// Modify tracked files, stage one of them, and add untracked and ignored files
+	writeFile(t, filepath.Join(repo, "src", "app.go"), "package app // changed")
+	writeFile(t, filepath.Join(repo, "src", "app_test.go"), "package app // changed")
+	writeFile(t, filepath.Join(repo, "docs", "guide.md"), "guide v2")
+	runGit(t, repo, "add", "docs/guide.md")
+	writeFile(t, filepath.Join(repo, "src", "new.go"), "package app // new")
+	writeFile(t, filepath.Join(repo, "debug.log"), "noise")
+
+	provider := &RealGitProvider{}
+	tests := []struct {
+		name    string
+		pending PendingCommit
+		want    []string
+	}{
+		{"add all respects gitignore", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"."}}}}, []string{"docs/guide.md", "src/app.go", "src/app_test.go", "src/new.go"}},
+		{"add glob", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"src/*_test.go"}}}}, []string{"docs/guide.md", "src/app_test.go"}},
+		{"add from subdirectory", PendingCommit{Adds: []PendingAdd{{Dir: filepath.Join(repo, "src"), Args: []string{"new.go"}}}}, []string{"docs/guide.md", "src/new.go"}},
+		{"add update", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"-u"}}}}, []string{"docs/guide.md", "src/app.go", "src/app_test.go"}},
+		{"exclude magic", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"-A", "--", ".", ":(exclude)src/*_test.go"}}}}, []string{"docs/guide.md", "src/app.go", "src/new.go"}},
+		{"commit all", PendingCommit{All: true}, []string{"docs/guide.md", "src/app.go", "src/app_test.go"}},
+		{"commit pathspec", PendingCommit{Pathspecs: []string{"src/app.go"}}, []string{"src/app.go"}},
+		{"commit pathspec with include", PendingCommit{Pathspecs: []string{"src/app.go"}, Include: true}, []string{"docs/guide.md", "src/app.go"}},
+		{"interactive add stages whole paths", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"-p", "--edit", "src/app.go"}}}}, []string{"docs/guide.md", "src/app.go"}},
+		{"bundled interactive option", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"-Ai", "src"}}}}, []string{"docs/guide.md", "src/app.go", "src/app_test.go", "src/new.go"}},
+		{"failing add stages nothing", PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"missing.txt", "src/new.go"}}}}, []string{"docs/guide.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range provider.GetPendingFiles(repo, tt.pending) {
+				got = append(got, f.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected files %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// The real index is left as it was
+	if staged := provider.GetStagedFiles(repo); len(staged) != 1 || staged[0].Path != "docs/guide.md" {
+		t.Errorf("Expected only docs/guide.md to stay staged, got %+v", staged)
+	}
+
+	// Blobs written while replaying git add stay out of the repository
+	cmd := exec.Command("git", "hash-object", "src/new.go")
+	cmd.Dir = repo
+	blob, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git hash-object failed: %v", err)
+	}
+	cmd = exec.Command("git", "cat-file", "-e", strings.TrimSpace(string(blob)))
+	cmd.Dir = repo
+	if err := cmd.Run(); err == nil {
+		t.Error("Expected the blob of src/new.go not to be written to the repository")
+	}
+}
+
+// TestRealGitProviderUnusualPaths tests that paths git would quote, such as ones
+// with non-ASCII characters, tabs or quotes, are reported as they are
+func TestRealGitProviderUnusualPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "checkout", "-q", "-b", "main")
+	paths := []string{"a\tb.md", "docs/caf\u00e9.md", "say \"hi\".md"}
+	for _, path := range paths {
+		writeFile(t, filepath.Join(repo, filepath.FromSlash(path)), "x")
+	}
+
+	format := func(files []schema.FileStatus) []string {
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Path)
+		}
+		return got
+	}
+
+	provider := &RealGitProvider{}
+	pending := PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"."}}}}
+	if got := format(provider.GetPendingFiles(repo, pending)); !reflect.DeepEqual(got, paths) {
+		t.Errorf("GetPendingFiles() = %q, want %q", got, paths)
+	}
+
+	runGit(t, repo, "add", ".")
+	if got := format(provider.GetStagedFiles(repo)); !reflect.DeepEqual(got, paths) {
+		t.Errorf("GetStagedFiles() = %q, want %q", got, paths)
+	}
+
+	runGit(t, repo, "commit", "-q", "-m", "unusual paths")
+	_, _, commits := provider.GetOutgoingCommits(repo, PushRefspec{Src: "HEAD", Dst: "refs/heads/main"})
+	if len(commits) != 1 || !reflect.DeepEqual(format(commits[0].Files), paths) {
+		t.Errorf("GetOutgoingCommits() = %+v, want one commit with %q", commits, paths)
+	}
+}
+
+// TestRealGitProviderResolveRef tests that short names resolve to tags or branches
+// by what exists in the repository, not by how they look
+func TestRealGitProviderResolveRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "checkout", "-q", "-b", "main")
+	writeFile(t, filepath.Join(repo, "README.md"), "hello")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	runGit(t, repo, "branch", "v2")
+	runGit(t, repo, "tag", "release-2026")
+
+	provider := &RealGitProvider{}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"main", "refs/heads/main"},
+		{"v2", "refs/heads/v2"},
+		{"release-2026", "refs/tags/release-2026"},
+		{"refs/tags/release-2026", "refs/tags/release-2026"},
+		{"HEAD", "refs/heads/main"},
+		{"missing", ""},
+		{"--all", ""},
+	}
+	for _, tt := range tests {
+		if got := provider.ResolveRef(repo, tt.name); got != tt.want {
+			t.Errorf("ResolveRef(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	// Revisions from the command line are never passed to git as options
+	if got := provider.GetCommitMessage(repo, "HEAD"); got != "initial" {
+		t.Errorf("GetCommitMessage(HEAD) = %q, want %q", got, "initial")
+	}
+	if got := provider.GetCommitMessage(repo, "--output=stolen"); got != "" {
+		t.Errorf("GetCommitMessage(--output=stolen) = %q, want \"\"", got)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "stolen")); err == nil {
+		t.Error("Expected an option-like revision not to reach git")
+	}
+}
+
+// TestRealGitProviderAliasesAndRefs tests reading aliases, including ones git ignores
+// because they shadow a built-in subcommand, and listing refs for --tags and --all
+func TestRealGitProviderAliasesAndRefs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "checkout", "-q", "-b", "main")
+	writeFile(t, filepath.Join(repo, "README.md"), "hello")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+	runGit(t, repo, "branch", "feature")
+	runGit(t, repo, "tag", "v1.0")
+	runGit(t, repo, "config", "alias.ci", "commit -v")
+	runGit(t, repo, "config", "alias.pf", "!git push --force\ngit status")
+	runGit(t, repo, "config", "alias.status", "push")
+
+	provider := &RealGitProvider{}
+	aliases := provider.GetAliases(repo)
+	if aliases["ci"] != "commit -v" {
+		t.Errorf("Expected alias ci to be %q, got %q", "commit -v", aliases["ci"])
+	}
+	if aliases["pf"] != "!git push --force\ngit status" {
+		t.Errorf("Expected the multi-line alias pf to be read whole, got %q", aliases["pf"])
+	}
+	if _, ok := aliases["status"]; ok {
+		t.Errorf("Expected an alias named like a built-in to be left out, got %q", aliases["status"])
+	}
+
+	if got, want := provider.ListRefs(repo, "refs/heads/"), []string{"refs/heads/feature", "refs/heads/main"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRefs(refs/heads/) = %v, want %v", got, want)
+	}
+	if got, want := provider.ListRefs(repo, "refs/tags/"), []string{"refs/tags/v1.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListRefs(refs/tags/) = %v, want %v", got, want)
+	}
+}
+
+// TestRealGitProviderGetBranch tests that a detached HEAD has no branch
+func TestRealGitProviderGetBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "checkout", "-q", "-b", "main")
+	writeFile(t, filepath.Join(repo, "README.md"), "hello")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	provider := &RealGitProvider{}
+	if got := provider.GetBranch(repo); got != "main" {
+		t.Errorf("GetBranch() = %q, want %q", got, "main")
+	}
+	runGit(t, repo, "checkout", "-q", "--detach")
+	if got := provider.GetBranch(repo); got != "" {
+		t.Errorf("GetBranch() on a detached HEAD = %q, want \"\"", got)
+	}
+}
+
+// TestRealGitProviderRenames tests that staged renames report both paths
+func TestRealGitProviderRenames(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "config", "diff.renames", "true")
+	writeFile(t, filepath.Join(repo, "config", "prod.yml"), "replicas: 3\nregion: eu\n")
+	writeFile(t, filepath.Join(repo, "docs", "old.md"), "a guide long enough to be detected as a rename\n")
+	runGit(t, repo, "add", ".")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	runGit(t, repo, "mv", "config/prod.yml", "config/production.yml")
+	if err := os.Rename(filepath.Join(repo, "docs", "old.md"), filepath.Join(repo, "docs", "new.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	format := func(files []schema.FileStatus) string {
+		var got []string
+		for _, f := range files {
+			got = append(got, f.Status+" "+f.Path)
+		}
+		return strings.Join(got, ", ")
+	}
+
+	provider := &RealGitProvider{}
+	if got, want := format(provider.GetStagedFiles(repo)), "deleted config/prod.yml, added config/production.yml"; got != want {
+		t.Errorf("GetStagedFiles() = %q, want %q", got, want)
+	}
+	pending := PendingCommit{Adds: []PendingAdd{{Dir: repo, Args: []string{"-A", "docs"}}}}
+	want := "deleted config/prod.yml, added config/production.yml, added docs/new.md, deleted docs/old.md"
+	if got := format(provider.GetPendingFiles(repo, pending)); got != want {
+		t.Errorf("GetPendingFiles() = %q, want %q", got, want)
+	}
+}
+
+// TestRealGitProviderGetRepoRoot tests finding the repository root from a subdirectory
+func TestRealGitProviderGetRepoRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	writeFile(t, filepath.Join(repo, "src", "app.go"), "package app")
+
+	want, err := filepath.EvalSymlinks(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &RealGitProvider{}
+	if got := provider.GetRepoRoot(filepath.Join(repo, "src")); got != want {
+		t.Errorf("GetRepoRoot() = %q, want %q", got, want)
+	}
+	if got := provider.GetRepoRoot(t.TempDir()); got != "" {
+		t.Errorf("GetRepoRoot() outside a repository = %q, want \"\"", got)
+	}
+}
+
+// runGit runs a git command in dir, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+}
+
+// writeFile writes content to path, creating parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,525 @@
+package event
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// GitOperation is a git subcommand found in a command line
+type GitOperation struct {
+	Subcommand string   // e.g. "commit", "push", "add"
+	Args       []string // Arguments after the subcommand
+	Dir        string   // Directory git runs in from cd and -C, relative to the starting directory ("" if unchanged)
+	Input      string   // Text given on stdin, such as a heredoc
+}
+
+// WorkDir returns the directory the operation runs in, given the directory the
+// command line started in
+func (op GitOperation) WorkDir(cwd string) string {
+	if op.Dir == "" {
+		return cwd
+	}
+	if filepath.IsAbs(op.Dir) || cwd == "" {
+		return op.Dir
+	}
+	return filepath.Join(cwd, op.Dir)
+}
+
+// ParseGitOperations returns the git operations a command line runs, in order
+func ParseGitOperations(line string, dialect Dialect) []GitOperation {
+	var ops []GitOperation
+	for _, cmd := range ParseCommandLine(line, dialect) {
+		if op, ok := gitOperation(cmd, dialect); ok {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// commandWrappers run the command that follows their own options, and list the
+// options that take a separate value. xargs adds the words it reads to the end of
+// the command.
+var commandWrappers = map[string]map[string]bool{
+	"env":     {"-u": true, "--unset": true, "-C": true, "--chdir": true},
+	"sudo":    {"-u": true, "--user": true, "-g": true, "--group": true, "-h": true, "--host": true, "-p": true, "--prompt": true, "-C": true, "-D": true, "--chdir": true},
+	"nice":    {"-n": true, "--adjustment": true},
+	"command": {},
+	"exec":    {"-a": true},
+	"nohup":   {},
+	"time":    {"-f": true, "--format": true, "-o": true, "--output": true},
+	"xargs": {
+		"-a": true, "--arg-file": true, "-d": true, "--delimiter": true, "-E": true, "-I": true,
+		"-L": true, "-n": true, "--max-args": true, "-P": true, "--max-procs": true, "-s": true, "--max-chars": true,
+	},
+}
+
+// gitGlobalOptionsWithValue are git options before the subcommand that take a
+// separate value
+var gitGlobalOptionsWithValue = map[string]bool{
+	"-c": true, "--git-dir": true, "--work-tree": true, "--namespace": true, "--super-prefix": true, "--config-env": true,
+}
+
+// gitOperation returns the git operation a simple command runs, if any
+func gitOperation(cmd SimpleCommand, dialect Dialect) (GitOperation, bool) {
+	args := unwrapCommand(cmd.Args, dialect)
+	if len(args) == 0 || commandName(args[0], dialect) != "git" {
+		return GitOperation{}, false
+	}
+
+	dir := cmd.Dir
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-C" && i+1 < len(args):
+			i++
+			dir = joinDir(dir, args[i])
+		case gitGlobalOptionsWithValue[arg]:
+			i++
+		case arg == "--help" || arg == "-h" || arg == "--version" || arg == "-v":
+			// Prints help or the version without running the subcommand
+			return GitOperation{}, false
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return GitOperation{Subcommand: arg, Args: args[i+1:], Dir: dir, Input: cmd.Input}, true
+		}
+	}
+	return GitOperation{}, false
+}
+
+// unwrapCommand strips commands such as env and sudo that run another command,
+// returning the arguments of the command they run
+func unwrapCommand(args []string, dialect Dialect) []string {
+	for len(args) > 0 {
+		name := commandName(args[0], dialect)
+		optionsWithValue, ok := commandWrappers[name]
+		if !ok {
+			return args
+		}
+		rest := args[1:]
+		for len(rest) > 0 && (strings.HasPrefix(rest[0], "-") || (name == "env" && isAssignment(rest[0]))) {
+			if name == "command" && (rest[0] == "-v" || rest[0] == "-V") {
+				// command -v only looks the command up
+				return nil
+			}
+			if rest[0] == "--" {
+				rest = rest[1:]
+				break
+			}
+			if optionsWithValue[rest[0]] && len(rest) > 1 {
+				rest = rest[1:]
+			}
+			rest = rest[1:]
+		}
+		args = rest
+	}
+	return args
+}
+
+// firstOperation returns the first operation running subcommand, or nil
+func firstOperation(ops []GitOperation, subcommand string) *GitOperation {
+	for i := range ops {
+		if ops[i].Subcommand == subcommand {
+			return &ops[i]
+		}
+	}
+	return nil
+}
+
+// IsGitCommitCommand checks if a shell command contains a git commit
+func IsGitCommitCommand(command string) bool {
+	return firstOperation(ParseGitOperations(command, DialectPOSIX), "commit") != nil
+}
+
+// IsGitPushCommand checks if a shell command contains a git push
+func IsGitPushCommand(command string) bool {
+	return firstOperation(ParseGitOperations(command, DialectPOSIX), "push") != nil
+}
+
+// IsGitAddCommand checks if a shell command contains a git add
+func IsGitAddCommand(command string) bool {
+	return firstOperation(ParseGitOperations(command, DialectPOSIX), "add") != nil
+}
+
+// ExtractCommitMessage extracts the commit message from a git commit command. It
+// covers messages given on the command line (-m, --message, -F - with a heredoc);
+// messages read from files or earlier commits need the repository, so only the
+// detector resolves those.
+func ExtractCommitMessage(command string) string {
+	op := firstOperation(ParseGitOperations(command, DialectPOSIX), "commit")
+	if op == nil {
+		return ""
+	}
+	message, _ := parseCommitOptions(op.Args).inlineMessage(op.Input)
+	return message
+}
+
+// commitOptions are the git commit options that decide the message and flags of
+// the commit
+type commitOptions struct {
+	messages   []string // -m values, in order; each becomes a paragraph
+	file       string   // -F value; "-" reads stdin
+	reuse      string   // Commit whose message -C or -c reuses
+	fixup      string   // --fixup value, optionally prefixed with amend: or reword:
+	squash     string   // --squash value
+	amend      bool
+	allowEmpty bool
+	noVerify   bool
+	all        bool     // -a stages modified tracked files first
+	include    bool     // -i commits pathspecs along with the staged changes
+	pathspecs  []string // Paths to commit instead of the staged changes
+}
+
+// commitShortOptionsWithValue are git commit short options that take a value,
+// attached or as the next argument
+const commitShortOptionsWithValue = "mFCct"
+
+// commitLongOptionsWithValue are git commit long options whose value may be the
+// next argument, other than those setting the message
+var commitLongOptionsWithValue = map[string]bool{
+	"--author": true, "--date": true, "--cleanup": true, "--trailer": true, "--template": true, "--pathspec-from-file": true,
+}
+
+// parseCommitOptions reads the options given to git commit
+func parseCommitOptions(args []string) commitOptions {
+	var opts commitOptions
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		// value returns the value of an option given as --name=value or --name value
+		value := func(name string) string {
+			if v, ok := strings.CutPrefix(arg, name+"="); ok {
+				return v
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		is := func(name string) bool {
+			return arg == name || strings.HasPrefix(arg, name+"=")
+		}
+
+		switch {
+		case arg == "--":
+			opts.pathspecs = append(opts.pathspecs, args[i+1:]...)
+			return opts
+		case is("--message"):
+			opts.messages = append(opts.messages, value("--message"))
+		case is("--file"):
+			opts.file = value("--file")
+		case is("--reuse-message"):
+			opts.reuse = value("--reuse-message")
+		case is("--reedit-message"):
+			opts.reuse = value("--reedit-message")
+		case is("--fixup"):
+			opts.fixup = value("--fixup")
+		case is("--squash"):
+			opts.squash = value("--squash")
+		case arg == "--amend":
+			opts.amend = true
+		case arg == "--allow-empty":
+			opts.allowEmpty = true
+		case arg == "--no-verify":
+			opts.noVerify = true
+		case arg == "--verify":
+			opts.noVerify = false
+		case arg == "--all":
+			opts.all = true
+		case arg == "--include":
+			opts.include = true
+		case arg == "--only":
+			opts.include = false
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg, "=")
+			if commitLongOptionsWithValue[name] && !strings.Contains(arg, "=") {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			i = opts.readShortOptions(args, i)
+		default:
+			opts.pathspecs = append(opts.pathspecs, arg)
+		}
+	}
+	return opts
+}
+
+// readShortOptions reads a cluster of short options such as -am or -m<msg>, and
+// returns the index of the last argument it used
+func (opts *commitOptions) readShortOptions(args []string, i int) int {
+	cluster := args[i][1:]
+	for j, flag := range cluster {
+		switch flag {
+		case 'n':
+			opts.noVerify = true
+			continue
+		case 'a':
+			opts.all = true
+			continue
+		case 'i':
+			opts.include = true
+			continue
+		case 'o':
+			opts.include = false
+			continue
+		case 'S', 'u':
+			// Optional values are always attached
+			return i
+		}
+		if !strings.ContainsRune(commitShortOptionsWithValue, flag) {
+			continue
+		}
+
+		value := cluster[j+1:]
+		if value == "" && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		switch flag {
+		case 'm':
+			opts.messages = append(opts.messages, value)
+		case 'F':
+			opts.file = value
+		case 'C', 'c':
+			opts.reuse = value
+		}
+		return i
+	}
+	return i
+}
+
+// inlineMessage returns the message when it is given on the command line or on
+// stdin, reporting false when it comes from a file or another commit
+func (opts commitOptions) inlineMessage(input string) (string, bool) {
+	switch {
+	case len(opts.messages) > 0 && opts.fixup == "" && opts.squash == "":
+		return cleanupMessage(strings.Join(opts.messages, "\n\n")), true
+	case opts.file == "-":
+		return cleanupMessage(input), true
+	case opts.file == "" && opts.reuse == "" && opts.fixup == "" && opts.squash == "" && !opts.amend:
+		return "", true
+	}
+	return "", false
+}
+
+// cleanupMessage tidies a message the way git does when no editor is involved:
+// trailing whitespace is removed from each line, runs of blank lines become one,
+// and leading and trailing blank lines are dropped
+func cleanupMessage(message string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RefResolver returns the full name of the local ref a short name refers to, such
+// as refs/tags/v1.0 for v1.0, or "" if the name is not a ref
+type RefResolver func(name string) string
+
+// RefLister returns the full names of the local refs that start with prefix, such
+// as every tag for refs/tags/
+type RefLister func(prefix string) []string
+
+// ExtractPushRef determines the ref being pushed, or "" when it pushes the current
+// branch and that is unknown, or pushes a set of refs such as --tags. resolveRef
+// tells tags from branches; when it is nil, short names are taken to be branches.
+func ExtractPushRef(command string, currentBranch string, resolveRef RefResolver) string {
+	op := firstOperation(ParseGitOperations(command, DialectPOSIX), "push")
+	if op == nil {
+		return branchRef(currentBranch)
+	}
+	specs := pushRefspecs(op.Args, currentBranch, resolveRef, nil)
+	if len(specs) == 0 {
+		return ""
+	}
+	return specs[0].Dst
+}
+
+// pushOptionsWithValue are git push options that take a separate value
+var pushOptionsWithValue = map[string]bool{
+	"-o": true, "--push-option": true, "--receive-pack": true, "--exec": true, "--repo": true,
+}
+
+// pushOptions are the git push options that decide which refs are pushed
+type pushOptions struct {
+	remote   string   // First positional argument, "" for the default
+	refspecs []string // Positional arguments after the remote
+	delete   bool     // --delete: the refspecs name remote refs to delete
+	tags     bool     // --tags: every tag is pushed as well
+	all      bool     // --all or --branches: every branch is pushed
+	mirror   bool     // --mirror: every ref is pushed
+}
+
+// parsePushOptions reads the arguments given to git push
+func parsePushOptions(args []string) pushOptions {
+	var opts pushOptions
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case pushOptionsWithValue[arg]:
+			i++
+		case arg == "--delete":
+			opts.delete = true
+		case arg == "--tags":
+			opts.tags = true
+		case arg == "--all" || arg == "--branches":
+			opts.all = true
+		case arg == "--mirror":
+			opts.mirror = true
+		case strings.HasPrefix(arg, "--"):
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			// A cluster of short flags such as -fd; -o takes the rest as its value
+			for _, flag := range arg[1:] {
+				if flag == 'o' {
+					break
+				}
+				if flag == 'd' {
+					opts.delete = true
+				}
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) > 0 {
+		opts.remote = positional[0]
+		opts.refspecs = positional[1:]
+	}
+	return opts
+}
+
+// pushRefspecs returns each ref git push updates: the refspecs given, the refs
+// named by --delete, the branches, tags or every ref pushed by --all, --tags and
+// --mirror, or the current branch when none of these are given. listRefs finds the
+// refs --all, --tags and --mirror push; when it is nil they are left out.
+func pushRefspecs(args []string, currentBranch string, resolveRef RefResolver, listRefs RefLister) []PushRefspec {
+	if resolveRef == nil {
+		resolveRef = func(string) string { return "" }
+	}
+	if listRefs == nil {
+		listRefs = func(string) []string { return nil }
+	}
+	opts := parsePushOptions(args)
+
+	var specs []PushRefspec
+	for _, refspec := range opts.refspecs {
+		spec := parsePushRefspec(refspec, currentBranch, resolveRef)
+		if opts.delete {
+			// --delete takes plain ref names, as if each were written :name
+			name := strings.TrimPrefix(refspec, "+")
+			spec = PushRefspec{Dst: fullRef(name, resolveRef(name), "refs/heads/")}
+		}
+		specs = append(specs, spec)
+	}
+
+	// --mirror also deletes remote refs that do not exist locally, which cannot be
+	// seen from here
+	var prefixes []string
+	if opts.all || opts.mirror {
+		prefixes = append(prefixes, "refs/heads/")
+	}
+	if opts.tags || opts.mirror {
+		prefixes = append(prefixes, "refs/tags/")
+	}
+	for _, prefix := range prefixes {
+		for _, ref := range listRefs(prefix) {
+			specs = append(specs, PushRefspec{Src: ref, Dst: ref})
+		}
+	}
+
+	// Without refspecs or a set of refs, git push pushes the current branch
+	if len(specs) == 0 && prefixes == nil {
+		specs = []PushRefspec{{Src: "HEAD", Dst: branchRef(currentBranch)}}
+	}
+	for i := range specs {
+		specs[i].Remote = opts.remote
+	}
+	return specs
+}
+
+// parsePushRefspec splits a refspec into the local revision pushed and the ref it
+// updates on the remote. An empty source, as in :branch, deletes the remote ref.
+// Like git push, a source without a destination updates the ref of the same full
+// name, and a short destination is a tag when the source is a tag.
+func parsePushRefspec(refspec, currentBranch string, resolveRef RefResolver) PushRefspec {
+	refspec = strings.TrimPrefix(refspec, "+")
+	src, dst, found := strings.Cut(refspec, ":")
+	if src == "" {
+		return PushRefspec{Dst: fullRef(dst, resolveRef(dst), "refs/heads/")}
+	}
+
+	srcRef := branchRef(currentBranch)
+	if src != "HEAD" {
+		srcRef = fullRef(src, resolveRef(src), "refs/heads/")
+	}
+	if !found || dst == "" {
+		return PushRefspec{Src: src, Dst: srcRef}
+	}
+
+	prefix := "refs/heads/"
+	if strings.HasPrefix(srcRef, "refs/tags/") {
+		prefix = "refs/tags/"
+	}
+	return PushRefspec{Src: src, Dst: fullRef(dst, "", prefix)}
+}
+
+// fullRef returns name if it is already a full ref name, otherwise the name it
+// resolved to, or prefix+name when it did not resolve
+func fullRef(name, resolved, prefix string) string {
+	switch {
+	case strings.HasPrefix(name, "refs/"):
+		return name
+	case strings.HasPrefix(resolved, "refs/"):
+		return resolved
+	}
+	return prefix + name
+}
+
+// branchRef returns the ref of a branch, or "" when the branch is unknown, such as
+// on a detached HEAD
+func branchRef(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	return "refs/heads/" + branch
+}
+
+// ExtractGitAddFiles extracts file patterns from the git add commands in a command line
+func ExtractGitAddFiles(command string) []string {
+	var files []string
+	for _, op := range ParseGitOperations(command, DialectPOSIX) {
+		if op.Subcommand == "add" {
+			files = append(files, addPathspecs(op.Args)...)
+		}
+	}
+	return files
+}
+
+// addPathspecs returns the pathspecs given to git add, excluding flags
+func addPathspecs(args []string) []string {
+	var paths []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(paths, args[i+1:]...)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			paths = append(paths, arg)
+		}
+	}
+	return paths
+}
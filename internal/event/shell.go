@@ -0,0 +1,785 @@
+package event
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Dialect is the shell syntax a command line is written in
+type Dialect int
+
+const (
+	// DialectPOSIX is sh, bash and zsh syntax
+	DialectPOSIX Dialect = iota
+	// DialectPowerShell is the syntax of commands run by the powershell tool
+	DialectPowerShell
+)
+
+// DialectForTool returns the dialect of the commands a shell tool runs
+func DialectForTool(toolName string) Dialect {
+	if toolName == "powershell" {
+		return DialectPowerShell
+	}
+	return DialectPOSIX
+}
+
+// SimpleCommand is one command of a command line, such as either side of `a && b`
+// or the command inside `$(...)`
+type SimpleCommand struct {
+	Args  []string // Words after quote removal, without leading assignments or redirections; Args[0] is the program
+	Dir   string   // Directory earlier cd commands moved to, relative to the starting directory ("" if unchanged)
+	Input string   // Text given on stdin by a heredoc or here-string
+}
+
+// ParseCommandLine splits a command line into the simple commands it runs, in the
+// order they run: commands inside substitutions come before the command using their
+// output. Text in quotes, comments and heredoc bodies is never read as a command.
+// Unterminated quotes and parentheses are treated as closed at the end of the line.
+// Scripts handed to another shell, as in bash -c '...' or eval, are parsed in turn
+// and their commands take the place of the command that runs them.
+func ParseCommandLine(line string, dialect Dialect) []SimpleCommand {
+	p := &shellParser{src: []rune(line), dialect: dialect}
+	p.parseList("", false)
+
+	commands := make([]SimpleCommand, 0, len(p.commands))
+	for _, cmd := range p.commands {
+		script, ok := nestedScript(*cmd, dialect)
+		if !ok {
+			commands = append(commands, *cmd)
+			continue
+		}
+		for _, inner := range ParseCommandLine(script, DialectPOSIX) {
+			inner.Dir = joinDir(cmd.Dir, inner.Dir)
+			commands = append(commands, inner)
+		}
+	}
+	return commands
+}
+
+// posixShells are the shells whose -c script, or script on stdin, is parsed as
+// part of the command line
+var posixShells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true}
+
+// shellOptionsWithValue are options of posixShells that take a separate value
+var shellOptionsWithValue = map[string]bool{"-o": true, "+o": true, "-O": true, "+O": true, "--rcfile": true, "--init-file": true}
+
+// nestedScript returns the POSIX command line a command hands to a shell: the
+// script of sh -c or of a shell reading stdin, the words of eval, or the command
+// watch repeats. Only POSIX command lines are looked into.
+func nestedScript(cmd SimpleCommand, dialect Dialect) (string, bool) {
+	if dialect != DialectPOSIX {
+		return "", false
+	}
+	args := unwrapCommand(cmd.Args, dialect)
+	if len(args) == 0 {
+		return "", false
+	}
+
+	name := commandName(args[0], dialect)
+	switch {
+	case name == "eval":
+		return strings.Join(args[1:], " "), len(args) > 1
+	case name == "watch":
+		return watchScript(args[1:])
+	case !posixShells[name]:
+		return "", false
+	}
+
+	script := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			if i+1 < len(args) {
+				return args[i+1], script
+			}
+			i = len(args)
+		case shellOptionsWithValue[arg]:
+			i++
+		case strings.HasPrefix(arg, "--"):
+		case (strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "+")) && len(arg) > 1:
+			script = script || (arg[0] == '-' && strings.Contains(arg, "c"))
+		case script:
+			return arg, true
+		default:
+			// A script file, which is not read
+			return "", false
+		}
+	}
+	// Without -c or a script file the shell runs what it is given on stdin
+	return cmd.Input, !script && cmd.Input != ""
+}
+
+// watchScript returns the command watch runs given its arguments. watch hands the
+// words to sh -c, or with -x runs them as they are.
+func watchScript(args []string) (string, bool) {
+	direct := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		arg := args[0]
+		args = args[1:]
+		if arg == "--" {
+			break
+		}
+		switch arg {
+		case "-n", "--interval", "-q", "--equexit":
+			if len(args) > 0 {
+				args = args[1:]
+			}
+		case "-x", "--exec":
+			direct = true
+		}
+	}
+	if len(args) == 0 {
+		return "", false
+	}
+	if direct {
+		return quoteWords(args), true
+	}
+	return strings.Join(args, " "), true
+}
+
+// quoteWords joins words into a POSIX command line that parses back into them
+func quoteWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellParser reads a command line in a single pass. Lists inside subshells and
+// substitutions are parsed recursively so that quoting, heredocs and nesting are
+// handled the same way at every level.
+type shellParser struct {
+	src      []rune
+	pos      int
+	dialect  Dialect
+	commands []*SimpleCommand
+	heredocs []*pendingHeredoc // Heredocs whose bodies start after the next newline
+}
+
+// pendingHeredoc is a heredoc redirection whose body has not been read yet
+type pendingHeredoc struct {
+	delimiter string
+	stripTabs bool // <<- strips leading tabs from the body and the delimiter line
+	command   *SimpleCommand
+}
+
+// posixReservedWords start or end compound commands and are skipped at the start
+// of a command so that the command inside is seen
+var posixReservedWords = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "elif": true, "else": true,
+	"fi": true, "do": true, "done": true, "while": true, "until": true,
+}
+
+// parseList reads commands until the end of input or, when nested, the ')' that
+// closes the list. dir is the directory the list starts in.
+func (p *shellParser) parseList(dir string, nested bool) {
+	cmd := &SimpleCommand{Dir: dir}
+	finish := func() {
+		if len(cmd.Args) > 0 {
+			p.commands = append(p.commands, cmd)
+			dir = p.changeDir(cmd.Args, dir)
+		}
+		cmd = &SimpleCommand{Dir: dir}
+	}
+
+	for {
+		p.skipBlanks()
+		if p.pos >= len(p.src) {
+			finish()
+			return
+		}
+
+		ch := p.src[p.pos]
+		switch {
+		case ch == '\n':
+			p.pos++
+			finish()
+			p.readHeredocs()
+
+		case ch == '#':
+			p.skipComment()
+
+		case p.dialect == DialectPowerShell && p.hasPrefix("<#"):
+			p.skipBlockComment()
+
+		case ch == ')':
+			p.pos++
+			finish()
+			if nested {
+				return
+			}
+
+		case ch == '(':
+			start := p.pos
+			p.pos++
+			if p.dialect == DialectPowerShell && len(cmd.Args) > 0 {
+				// A parenthesized expression used as an argument
+				p.parseList(dir, true)
+				cmd.Args = append(cmd.Args, string(p.src[start:p.pos]))
+				continue
+			}
+			// A subshell: directory changes inside do not last past it
+			finish()
+			p.parseList(dir, true)
+
+		case p.dialect == DialectPOSIX && (p.hasPrefix("<(") || p.hasPrefix(">(")):
+			// Process substitution
+			start := p.pos
+			p.pos += 2
+			p.parseList(dir, true)
+			cmd.Args = append(cmd.Args, string(p.src[start:p.pos]))
+
+		case p.isRedirect():
+			p.readRedirect(cmd, dir)
+
+		case p.isOperator():
+			p.readOperator()
+			finish()
+
+		default:
+			word := p.readWord(dir)
+			if p.pos < len(p.src) && (p.src[p.pos] == '<' || p.src[p.pos] == '>') && isFileDescriptor(word, p.dialect) {
+				// The file descriptor of a redirection, as in 2>&1
+				continue
+			}
+			if len(cmd.Args) == 0 && p.dialect == DialectPOSIX && (isAssignment(word) || posixReservedWords[word]) {
+				continue
+			}
+			cmd.Args = append(cmd.Args, word)
+		}
+	}
+}
+
+func (p *shellParser) hasPrefix(s string) bool {
+	prefix := []rune(s)
+	if p.pos+len(prefix) > len(p.src) {
+		return false
+	}
+	for i, r := range prefix {
+		if p.src[p.pos+i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *shellParser) peek(offset int) rune {
+	if p.pos+offset < len(p.src) {
+		return p.src[p.pos+offset]
+	}
+	return 0
+}
+
+// skipBlanks skips spaces, tabs and line continuations, but not newlines
+func (p *shellParser) skipBlanks() {
+	for p.pos < len(p.src) {
+		switch ch := p.src[p.pos]; {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			p.pos++
+		case ch == p.escapeChar() && p.peek(1) == '\n':
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (p *shellParser) skipComment() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *shellParser) skipBlockComment() {
+	p.pos += 2
+	for p.pos < len(p.src) && !p.hasPrefix("#>") {
+		p.pos++
+	}
+	p.pos = min(p.pos+2, len(p.src))
+}
+
+func (p *shellParser) escapeChar() rune {
+	if p.dialect == DialectPowerShell {
+		return '`'
+	}
+	return '\\'
+}
+
+// isOperator reports whether a command separator starts at the current position
+func (p *shellParser) isOperator() bool {
+	switch p.src[p.pos] {
+	case ';', '&', '|':
+		return true
+	case '{', '}':
+		return p.dialect == DialectPowerShell
+	}
+	return false
+}
+
+// readOperator consumes a command separator. PowerShell's call operator & also
+// lands here; it only ever precedes a command, so ending the empty one is harmless.
+func (p *shellParser) readOperator() {
+	switch {
+	case p.hasPrefix("&&"), p.hasPrefix("||"), p.hasPrefix(";;"), p.hasPrefix("|&"):
+		p.pos += 2
+	default:
+		p.pos++
+	}
+}
+
+// isRedirect reports whether a redirection operator starts at the current position
+func (p *shellParser) isRedirect() bool {
+	switch p.src[p.pos] {
+	case '<', '>':
+		return true
+	case '&':
+		return p.dialect == DialectPOSIX && p.peek(1) == '>'
+	}
+	return false
+}
+
+// readRedirect consumes a redirection and its target, which is not an argument.
+// Heredocs and here-strings record the text the command reads on stdin.
+func (p *shellParser) readRedirect(cmd *SimpleCommand, dir string) {
+	var op string
+	for _, candidate := range []string{"<<<", "<<-", "&>>", "<<", ">>", "<&", ">&", "<>", ">|", "&>", "<", ">"} {
+		if p.hasPrefix(candidate) {
+			op = candidate
+			break
+		}
+	}
+	p.pos += len(op)
+
+	// PowerShell merges streams with 2>&1 and needs no target
+	if p.dialect == DialectPowerShell && op == ">&" {
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		return
+	}
+
+	p.skipBlanks()
+	if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+		return
+	}
+	target := p.readWord(dir)
+	switch op {
+	case "<<", "<<-":
+		p.heredocs = append(p.heredocs, &pendingHeredoc{delimiter: target, stripTabs: op == "<<-", command: cmd})
+	case "<<<":
+		cmd.Input = target + "\n"
+	}
+}
+
+// readHeredocs reads the bodies of heredocs started on the line that just ended
+func (p *shellParser) readHeredocs() {
+	pending := p.heredocs
+	p.heredocs = nil
+	for _, h := range pending {
+		var body strings.Builder
+		for p.pos < len(p.src) {
+			end := p.pos
+			for end < len(p.src) && p.src[end] != '\n' {
+				end++
+			}
+			line := strings.TrimSuffix(string(p.src[p.pos:end]), "\r")
+			p.pos = min(end+1, len(p.src))
+			if h.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == h.delimiter {
+				break
+			}
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+		h.command.Input = body.String()
+	}
+}
+
+// isWordEnd reports whether an unquoted character ends a word
+func (p *shellParser) isWordEnd(ch rune) bool {
+	switch ch {
+	case ' ', '\t', '\r', '\n', ';', '&', '|', '(', ')', '<', '>':
+		return true
+	case '{', '}':
+		return p.dialect == DialectPowerShell
+	}
+	return false
+}
+
+// readWord reads a word and returns it after quote removal. Substitutions and
+// variables are kept as written, since their values are not known; the commands
+// inside substitutions are added to the parsed commands.
+func (p *shellParser) readWord(dir string) string {
+	var b strings.Builder
+	start := p.pos
+
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if p.isWordEnd(ch) {
+			break
+		}
+
+		switch {
+		case ch == p.escapeChar():
+			if p.peek(1) == '\n' {
+				p.pos += 2
+				continue
+			}
+			if p.pos+1 < len(p.src) {
+				b.WriteRune(p.src[p.pos+1])
+			}
+			p.pos = min(p.pos+2, len(p.src))
+
+		case ch == '\'':
+			p.readSingleQuoted(&b)
+
+		case ch == '"':
+			p.readDoubleQuoted(&b, dir)
+
+		case p.dialect == DialectPOSIX && ch == '$' && p.peek(1) == '\'':
+			p.readANSIQuoted(&b)
+
+		case (ch == '$' || (p.dialect == DialectPowerShell && ch == '@')) && p.peek(1) == '(':
+			p.readSubstitution(&b, dir)
+
+		case (ch == '$' || (p.dialect == DialectPowerShell && ch == '@')) && p.peek(1) == '{':
+			p.readBraced(&b)
+
+		case p.dialect == DialectPowerShell && ch == '@' && p.pos == start && (p.peek(1) == '\'' || p.peek(1) == '"'):
+			if !p.readHereString(&b) {
+				b.WriteRune(ch)
+				p.pos++
+			}
+
+		case p.dialect == DialectPOSIX && ch == '`':
+			p.readBackquoted(&b, dir)
+
+		default:
+			b.WriteRune(ch)
+			p.pos++
+		}
+	}
+	return b.String()
+}
+
+// readSingleQuoted reads '...'. PowerShell writes a quote inside as two quotes.
+func (p *shellParser) readSingleQuoted(b *strings.Builder) {
+	p.pos++
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if ch == '\'' {
+			if p.dialect == DialectPowerShell && p.peek(1) == '\'' {
+				b.WriteRune('\'')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return
+		}
+		b.WriteRune(ch)
+		p.pos++
+	}
+}
+
+// readDoubleQuoted reads "...", in which substitutions still run
+func (p *shellParser) readDoubleQuoted(b *strings.Builder, dir string) {
+	p.pos++
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		switch {
+		case ch == '"':
+			if p.dialect == DialectPowerShell && p.peek(1) == '"' {
+				b.WriteRune('"')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return
+
+		case ch == p.escapeChar() && p.pos+1 < len(p.src):
+			next := p.src[p.pos+1]
+			p.pos += 2
+			if p.dialect == DialectPowerShell {
+				b.WriteRune(powerShellEscape(next))
+				continue
+			}
+			switch next {
+			case '\n':
+			case '$', '`', '"', '\\':
+				b.WriteRune(next)
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(next)
+			}
+
+		case ch == '$' && p.peek(1) == '(':
+			p.readSubstitution(b, dir)
+
+		case ch == '$' && p.peek(1) == '{':
+			p.readBraced(b)
+
+		case p.dialect == DialectPOSIX && ch == '`':
+			p.readBackquoted(b, dir)
+
+		default:
+			b.WriteRune(ch)
+			p.pos++
+		}
+	}
+}
+
+// powerShellEscape returns the character a backtick escape stands for
+func powerShellEscape(ch rune) rune {
+	switch ch {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	}
+	return ch
+}
+
+// readANSIQuoted reads bash's $'...', which understands backslash escapes
+func (p *shellParser) readANSIQuoted(b *strings.Builder) {
+	p.pos += 2
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if ch == '\'' {
+			p.pos++
+			return
+		}
+		if ch == '\\' && p.pos+1 < len(p.src) {
+			next := p.src[p.pos+1]
+			p.pos += 2
+			switch next {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			default:
+				b.WriteRune(next)
+			}
+			continue
+		}
+		b.WriteRune(ch)
+		p.pos++
+	}
+}
+
+// readSubstitution reads $(...) (or PowerShell's @(...)), parsing the commands
+// inside. Arithmetic $((...)) runs no commands and is skipped. A substitution whose
+// output is known without running it, like $(cat <<EOF ...), is replaced by that
+// output; others are kept as written.
+func (p *shellParser) readSubstitution(b *strings.Builder, dir string) {
+	start := p.pos
+	p.pos += 2
+	if p.dialect == DialectPOSIX && p.peek(0) == '(' {
+		p.skipBalanced(2)
+		b.WriteString(string(p.src[start:p.pos]))
+		return
+	}
+
+	first := len(p.commands)
+	p.parseList(dir, true)
+	if output, ok := p.staticOutput(p.commands[first:]); ok {
+		// Like the shell, drop trailing newlines from the output
+		b.WriteString(strings.TrimRight(output, "\n"))
+		return
+	}
+	b.WriteString(string(p.src[start:p.pos]))
+}
+
+// staticOutput returns what a substitution prints when that does not depend on
+// anything outside the command line: a single cat of a heredoc or here-string, or
+// an echo of literal words
+func (p *shellParser) staticOutput(commands []*SimpleCommand) (string, bool) {
+	if p.dialect != DialectPOSIX || len(commands) != 1 {
+		return "", false
+	}
+	args := commands[0].Args
+	switch args[0] {
+	case "cat":
+		if commands[0].Input != "" && (len(args) == 1 || (len(args) == 2 && args[1] == "-")) {
+			return commands[0].Input, true
+		}
+	case "echo":
+		for _, arg := range args[1:] {
+			if strings.ContainsAny(arg, "$`") {
+				return "", false
+			}
+		}
+		if len(args) > 1 && strings.HasPrefix(args[1], "-") {
+			return "", false
+		}
+		return strings.Join(args[1:], " ") + "\n", true
+	}
+	return "", false
+}
+
+// readBraced reads ${...} (or PowerShell's @{...}) as written
+func (p *shellParser) readBraced(b *strings.Builder) {
+	start := p.pos
+	p.pos += 2
+	depth := 1
+	for p.pos < len(p.src) && depth > 0 {
+		switch p.src[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		p.pos++
+	}
+	b.WriteString(string(p.src[start:p.pos]))
+}
+
+// skipBalanced skips to the parenthesis that brings depth to zero
+func (p *shellParser) skipBalanced(depth int) {
+	for p.pos < len(p.src) && depth > 0 {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.pos++
+	}
+}
+
+// readBackquoted reads `...`, the old form of command substitution. Backslashes
+// escape backquotes inside, so the commands are parsed from the unescaped text.
+func (p *shellParser) readBackquoted(b *strings.Builder, dir string) {
+	start := p.pos
+	p.pos++
+	var inner strings.Builder
+	for p.pos < len(p.src) && p.src[p.pos] != '`' {
+		ch := p.src[p.pos]
+		if ch == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune("`$\\", p.src[p.pos+1]) {
+			ch = p.src[p.pos+1]
+			p.pos++
+		}
+		inner.WriteRune(ch)
+		p.pos++
+	}
+	p.pos = min(p.pos+1, len(p.src))
+
+	sub := &shellParser{src: []rune(inner.String()), dialect: p.dialect}
+	sub.parseList(dir, false)
+	p.commands = append(p.commands, sub.commands...)
+	b.WriteString(string(p.src[start:p.pos]))
+}
+
+// readHereString reads a PowerShell here-string: @' or @" at the end of a line, up
+// to a line starting with '@ or "@. It reports false if the text is not one.
+func (p *shellParser) readHereString(b *strings.Builder) bool {
+	quote := p.src[p.pos+1]
+	i := p.pos + 2
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\r') {
+		i++
+	}
+	if i >= len(p.src) || p.src[i] != '\n' {
+		return false
+	}
+
+	closing := string(quote) + "@"
+	var lines []string
+	p.pos = i + 1
+	for p.pos < len(p.src) {
+		end := p.pos
+		for end < len(p.src) && p.src[end] != '\n' {
+			end++
+		}
+		line := strings.TrimSuffix(string(p.src[p.pos:end]), "\r")
+		if strings.HasPrefix(line, closing) {
+			p.pos += len([]rune(closing))
+			break
+		}
+		lines = append(lines, line)
+		p.pos = min(end+1, len(p.src))
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	return true
+}
+
+// changeDir follows cd and its PowerShell equivalents so later commands know
+// where they run. Targets that depend on the environment leave dir unchanged.
+func (p *shellParser) changeDir(args []string, dir string) string {
+	switch commandName(args[0], p.dialect) {
+	case "cd", "pushd", "chdir", "set-location", "sl", "push-location":
+	default:
+		return dir
+	}
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if strings.HasPrefix(arg, "~") || strings.ContainsAny(arg, "$`") {
+			return dir
+		}
+		return joinDir(dir, arg)
+	}
+	return dir
+}
+
+// joinDir resolves target against dir, where "" is the starting directory
+func joinDir(dir, target string) string {
+	if target == "" {
+		return dir
+	}
+	if filepath.IsAbs(target) {
+		return filepath.Clean(target)
+	}
+	return filepath.Join(dir, target)
+}
+
+// commandName returns the program a command runs without its directory. PowerShell
+// names are case-insensitive and may carry an .exe extension.
+func commandName(program string, dialect Dialect) string {
+	if dialect == DialectPowerShell {
+		program = strings.ToLower(program[strings.LastIndexAny(program, `/\`)+1:])
+		return strings.TrimSuffix(program, ".exe")
+	}
+	return program[strings.LastIndex(program, "/")+1:]
+}
+
+// isAssignment reports whether a word is a variable assignment such as FOO=bar
+func isAssignment(word string) bool {
+	name, _, found := strings.Cut(word, "=")
+	if !found || name == "" {
+		return false
+	}
+	for i, ch := range name {
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		if !isLetter && (i == 0 || ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isFileDescriptor reports whether a word directly before a redirection names the
+// stream being redirected, like 2 in 2>&1 or * in PowerShell's *>
+func isFileDescriptor(word string, dialect Dialect) bool {
+	if word == "" {
+		return false
+	}
+	if dialect == DialectPowerShell && word == "*" {
+		return true
+	}
+	for _, ch := range word {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
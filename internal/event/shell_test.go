@@ -0,0 +1,290 @@
+package event
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		dialect Dialect
+		want    []SimpleCommand
+	}{
+		{
+			"operators",
+			"a 1 && b 2 || c; d & e | f |& g",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"a", "1"}}, {Args: []string{"b", "2"}}, {Args: []string{"c"}}, {Args: []string{"d"}}, {Args: []string{"e"}}, {Args: []string{"f"}}, {Args: []string{"g"}}},
+		},
+		{
+			"quoting",
+			`echo 'a "b"' "c 'd' \"e\"" f\ g $'h\ti'`,
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"echo", `a "b"`, `c 'd' "e"`, "f g", "h\ti"}}},
+		},
+		{
+			"quoted operators are text",
+			`echo "a && git commit" ';'`,
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"echo", "a && git commit", ";"}}},
+		},
+		{
+			"comments",
+			"# git commit\necho a # git push",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"echo", "a"}}},
+		},
+		{
+			"assignments and redirections",
+			"FOO=1 BAR='x y' make test > out.log 2>&1 < in.txt",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"make", "test"}}},
+		},
+		{
+			"line continuation",
+			"git commit \\\n  -m msg",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"git", "commit", "-m", "msg"}}},
+		},
+		{
+			"command substitution",
+			`echo "$(git rev-parse HEAD)" && x=$(date)`,
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"git", "rev-parse", "HEAD"}}, {Args: []string{"echo", "$(git rev-parse HEAD)"}}, {Args: []string{"date"}}},
+		},
+		{
+			"static substitutions",
+			"git commit -m \"$(cat <<'EOF'\nfix: a\n\nbody\nEOF\n)\" -m \"$(echo 'second part')\" -m \"$(echo $USER)\"",
+			DialectPOSIX,
+			[]SimpleCommand{
+				{Args: []string{"cat"}, Input: "fix: a\n\nbody\n"},
+				{Args: []string{"echo", "second part"}},
+				{Args: []string{"echo", "$USER"}},
+				{Args: []string{"git", "commit", "-m", "fix: a\n\nbody", "-m", "second part", "-m", "$(echo $USER)"}},
+			},
+		},
+		{
+			"backquotes and arithmetic",
+			"echo `git status` $((1 + 2))",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"git", "status"}}, {Args: []string{"echo", "`git status`", "$((1 + 2))"}}},
+		},
+		{
+			"heredoc",
+			"cat <<'EOF' > notes.txt && git add notes.txt\ngit commit\nEOF\necho done",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"cat"}, Input: "git commit\n"}, {Args: []string{"git", "add", "notes.txt"}}, {Args: []string{"echo", "done"}}},
+		},
+		{
+			"indented heredoc and here-string",
+			"cat <<-END\n\tline\n\tEND\ngrep x <<< 'text'",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"cat"}, Input: "line\n"}, {Args: []string{"grep", "x"}, Input: "text\n"}},
+		},
+		{
+			"compound commands",
+			"if git diff --quiet; then echo clean; else { git add .; }; fi",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"git", "diff", "--quiet"}}, {Args: []string{"echo", "clean"}}, {Args: []string{"git", "add", "."}}},
+		},
+		{
+			"directory changes",
+			"cd sub && make && (cd ../other; ls) && pushd /abs && ls",
+			DialectPOSIX,
+			[]SimpleCommand{
+				{Args: []string{"cd", "sub"}},
+				{Args: []string{"make"}, Dir: "sub"},
+				{Args: []string{"cd", "../other"}, Dir: "sub"},
+				{Args: []string{"ls"}, Dir: "other"},
+				{Args: []string{"pushd", "/abs"}, Dir: "sub"},
+				{Args: []string{"ls"}, Dir: "/abs"},
+			},
+		},
+		{
+			"unknown directory",
+			"cd $HOME && ls",
+			DialectPOSIX,
+			[]SimpleCommand{{Args: []string{"cd", "$HOME"}}, {Args: []string{"ls"}}},
+		},
+		{
+			"powershell quoting",
+			"git commit -m 'it''s done' -m \"line`nnext\" ; Write-Host \"a\"\"b\"",
+			DialectPowerShell,
+			[]SimpleCommand{{Args: []string{"git", "commit", "-m", "it's done", "-m", "line\nnext"}}, {Args: []string{"Write-Host", `a"b`}}},
+		},
+		{
+			"powershell call operator and blocks",
+			"& 'C:\\Program Files\\Git\\bin\\git.exe' status; if ($?) { Set-Location src; git add . } <# git push #>",
+			DialectPowerShell,
+			[]SimpleCommand{
+				{Args: []string{`C:\Program Files\Git\bin\git.exe`, "status"}},
+				{Args: []string{"$?"}}, // Parenthesized expressions can run commands
+				{Args: []string{"if", "($?)"}},
+				{Args: []string{"Set-Location", "src"}},
+				{Args: []string{"git", "add", "."}, Dir: "src"},
+			},
+		},
+		{
+			"powershell here-string",
+			"$msg = @'\nfix: a\n'@\ngit commit -m $msg 2>&1",
+			DialectPowerShell,
+			[]SimpleCommand{{Args: []string{"$msg", "=", "fix: a"}}, {Args: []string{"git", "commit", "-m", "$msg"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCommandLine(tt.line, tt.dialect)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseGitOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		dialect Dialect
+		want    []GitOperation
+	}{
+		{
+			"chain",
+			"git add -A && git commit -m 'msg' && git push",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "add", Args: []string{"-A"}}, {Subcommand: "commit", Args: []string{"-m", "msg"}}, {Subcommand: "push", Args: []string{}}},
+		},
+		{
+			"subcommand names in arguments",
+			"git log --grep commit && echo git push",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "log", Args: []string{"--grep", "commit"}}},
+		},
+		{
+			"global options",
+			"git -c user.name=x --no-pager -C repo commit --amend",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"--amend"}, Dir: "repo"}},
+		},
+		{
+			"directory from cd and -C",
+			"cd sub && git -C repo commit",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{}, Dir: "sub/repo"}},
+		},
+		{
+			"wrappers",
+			"env GIT_AUTHOR_NAME=x sudo -E /usr/bin/git push",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "push", Args: []string{}}},
+		},
+		{
+			"help and lookups",
+			"git --help commit; command -v git; git --version",
+			DialectPOSIX,
+			nil,
+		},
+		{
+			"inside substitutions and pipes",
+			"echo $(git commit -m x) | git hash-object --stdin",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"-m", "x"}}, {Subcommand: "hash-object", Args: []string{"--stdin"}}},
+		},
+		{
+			"heredoc message",
+			"git commit -F - <<'EOF'\nfeat: add\n\ngit push later\nEOF",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"-F", "-"}, Input: "feat: add\n\ngit push later\n"}},
+		},
+		{
+			"heredoc inside substitution",
+			"git commit -m \"$(cat <<'EOF'\nfix: b\nEOF\n)\"",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"-m", "fix: b"}}},
+		},
+		{
+			"wrapper options with values",
+			"sudo -u bot git push && nice -n 10 git commit && env -u HOME git add .",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "push", Args: []string{}}, {Subcommand: "commit", Args: []string{}}, {Subcommand: "add", Args: []string{"."}}},
+		},
+		{
+			"shell scripts",
+			"bash -c 'git add -A && git commit -m \"a b\"' && sh -ec \"cd sub; git push\" && zsh -o pipefail -lc 'git push -f'",
+			DialectPOSIX,
+			[]GitOperation{
+				{Subcommand: "add", Args: []string{"-A"}},
+				{Subcommand: "commit", Args: []string{"-m", "a b"}},
+				{Subcommand: "push", Args: []string{}, Dir: "sub"},
+				{Subcommand: "push", Args: []string{"-f"}},
+			},
+		},
+		{
+			"shell script on stdin",
+			"bash <<'EOF'\ngit push origin main\nEOF",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "push", Args: []string{"origin", "main"}}},
+		},
+		{
+			"shell script file",
+			"bash deploy.sh git push",
+			DialectPOSIX,
+			nil,
+		},
+		{
+			"eval, xargs and watch",
+			"eval git push --force && echo a.txt | xargs -n 1 git rm && watch -n 5 'git push' && watch -x git commit -m 'a b'",
+			DialectPOSIX,
+			[]GitOperation{
+				{Subcommand: "push", Args: []string{"--force"}},
+				{Subcommand: "rm", Args: []string{}},
+				{Subcommand: "push", Args: []string{}},
+				{Subcommand: "commit", Args: []string{"-m", "a b"}},
+			},
+		},
+		{
+			"nested scripts in a directory",
+			"cd repo && sudo bash -c \"eval 'git commit -m x'\"",
+			DialectPOSIX,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"-m", "x"}, Dir: "repo"}},
+		},
+		{
+			"powershell",
+			"Set-Location C:/repo; & GIT.EXE commit -m 'a b'",
+			DialectPowerShell,
+			[]GitOperation{{Subcommand: "commit", Args: []string{"-m", "a b"}, Dir: "C:/repo"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseGitOperations(tt.line, tt.dialect)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGitOperationWorkDir(t *testing.T) {
+	tests := []struct {
+		dir  string
+		cwd  string
+		want string
+	}{
+		{"", "/repo", "/repo"},
+		{"sub", "/repo", "/repo/sub"},
+		{"/abs", "/repo", "/abs"},
+		{"sub", "", "sub"},
+	}
+
+	for _, tt := range tests {
+		got := GitOperation{Dir: tt.dir}.WorkDir(tt.cwd)
+		if got != tt.want {
+			t.Errorf("Expected WorkDir(%q) with Dir %q to be %q, got %q", tt.cwd, tt.dir, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package expression
+
+import (
+	"fmt"
+)
+
+// node is an element of a compiled expression. Each node records the offset of the
+// token it was built from so later checks can point at the offending source.
+type node interface {
+	eval(ctx *Context) (interface{}, error)
+	position() int
+}
+
+// walk calls fn for n and every node below it
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch n := n.(type) {
+	case *propertyNode:
+		walk(n.target, fn)
+	case *filterNode:
+		walk(n.target, fn)
+	case *indexNode:
+		walk(n.target, fn)
+		walk(n.index, fn)
+	case *callNode:
+		for _, arg := range n.args {
+			walk(arg, fn)
+		}
+	case *unaryNode:
+		walk(n.operand, fn)
+	case *logicalNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	case *binaryNode:
+		walk(n.left, fn)
+		walk(n.right, fn)
+	}
+}
+
+// literalNode is a constant: string, number, boolean or null
+type literalNode struct {
+	pos   int
+	value interface{}
+}
+
+func (n *literalNode) eval(ctx *Context) (interface{}, error) {
+	return n.value, nil
+}
+
+func (n *literalNode) position() int { return n.pos }
+
+// identNode is a bare name: a context (event, env, steps, vars, secrets, runner, git)
+// or a function name
+type identNode struct {
+	pos  int
+	name string
+}
+
+func (n *identNode) eval(ctx *Context) (interface{}, error) {
+	switch n.name {
+	case "event":
+		return ctx.Event, nil
+	case "env":
+		return ctx.Env, nil
+	case "steps":
+		return ctx.Steps, nil
+	case "vars":
+		return ctx.Vars, nil
+	case "secrets":
+		return ctx.Secrets, nil
+	case "runner":
+		return ctx.Runner, nil
+	case "git":
+		return ctx.Git, nil
+	}
+	// Unknown names evaluate to themselves
+	return n.name, nil
+}
+
+func (n *identNode) position() int { return n.pos }
+
+// propertyNode is `target.name`. When mapped, target is the result of an object
+// filter and the property is read from every element, dropping missing values.
+type propertyNode struct {
+	pos    int
+	target node
+	name   string
+	mapped bool
+}
+
+func (n *propertyNode) eval(ctx *Context) (interface{}, error) {
+	obj, err := n.target.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mapped {
+		return getProperty(obj, n.name), nil
+	}
+	items, _ := obj.([]interface{})
+	return flatMap(items, func(item interface{}) []interface{} {
+		if value := getProperty(item, n.name); value != nil {
+			return []interface{}{value}
+		}
+		return nil
+	}), nil
+}
+
+func (n *propertyNode) position() int { return n.pos }
+
+// filterNode is the object filter `target.*`. When flatten is set, target is already
+// a filtered array and each element is filtered in turn.
+type filterNode struct {
+	pos     int
+	target  node
+	flatten bool
+}
+
+func (n *filterNode) eval(ctx *Context) (interface{}, error) {
+	obj, err := n.target.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.flatten {
+		items, _ := obj.([]interface{})
+		return flatMap(items, objectFilter), nil
+	}
+	return objectFilter(obj), nil
+}
+
+func (n *filterNode) position() int { return n.pos }
+
+// indexNode is `target[index]`
+type indexNode struct {
+	pos    int
+	target node
+	index  node
+}
+
+func (n *indexNode) eval(ctx *Context) (interface{}, error) {
+	obj, err := n.target.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getIndex(obj, index), nil
+}
+
+func (n *indexNode) position() int { return n.pos }
+
+// callNode is a function call. Functions are resolved at evaluation time so a
+// compiled expression can run against contexts with different function sets.
+type callNode struct {
+	pos  int
+	name string
+	args []node
+}
+
+func (n *callNode) eval(ctx *Context) (interface{}, error) {
+	args := make([]interface{}, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	fn, err := ctx.lookupFunction(n.name)
+	if err != nil {
+		return nil, locate(n, err)
+	}
+	value, err := fn(args...)
+	if err != nil {
+		return nil, locate(n, err)
+	}
+	return value, nil
+}
+
+func (n *callNode) position() int { return n.pos }
+
+// unaryNode is `!operand` or `-operand`
+type unaryNode struct {
+	pos     int
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(ctx *Context) (interface{}, error) {
+	value, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !toBool(value), nil
+	}
+	result, err := arithmetic("-", int64(0), value)
+	if err != nil {
+		return nil, locate(n, err)
+	}
+	return result, nil
+}
+
+func (n *unaryNode) position() int { return n.pos }
+
+// logicalNode is `left || right` or `left && right`. The right side is only
+// evaluated when it can change the result.
+type logicalNode struct {
+	pos   int
+	op    string
+	left  node
+	right node
+}
+
+func (n *logicalNode) eval(ctx *Context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "||" && toBool(left) {
+		return true, nil
+	}
+	if n.op == "&&" && !toBool(left) {
+		return false, nil
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toBool(right), nil
+}
+
+func (n *logicalNode) position() int { return n.pos }
+
+// binaryNode is a comparison or arithmetic operation
+type binaryNode struct {
+	pos   int
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(ctx *Context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equals(left, right), nil
+	case "!=":
+		return !equals(left, right), nil
+	case "<":
+		return toNumber(left) < toNumber(right), nil
+	case "<=":
+		return toNumber(left) <= toNumber(right), nil
+	case ">":
+		return toNumber(left) > toNumber(right), nil
+	case ">=":
+		return toNumber(left) >= toNumber(right), nil
+	case "+", "-", "*", "/":
+		result, err := arithmetic(n.op, left, right)
+		if err != nil {
+			return nil, locate(n, err)
+		}
+		return result, nil
+	}
+	return nil, locate(n, fmt.Errorf("unknown operator: %s", n.op))
+}
+
+func (n *binaryNode) position() int { return n.pos }
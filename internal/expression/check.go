@@ -0,0 +1,370 @@
+package expression
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CheckError is a problem found by statically checking a compiled expression
+type CheckError struct {
+	Column int // 1-based column of the offending token
+	Msg    string
+}
+
+func (e *CheckError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// CheckScope describes what an expression can refer to
+type CheckScope struct {
+	// Steps holds the keys available in the steps context. A nil map skips
+	// checking step references.
+	Steps map[string]bool
+}
+
+// arity is the number of arguments a function accepts; max < 0 means no limit
+type arity struct {
+	min, max int
+}
+
+// functionArity lists every built-in function with the arguments it accepts. It must
+// name exactly the functions NewContext registers.
+var functionArity = map[string]arity{
+	"contains":     {2, 2},
+	"startsWith":   {2, 2},
+	"endsWith":     {2, 2},
+	"format":       {1, -1},
+	"join":         {1, 2},
+	"toJSON":       {1, 1},
+	"fromJSON":     {1, 1},
+	"always":       {0, 0},
+	"success":      {0, 0},
+	"failure":      {0, 0},
+	"cancelled":    {0, 0},
+	"matches":      {2, 2},
+	"regexReplace": {3, 3},
+	"regexCapture": {2, 3},
+	"glob":         {2, 2},
+	"length":       {1, 1},
+	"unique":       {1, 1},
+	"sort":         {1, 1},
+	"any":          {1, -1},
+	"all":          {1, -1},
+	"hashFiles":    {1, -1},
+	"fileExists":   {1, 1},
+	"readFile":     {1, 1},
+	"lineCount":    {1, 1},
+}
+
+// shape describes the known structure of a value for static checks.
+// A nil shape is a value whose structure is unknown, so nothing below it is checked.
+type shape struct {
+	fields map[string]*shape // Known properties of an object
+	elem   *shape            // Shape of array elements (or of filtered object values)
+	open   bool              // Any property is allowed, e.g. env or tool args
+}
+
+// leafShape is a scalar with no properties
+var leafShape = &shape{}
+
+// openShape is an object whose keys are user-defined
+var openShape = &shape{open: true}
+
+func object(fields map[string]*shape) *shape {
+	return &shape{fields: fields}
+}
+
+func arrayOf(elem *shape) *shape {
+	return &shape{elem: elem}
+}
+
+// toolResultShape mirrors toolResultToMap
+var toolResultShape = object(map[string]*shape{
+	"type":      leafShape,
+	"output":    leafShape,
+	"exit_code": leafShape,
+})
+
+// fileStatusShape mirrors the files of commit and push events
+var fileStatusShape = object(map[string]*shape{
+	"path":   leafShape,
+	"status": leafShape,
+})
+
+// eventShape mirrors EventToMap
+var eventShape = object(map[string]*shape{
+	"cwd":       leafShape,
+	"timestamp": leafShape,
+	"hook": object(map[string]*shape{
+		"type": leafShape,
+		"cwd":  leafShape,
+		"tool": object(map[string]*shape{
+			"name":   leafShape,
+			"args":   openShape,
+			"result": toolResultShape,
+		}),
+	}),
+	"tool": object(map[string]*shape{
+		"name":      leafShape,
+		"args":      openShape,
+		"hook_type": leafShape,
+		"result":    toolResultShape,
+	}),
+	"file": object(map[string]*shape{
+		"path":     leafShape,
+		"action":   leafShape,
+		"content":  leafShape,
+		"old_path": leafShape,
+	}),
+	"commit": object(map[string]*shape{
+		"sha":         leafShape,
+		"message":     leafShape,
+		"author":      leafShape,
+		"branch":      leafShape,
+		"files":       arrayOf(fileStatusShape),
+		"amend":       leafShape,
+		"fixup":       leafShape,
+		"allow_empty": leafShape,
+		"no_verify":   leafShape,
+	}),
+	"push": object(map[string]*shape{
+		"ref":    leafShape,
+		"before": leafShape,
+		"after":  leafShape,
+		"commits": arrayOf(object(map[string]*shape{
+			"sha":     leafShape,
+			"message": leafShape,
+			"author":  leafShape,
+			"files":   arrayOf(fileStatusShape),
+		})),
+	}),
+})
+
+// runnerShape mirrors RunnerContext
+var runnerShape = object(map[string]*shape{
+	"os":      leafShape,
+	"arch":    leafShape,
+	"temp":    leafShape,
+	"version": leafShape,
+})
+
+// gitShape mirrors GitContext
+var gitShape = object(map[string]*shape{
+	"branch": leafShape,
+	"remote": leafShape,
+	"ahead":  leafShape,
+	"behind": leafShape,
+	"sha":    leafShape,
+	"author": leafShape,
+})
+
+// stepShape mirrors stepContextToMap
+var stepShape = object(map[string]*shape{
+	"outputs": openShape,
+	"outcome": leafShape,
+})
+
+// Check statically checks a compiled expression for unknown functions, wrong
+// argument counts, unknown contexts and references to properties that can never exist
+func (p *Program) Check(scope *CheckScope) []*CheckError {
+	c := &checker{scope: scope}
+	c.check(p.root)
+	return c.errs
+}
+
+type checker struct {
+	scope *CheckScope
+	errs  []*CheckError
+}
+
+func (c *checker) errorf(n node, format string, args ...interface{}) {
+	c.errs = append(c.errs, &CheckError{Column: n.position() + 1, Msg: fmt.Sprintf(format, args...)})
+}
+
+// check walks a node, reporting problems, and returns the shape of its value
+func (c *checker) check(n node) *shape {
+	switch n := n.(type) {
+	case *identNode:
+		return c.checkContext(n)
+
+	case *propertyNode:
+		target := c.check(n.target)
+		if !n.mapped {
+			return c.checkProperty(n, target, n.name)
+		}
+		if target == nil || target.elem == nil {
+			return nil
+		}
+		return arrayOf(c.checkProperty(n, target.elem, n.name))
+
+	case *filterNode:
+		target := c.check(n.target)
+		if n.flatten {
+			if target == nil || target.elem == nil || target.elem.elem == nil {
+				return nil
+			}
+			return arrayOf(target.elem.elem)
+		}
+		if target == nil || target.elem == nil {
+			return nil
+		}
+		return target
+
+	case *indexNode:
+		target := c.check(n.target)
+		c.check(n.index)
+		if target == nil {
+			return nil
+		}
+		if target.elem != nil {
+			return target.elem
+		}
+		if key, ok := n.index.(*literalNode); ok {
+			if name, ok := key.value.(string); ok {
+				return c.checkProperty(n, target, name)
+			}
+		}
+		return nil
+
+	case *callNode:
+		c.checkCall(n)
+		for _, arg := range n.args {
+			c.check(arg)
+		}
+
+	case *unaryNode:
+		c.check(n.operand)
+
+	case *logicalNode:
+		c.check(n.left)
+		c.check(n.right)
+
+	case *binaryNode:
+		c.check(n.left)
+		c.check(n.right)
+	}
+	return nil
+}
+
+// checkContext checks a bare name used as a value
+func (c *checker) checkContext(n *identNode) *shape {
+	switch n.name {
+	case "event":
+		return eventShape
+	case "env", "vars", "secrets":
+		return openShape
+	case "runner":
+		return runnerShape
+	case "git":
+		return gitShape
+	case "steps":
+		if c.scope == nil || c.scope.Steps == nil {
+			return nil
+		}
+		fields := make(map[string]*shape, len(c.scope.Steps))
+		for key := range c.scope.Steps {
+			fields[key] = stepShape
+		}
+		return &shape{fields: fields, elem: stepShape}
+	}
+	c.errorf(n, "unknown context '%s' (available: %s)%s", n.name, strings.Join(contextNames, ", "), didYouMean(n.name, contextNames))
+	return nil
+}
+
+// checkProperty checks that a property can exist on a value of the given shape
+func (c *checker) checkProperty(n node, target *shape, name string) *shape {
+	if target == nil || target.open {
+		return nil
+	}
+	if field, ok := target.fields[name]; ok {
+		return field
+	}
+	if target.fields == nil {
+		c.errorf(n, "property '%s' does not exist: the value has no properties", name)
+		return nil
+	}
+	if len(target.fields) == 0 {
+		c.errorf(n, "unknown property '%s' (none are available here)", name)
+		return nil
+	}
+	// '-' continues a name, so git.ahead-1 reads the property "ahead-1"
+	if prefix, rest, found := strings.Cut(name, "-"); found && target.fields[prefix] != nil {
+		c.errorf(n, "unknown property '%s'; '-' without spaces is part of a name, to subtract write '%s - %s'", name, prefix, rest)
+		return nil
+	}
+	names := fieldNames(target)
+	c.errorf(n, "unknown property '%s' (available: %s)%s", name, strings.Join(names, ", "), didYouMean(name, names))
+	return nil
+}
+
+// checkCall checks that a function exists and is given an acceptable number of arguments
+func (c *checker) checkCall(n *callNode) {
+	a, ok := functionArity[n.name]
+	if !ok {
+		c.errorf(n, "unknown function '%s'%s", n.name, didYouMean(n.name, knownFunctions()))
+		return
+	}
+
+	count := len(n.args)
+	switch {
+	case a.max < 0 && count < a.min:
+		c.errorf(n, "%s requires at least %d %s, got %d", n.name, a.min, plural(a.min, "argument"), count)
+	case a.max >= 0 && a.min == a.max && count != a.min:
+		c.errorf(n, "%s requires %d %s, got %d", n.name, a.min, plural(a.min, "argument"), count)
+	case a.max >= 0 && (count < a.min || count > a.max):
+		c.errorf(n, "%s requires %d to %d arguments, got %d", n.name, a.min, a.max, count)
+	}
+
+	// any() and all() name the function to apply as a string
+	if (n.name == "any" || n.name == "all") && len(n.args) > 1 {
+		if lit, ok := n.args[1].(*literalNode); ok {
+			if fn, ok := lit.value.(string); ok {
+				if _, known := functionArity[fn]; !known {
+					c.errorf(lit, "unknown function '%s'%s", fn, didYouMean(fn, knownFunctions()))
+				}
+			}
+		}
+	}
+}
+
+// UsesContext reports whether the expression refers to a top-level context, e.g. secrets
+func (p *Program) UsesContext(name string) bool {
+	found := false
+	walk(p.root, func(n node) {
+		if ident, ok := n.(*identNode); ok && ident.name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// contextNames lists the top-level contexts in the order they are documented
+var contextNames = []string{"event", "env", "steps", "vars", "secrets", "runner", "git"}
+
+// knownFunctions returns the names of the built-in functions in sorted order
+func knownFunctions() []string {
+	names := make([]string, 0, len(functionArity))
+	for name := range functionArity {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldNames returns the known properties of a shape in sorted order
+func fieldNames(s *shape) []string {
+	names := make([]string, 0, len(s.fields))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
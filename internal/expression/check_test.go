@@ -0,0 +1,258 @@
+package expression
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+func TestProgramCheck(t *testing.T) {
+	scope := &CheckScope{Steps: map[string]bool{"lint": true, "Run tests": true}}
+
+	tests := []struct {
+		name   string
+		expr   string
+		errors []string // Expected messages, in order; empty means the expression is valid
+	}{
+		{"valid event property", "event.tool.name == 'edit'", nil},
+		{"valid nested filter", "contains(event.push.commits.*.files.*.path, 'go.mod')", nil},
+		{"valid indexed array", "event.commit.files[0].status", nil},
+		{"valid tool args", "event.tool.args.command.anything", nil},
+		{"valid env", "env.ANYTHING", nil},
+		{"valid vars", "vars.limits.max_lines > 0", nil},
+		{"valid runner", "runner.os == 'linux' && runner.version != ''", nil},
+		{"valid git", "git.branch == 'main' && git.ahead > 0", nil},
+		{"valid step", "steps.lint.outputs.count > 0 && steps['Run tests'].outcome == 'success'", nil},
+		{"valid step filter", "all(steps.*.outcome, 'endsWith', 'success')", nil},
+		{"valid status functions", "always() || success() || failure() || cancelled()", nil},
+		{"unknown property", "event.tool.nmae", []string{"column 12: unknown property 'nmae' (available: args, hook_type, name, result)"}},
+		{"unknown property after filter", "event.commit.files.*.pth", []string{"column 22: unknown property 'pth' (available: path, status)"}},
+		{"property of a scalar", "event.file.path.length", []string{"column 17: property 'length' does not exist"}},
+		{"unknown git property", "git.ahed > 0", []string{"column 5: unknown property 'ahed' (available: ahead, author, behind, branch, remote, sha)"}},
+		{"unspaced subtraction", "git.ahead-1 > 0", []string{"column 5: unknown property 'ahead-1'; '-' without spaces is part of a name, to subtract write 'ahead - 1'"}},
+		{"unknown context", "evnt.file.path", []string{"column 1: unknown context 'evnt'"}},
+		{"unknown step", "steps.lnt.outcome", []string{"column 7: unknown property 'lnt' (available: Run tests, lint)"}},
+		{"unknown step property", "steps.lint.output.x", []string{"column 12: unknown property 'output' (available: outcome, outputs)"}},
+		{"unknown function", "startswith(event.file.path, 'src/')", []string{"column 1: unknown function 'startswith'"}},
+		{"suggested function", "contians(event.file.path, 'x')", []string{"column 1: unknown function 'contians'; did you mean 'contains'?"}},
+		{"suggested context", "evnt.file.path", []string{"column 1: unknown context 'evnt' (available: event, env, steps, vars, secrets, runner, git); did you mean 'event'?"}},
+		{"suggested property", "event.file.pth", []string{"column 12: unknown property 'pth' (available: action, content, old_path, path); did you mean 'path'?"}},
+		{"too few arguments", "contains(event.file.path)", []string{"column 1: contains requires 2 arguments, got 1"}},
+		{"too many arguments", "toJSON(1, 2)", []string{"column 1: toJSON requires 1 argument, got 2"}},
+		{"argument range", "regexCapture('a')", []string{"column 1: regexCapture requires 2 to 3 arguments, got 1"}},
+		{"variadic minimum", "format()", []string{"column 1: format requires at least 1 argument, got 0"}},
+		{"unknown predicate", "any(event.commit.files.*.path, 'startswith', 'db/')", []string{"column 32: unknown function 'startswith'"}},
+		{
+			"multiple problems",
+			"contains(evnt.x) || event.file.pth",
+			[]string{"column 1: contains requires 2 arguments", "column 10: unknown context 'evnt'", "column 32: unknown property 'pth'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			errs := program.Check(scope)
+			if len(errs) != len(tt.errors) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.errors), len(errs), errs)
+			}
+			for i, want := range tt.errors {
+				if !strings.HasPrefix(errs[i].Error(), want) {
+					t.Errorf("Expected error starting with %q, got %q", want, errs[i].Error())
+				}
+			}
+		})
+	}
+}
+
+// TestFunctionArityMatchesRegistry tests that every registered function has an arity
+// for static checks, and that no arity is listed for a function that does not exist
+func TestFunctionArityMatchesRegistry(t *testing.T) {
+	ctx := NewContext()
+	registered := make(map[string]bool)
+	for name := range ctx.Functions {
+		registered[name] = true
+	}
+	for name := range ctx.ContextFunctions {
+		registered[name] = true
+	}
+
+	for name := range registered {
+		if _, ok := functionArity[name]; !ok {
+			t.Errorf("Function %q is registered but has no entry in functionArity", name)
+		}
+	}
+	for name := range functionArity {
+		if !registered[name] {
+			t.Errorf("functionArity lists %q, which is not a registered function", name)
+		}
+	}
+}
+
+func TestProgramCheckWithoutStepScope(t *testing.T) {
+	program, err := Compile("steps.anything.outcome")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if errs := program.Check(&CheckScope{}); len(errs) != 0 {
+		t.Errorf("Expected step references to be unchecked without a step scope, got %v", errs)
+	}
+}
+
+func TestCheckWorkflow(t *testing.T) {
+	wf, err := schema.LoadWorkflow("../../testdata/workflows/invalid/invalid-expressions.yml")
+	if err != nil {
+		t.Fatalf("Failed to load workflow: %v", err)
+	}
+
+	details := CheckWorkflow(wf)
+	want := []string{
+		"on.tool.if: column 12: unknown property 'nmae'",
+		"steps.0.if (step 'Lint'): column 1: unknown function 'startswith'",
+		"steps.0.run (step 'Lint'): column 1: contains requires 2 arguments, got 1",
+		"steps.1.run (step 'Report'): column 7: unknown property 'lnt' (available: lint)",
+		"steps.1.env.FILE (step 'Report'): column 1: unknown context 'evnt'",
+	}
+	if len(details) != len(want) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(want), len(details), details)
+	}
+	for i := range want {
+		if !strings.HasPrefix(details[i], want[i]) {
+			t.Errorf("Expected detail starting with %q, got %q", want[i], details[i])
+		}
+	}
+
+	valid, err := schema.LoadWorkflow("../../testdata/workflows/valid/expressions.yml")
+	if err != nil {
+		t.Fatalf("Failed to load workflow: %v", err)
+	}
+	if details := CheckWorkflow(valid); len(details) != 0 {
+		t.Errorf("Expected no problems in a valid workflow, got %v", details)
+	}
+}
+
+func TestSecretLeaks(t *testing.T) {
+	wf := &schema.Workflow{
+		Name:        "secrets",
+		Concurrency: &schema.ConcurrencyConfig{Group: "deploy-${{ secrets.TOKEN }}"},
+		Steps: []schema.Step{
+			{
+				Name: "Safe",
+				If:   "secrets.TOKEN != ''",
+				Run:  "curl -H \"Authorization: $TOKEN\" example.com",
+				Env:  map[string]string{"TOKEN": "${{ secrets.TOKEN }}"},
+			},
+			{
+				Name: "Leaky",
+				Run:  "curl -H 'Authorization: ${{ secrets.TOKEN }}' example.com",
+			},
+		},
+	}
+
+	details := SecretLeaks(wf)
+	want := []string{
+		"concurrency.group: secrets may be echoed",
+		"steps.1.run (step 'Leaky'): secrets may be echoed",
+	}
+	if len(details) != len(want) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(want), len(details), details)
+	}
+	for i := range want {
+		if !strings.HasPrefix(details[i], want[i]) {
+			t.Errorf("Expected detail starting with %q, got %q", want[i], details[i])
+		}
+	}
+}
+
+// TestShapesMatchContexts tests that the shapes used by static checks list exactly
+// the keys the context builders produce
+func TestShapesMatchContexts(t *testing.T) {
+	exitCode := 1
+	result := &schema.ToolResult{Type: "success", Output: "ok", ExitCode: &exitCode}
+	files := []schema.FileStatus{{Path: "a.go", Status: "added"}}
+	event := &schema.Event{
+		Cwd:       "/repo",
+		Timestamp: "2026-01-01T00:00:00Z",
+		Hook: &schema.HookEvent{
+			Type: "postToolUse",
+			Cwd:  "/repo",
+			Tool: &schema.ToolEvent{Name: "bash", Args: map[string]interface{}{"command": "ls"}, Result: result},
+		},
+		Tool:   &schema.ToolEvent{Name: "bash", Args: map[string]interface{}{"command": "ls"}, HookType: "postToolUse", Result: result},
+		File:   &schema.FileEvent{Path: "b.go", Action: "rename", Content: "x", OldPath: "a.go"},
+		Commit: &schema.CommitEvent{SHA: "abc", Message: "m", Author: "dev", Branch: "main", Files: files, Amend: true},
+		Push: &schema.PushEvent{
+			Ref:     "refs/heads/main",
+			Before:  "abc",
+			After:   "def",
+			Commits: []schema.CommitEvent{{SHA: "def", Message: "m", Author: "dev", Files: files}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		shape *shape
+		value interface{}
+	}{
+		{"event", eventShape, EventToMap(event)},
+		{"runner", runnerShape, RunnerContext("1.0.0")},
+		{"git", gitShape, GitContext(GitInfo{Branch: "main", Remote: "origin", Ahead: 1, SHA: "abc", Author: "dev"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareShape(t, tt.name, tt.shape, reflect.ValueOf(tt.value))
+		})
+	}
+}
+
+// compareShape reports keys of a built context value that its shape lacks, and
+// shape fields the value never has
+func compareShape(t *testing.T, path string, s *shape, v reflect.Value) {
+	t.Helper()
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if s.open {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		keys := make(map[string]bool)
+		for _, key := range v.MapKeys() {
+			name := key.String()
+			keys[name] = true
+			field, ok := s.fields[name]
+			if !ok {
+				t.Errorf("%s.%s is built but missing from the shape", path, name)
+				continue
+			}
+			compareShape(t, path+"."+name, field, v.MapIndex(key))
+		}
+		for name := range s.fields {
+			if !keys[name] {
+				t.Errorf("%s.%s is in the shape but never built", path, name)
+			}
+		}
+	case reflect.Slice:
+		if s.elem == nil {
+			t.Errorf("%s is an array but its shape is not", path)
+			return
+		}
+		if v.Len() == 0 {
+			t.Errorf("%s has no elements to compare", path)
+			return
+		}
+		compareShape(t, path+".*", s.elem, v.Index(0))
+	default:
+		if len(s.fields) > 0 || s.elem != nil {
+			t.Errorf("%s is a scalar but its shape has properties", path)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+// toList converts any slice or array value to []interface{}
+func toList(v interface{}) ([]interface{}, bool) {
+	if list, ok := v.([]interface{}); ok {
+		return list, true
+	}
+	if v == nil {
+		return nil, false
+	}
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, val.Len())
+	for i := range list {
+		list[i] = val.Index(i).Interface()
+	}
+	return list, true
+}
+
+// objectFilter implements the `.*` filter: the elements of an array, or the
+// values of an object in key order. Anything else filters to an empty array.
+func objectFilter(v interface{}) []interface{} {
+	if list, ok := toList(v); ok {
+		return list
+	}
+
+	if steps, ok := v.(map[string]StepContext); ok {
+		keys := make([]string, 0, len(steps))
+		for k := range steps {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			values = append(values, stepContextToMap(steps[k]))
+		}
+		return values
+	}
+
+	val := reflect.ValueOf(v)
+	if v == nil || val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return []interface{}{}
+	}
+
+	keys := make([]string, 0, val.Len())
+	for _, k := range val.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	values := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		values = append(values, val.MapIndex(reflect.ValueOf(k).Convert(val.Type().Key())).Interface())
+	}
+	return values
+}
+
+// flatMap applies fn to every item and concatenates the results
+func flatMap(items []interface{}, fn func(interface{}) []interface{}) []interface{} {
+	result := []interface{}{}
+	for _, item := range items {
+		result = append(result, fn(item)...)
+	}
+	return result
+}
+
+// builtinLength implements length(value) for arrays, objects and strings.
+// Strings are measured in characters; null has length 0.
+func builtinLength(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("length requires 1 argument")
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return int64(0), nil
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	}
+	if list, ok := toList(args[0]); ok {
+		return int64(len(list)), nil
+	}
+	if val := reflect.ValueOf(args[0]); val.Kind() == reflect.Map {
+		return int64(val.Len()), nil
+	}
+	return nil, fmt.Errorf("length requires an array, object or string")
+}
+
+// builtinAny implements any(array) and any(array, 'function', args...).
+// With only an array it is true if any element is truthy. With a function name it
+// is true if the function returns a truthy value for any element, called as
+// function(element, args...), e.g. any(event.commit.files.*.path, 'startsWith', 'db/').
+func builtinAny(ctx *Context, args ...interface{}) (interface{}, error) {
+	return evaluatePredicate(ctx, "any", args, true)
+}
+
+// builtinAll implements all(array) and all(array, 'function', args...).
+// It is the counterpart of any(): true if every element satisfies the predicate.
+// An empty array satisfies all().
+func builtinAll(ctx *Context, args ...interface{}) (interface{}, error) {
+	return evaluatePredicate(ctx, "all", args, false)
+}
+
+// evaluatePredicate runs a predicate over a list, stopping at the first element whose
+// result equals stopOn (true for any, false for all)
+func evaluatePredicate(ctx *Context, name string, args []interface{}, stopOn bool) (interface{}, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("%s requires at least 1 argument", name)
+	}
+
+	items, err := listArgument(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	test := func(item interface{}) (bool, error) {
+		return toBool(item), nil
+	}
+	if len(args) > 1 {
+		fnName, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s requires a function name as its second argument", name)
+		}
+		call, err := ctx.lookupFunction(fnName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		extra := args[2:]
+		test = func(item interface{}) (bool, error) {
+			result, err := call(append([]interface{}{item}, extra...)...)
+			if err != nil {
+				return false, err
+			}
+			return toBool(result), nil
+		}
+	}
+
+	for _, item := range items {
+		ok, err := test(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if ok == stopOn {
+			return stopOn, nil
+		}
+	}
+	return !stopOn, nil
+}
+
+// lookupFunction returns a callable for a built-in function by name
+func (ctx *Context) lookupFunction(name string) (Function, error) {
+	if ctxFn, ok := ctx.ContextFunctions[name]; ok {
+		return func(args ...interface{}) (interface{}, error) {
+			return ctxFn(ctx, args...)
+		}, nil
+	}
+	if fn, ok := ctx.Functions[name]; ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("unknown function: %s%s", name, didYouMean(name, ctx.functionNames()))
+}
+
+// builtinUnique implements unique(array): the array without duplicates, keeping
+// the first occurrence. Elements are compared like ==.
+func builtinUnique(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("unique requires 1 argument")
+	}
+	list, err := listArgument("unique", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+	for _, item := range list {
+		seen := false
+		for _, kept := range result {
+			if equals(item, kept) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// builtinSort implements sort(array): a sorted copy of the array. Arrays of numbers
+// sort numerically; anything else sorts by string value.
+func builtinSort(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("sort requires 1 argument")
+	}
+	list, err := listArgument("sort", args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := append([]interface{}{}, list...)
+	numeric := true
+	for _, item := range result {
+		if !isNumber(item) {
+			numeric = false
+			break
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if numeric {
+			return toNumber(result[i]) < toNumber(result[j])
+		}
+		return toString(result[i]) < toString(result[j])
+	})
+	return result, nil
+}
+
+// listArgument converts a function argument to a list; null is an empty list
+func listArgument(name string, v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return []interface{}{}, nil
+	}
+	list, ok := toList(v)
+	if !ok {
+		return nil, fmt.Errorf("%s requires an array", name)
+	}
+	return list, nil
+}
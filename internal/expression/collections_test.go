@@ -0,0 +1,141 @@
+package expression
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+// newCollectionsContext builds a context from a commit event with several files
+func newCollectionsContext() *Context {
+	ctx := NewContextForEvent(&schema.Event{
+		Commit: &schema.CommitEvent{
+			SHA: "abc123",
+			Files: []schema.FileStatus{
+				{Path: "db/migrations/002.sql", Status: "added"},
+				{Path: "src/app.go", Status: "modified"},
+				{Path: "db/schema.sql", Status: "modified"},
+			},
+		},
+	})
+	ctx.Steps["lint"] = StepContext{Outputs: map[string]string{"count": "2"}, Outcome: "success"}
+	ctx.Steps["test"] = StepContext{Outputs: map[string]string{"count": "5"}, Outcome: "failure"}
+	return ctx
+}
+
+// TestObjectFilter tests the .* object filter syntax
+func TestObjectFilter(t *testing.T) {
+	ctx := newCollectionsContext()
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"array property", "event.commit.files.*.path", []interface{}{"db/migrations/002.sql", "src/app.go", "db/schema.sql"}},
+		{"array second property", "event.commit.files.*.status", []interface{}{"added", "modified", "modified"}},
+		{"object values in key order", "steps.*.outcome", []interface{}{"success", "failure"}},
+		{"nested property", "steps.*.outputs.count", []interface{}{"2", "5"}},
+		{"missing property is dropped", "fromJSON('[{\"a\":1},{\"b\":2}]').*.a", []interface{}{float64(1)}},
+		{"filter of non-collection", "event.commit.sha.*", []interface{}{}},
+		{"index into filtered result", "event.commit.files.*.path[1]", "src/app.go"},
+		{"nested filter flattens", "fromJSON('[[1,2],[3]]').*.*", []interface{}{float64(1), float64(2), float64(3)}},
+		{"index into typed slice", "event.commit.files[2].path", "db/schema.sql"},
+		{"contains on filtered result", "contains(event.commit.files.*.path, 'src/app.go')", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCollectionFunctions tests length, any, all, unique and sort
+func TestCollectionFunctions(t *testing.T) {
+	ctx := newCollectionsContext()
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		// length
+		{"length of array", "length(event.commit.files)", int64(3)},
+		{"length of filtered", "length(event.commit.files.*.path)", int64(3)},
+		{"length of object", "length(steps)", int64(2)},
+		{"length of string", "length('héllo')", int64(5)},
+		{"length of null", "length(null)", int64(0)},
+		{"length in comparison", "length(event.commit.files) > 2", true},
+		// any / all
+		{"any truthy", "any(fromJSON('[0, false, 1]'))", true},
+		{"any none truthy", "any(fromJSON('[0, false, null]'))", false},
+		{"any empty", "any(fromJSON('[]'))", false},
+		{"any with predicate", "any(event.commit.files.*.path, 'startsWith', 'db/')", true},
+		{"any with glob", "any(event.commit.files.*.path, 'glob', 'db/**/*.sql')", true},
+		{"any with regex", "any(event.commit.files.*.path, 'matches', '\\.rb$')", false},
+		{"all truthy", "all(fromJSON('[1, true, \"x\"]'))", true},
+		{"all with predicate", "all(event.commit.files.*.path, 'endsWith', '.sql')", false},
+		{"all with glob", "all(event.commit.files.*.path, 'glob', '**/*.*')", true},
+		{"all empty", "all(fromJSON('[]'), 'startsWith', 'x')", true},
+		{"all of null", "all(null)", true},
+		// unique / sort
+		{"unique", "unique(event.commit.files.*.status)", []interface{}{"added", "modified"}},
+		{"unique numbers", "unique(fromJSON('[1, 2, 1, 3, 2]'))", []interface{}{float64(1), float64(2), float64(3)}},
+		{"sort strings", "sort(event.commit.files.*.path)", []interface{}{"db/migrations/002.sql", "db/schema.sql", "src/app.go"}},
+		{"sort numbers", "sort(fromJSON('[10, 9, 100]'))", []interface{}{float64(9), float64(10), float64(100)}},
+		{"sort unique combined", "join(sort(unique(event.commit.files.*.status)), ',')", "added,modified"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCollectionFunctionErrors tests argument errors of collection functions
+func TestCollectionFunctionErrors(t *testing.T) {
+	ctx := newCollectionsContext()
+
+	tests := []struct {
+		name   string
+		expr   string
+		errMsg string
+	}{
+		{"length wrong args", "length()", "requires 1 argument"},
+		{"length of number", "length(5)", "requires an array, object or string"},
+		{"any no args", "any()", "requires at least 1 argument"},
+		{"any non-array", "any('abc')", "requires an array"},
+		{"any unknown function", "any(event.commit.files, 'nope')", "unknown function: nope"},
+		{"all predicate error", "all(event.commit.files.*.path, 'matches', '(')", "invalid regular expression"},
+		{"unique non-array", "unique('abc')", "requires an array"},
+		{"sort wrong args", "sort()", "requires 1 argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctx.Evaluate(tt.expr)
+			if err == nil {
+				t.Fatalf("Evaluate(%q) expected error", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error message = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
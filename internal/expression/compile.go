@@ -0,0 +1,337 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SyntaxError describes an expression that could not be parsed
+type SyntaxError struct {
+	Expr   string // The expression source
+	Column int    // 1-based column of the offending token
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s%s", e.Column, e.Msg, pointer(e.Expr, e.Column))
+}
+
+// Program is a compiled expression that can be evaluated against any context
+type Program struct {
+	Source string
+	root   node
+}
+
+// Eval evaluates the compiled expression against a context. Errors are returned
+// as *EvalError with the column of the part of the expression that failed.
+func (p *Program) Eval(ctx *Context) (interface{}, error) {
+	value, err := p.root.eval(ctx)
+	if err != nil {
+		var located *locatedError
+		if errors.As(err, &located) {
+			return nil, &EvalError{Expr: p.Source, Column: located.pos + 1, Err: located.err}
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+// programCache holds compiled expressions by source text. Workflows use a small,
+// fixed set of expressions, so entries are never evicted.
+var programCache sync.Map // string -> *Program
+
+// Compile parses an expression (without the ${{ }} wrapper) into a Program.
+// Results are cached by source text, so each expression is only parsed once.
+// Syntax errors are returned as *SyntaxError with the column of the problem.
+func Compile(expr string) (*Program, error) {
+	if cached, ok := programCache.Load(expr); ok {
+		return cached.(*Program), nil
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{expr: expr, tokens: tokens}
+	root, err := c.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	program := &Program{Source: expr, root: root}
+	programCache.Store(expr, program)
+	return program, nil
+}
+
+// compiler builds an AST from tokens by recursive descent. Precedence, lowest first:
+// ||, &&, == !=, < <= > >=, + -, * /, unary ! -, then calls, property access and indexing.
+type compiler struct {
+	expr   string
+	tokens []Token
+	pos    int
+}
+
+func (c *compiler) parse() (node, error) {
+	root, err := c.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !c.isAtEnd() {
+		return nil, c.errorAt(c.peek(), fmt.Sprintf("unexpected token '%s'", c.peek().Value))
+	}
+	return root, nil
+}
+
+func (c *compiler) parseOr() (node, error) {
+	left, err := c.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for c.checkOperator("||") {
+		pos := c.advance().Pos
+		right, err := c.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{pos: pos, op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (c *compiler) parseAnd() (node, error) {
+	left, err := c.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+	for c.checkOperator("&&") {
+		pos := c.advance().Pos
+		right, err := c.parseEquality()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{pos: pos, op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (c *compiler) parseEquality() (node, error) {
+	return c.parseBinary(c.parseComparison, "==", "!=")
+}
+
+func (c *compiler) parseComparison() (node, error) {
+	return c.parseBinary(c.parseAdditive, "<", "<=", ">", ">=")
+}
+
+func (c *compiler) parseAdditive() (node, error) {
+	return c.parseBinary(c.parseMultiplicative, "+", "-")
+}
+
+func (c *compiler) parseMultiplicative() (node, error) {
+	return c.parseBinary(c.parseUnary, "*", "/")
+}
+
+// parseBinary parses a left-associative chain of the given operators
+func (c *compiler) parseBinary(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for c.checkOperator(ops...) {
+		tok := c.advance()
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: tok.Pos, op: tok.Value, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (c *compiler) parseUnary() (node, error) {
+	if c.checkOperator("!", "-") {
+		tok := c.advance()
+		operand, err := c.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: tok.Pos, op: tok.Value, operand: operand}, nil
+	}
+	return c.parsePostfix()
+}
+
+func (c *compiler) parsePostfix() (node, error) {
+	expr, err := c.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	// After an object filter (.*), property access applies to every element
+	filtered := false
+
+	for {
+		switch {
+		case c.check(TokenLeftParen):
+			// Function call
+			ident, ok := expr.(*identNode)
+			if !ok {
+				return nil, c.errorAt(c.peek(), "expected function name before '('")
+			}
+			c.advance()
+			args, err := c.parseArguments()
+			if err != nil {
+				return nil, err
+			}
+			expr = &callNode{pos: ident.pos, name: ident.name, args: args}
+
+		case c.check(TokenDot):
+			dot := c.advance()
+			// Object filter
+			if c.checkOperator("*") {
+				c.advance()
+				expr = &filterNode{pos: dot.Pos, target: expr, flatten: filtered}
+				filtered = true
+				continue
+			}
+			// Property access
+			if !c.check(TokenIdentifier) {
+				return nil, c.errorAt(c.peek(), "expected property name after '.'")
+			}
+			name := c.advance()
+			expr = &propertyNode{pos: name.Pos, target: expr, name: name.Value, mapped: filtered}
+
+		case c.check(TokenLeftBracket):
+			bracket := c.advance()
+			index, err := c.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !c.check(TokenRightBracket) {
+				return nil, c.errorAt(c.peek(), "expected ']' after index")
+			}
+			c.advance()
+			// Indexing a filtered result selects from the result array itself
+			expr = &indexNode{pos: bracket.Pos, target: expr, index: index}
+			filtered = false
+
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (c *compiler) parseArguments() ([]node, error) {
+	var args []node
+	if !c.check(TokenRightParen) {
+		for {
+			arg, err := c.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !c.check(TokenComma) {
+				break
+			}
+			c.advance()
+		}
+	}
+	if !c.check(TokenRightParen) {
+		return nil, c.errorAt(c.peek(), "expected ')' after arguments")
+	}
+	c.advance()
+	return args, nil
+}
+
+func (c *compiler) parsePrimary() (node, error) {
+	tok := c.peek()
+	switch tok.Type {
+	case TokenNumber:
+		c.advance()
+		if strings.ContainsAny(tok.Value, ".eE") {
+			f, err := strconv.ParseFloat(tok.Value, 64)
+			if err != nil {
+				return nil, c.errorAt(tok, fmt.Sprintf("invalid number '%s'", tok.Value))
+			}
+			return &literalNode{pos: tok.Pos, value: f}, nil
+		}
+		i, err := strconv.ParseInt(tok.Value, 10, 64)
+		if err != nil {
+			return nil, c.errorAt(tok, fmt.Sprintf("invalid number '%s'", tok.Value))
+		}
+		return &literalNode{pos: tok.Pos, value: i}, nil
+
+	case TokenString:
+		c.advance()
+		return &literalNode{pos: tok.Pos, value: tok.Value}, nil
+
+	case TokenIdentifier:
+		c.advance()
+		switch tok.Value {
+		case "true":
+			return &literalNode{pos: tok.Pos, value: true}, nil
+		case "false":
+			return &literalNode{pos: tok.Pos, value: false}, nil
+		case "null":
+			return &literalNode{pos: tok.Pos, value: nil}, nil
+		}
+		return &identNode{pos: tok.Pos, name: tok.Value}, nil
+
+	case TokenLeftParen:
+		c.advance()
+		expr, err := c.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !c.check(TokenRightParen) {
+			return nil, c.errorAt(c.peek(), "expected ')' after expression")
+		}
+		c.advance()
+		return expr, nil
+
+	case TokenEOF:
+		return nil, c.errorAt(tok, "unexpected token: end of expression")
+	}
+
+	return nil, c.errorAt(tok, fmt.Sprintf("unexpected token '%s'", tok.Value))
+}
+
+// errorAt creates a syntax error pointing at a token
+func (c *compiler) errorAt(tok Token, msg string) error {
+	return &SyntaxError{Expr: c.expr, Column: tok.Pos + 1, Msg: msg}
+}
+
+func (c *compiler) check(t TokenType) bool {
+	return c.peek().Type == t
+}
+
+// checkOperator reports whether the next token is one of the given operators
+func (c *compiler) checkOperator(ops ...string) bool {
+	tok := c.peek()
+	if tok.Type != TokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.Value == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compiler) advance() Token {
+	tok := c.peek()
+	if !c.isAtEnd() {
+		c.pos++
+	}
+	return tok
+}
+
+func (c *compiler) peek() Token {
+	return c.tokens[c.pos]
+}
+
+func (c *compiler) isAtEnd() bool {
+	return c.peek().Type == TokenEOF
+}
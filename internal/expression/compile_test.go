@@ -0,0 +1,157 @@
+package expression
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+func TestCompileSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		column  int
+		message string
+	}{
+		{"unterminated string", "contains('abc, 'a')", 18, "unterminated string"},
+		{"unexpected character", "event.x @ 1", 9, "unexpected character '@'"},
+		{"missing operand", "1 +", 4, "end of expression"},
+		{"missing property name", "event.", 7, "expected property name after '.'"},
+		{"unclosed call", "contains('a', 'b'", 18, "expected ')' after arguments"},
+		{"unclosed index", "event.files[0", 14, "expected ']' after index"},
+		{"unclosed group", "(1 + 2", 7, "expected ')' after expression"},
+		{"trailing tokens", "event.tool 'bash'", 12, "unexpected token 'bash'"},
+		{"column counts runes", "'é' == 'e' )", 12, "unexpected token ')'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if err == nil {
+				t.Fatal("Expected syntax error")
+			}
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Expected *SyntaxError, got %T: %v", err, err)
+			}
+			if syntaxErr.Column != tt.column {
+				t.Errorf("Expected column %d, got %d (%v)", tt.column, syntaxErr.Column, err)
+			}
+			if !strings.Contains(syntaxErr.Msg, tt.message) {
+				t.Errorf("Expected message containing %q, got %q", tt.message, syntaxErr.Msg)
+			}
+			if syntaxErr.Expr != tt.expr {
+				t.Errorf("Expected expression %q, got %q", tt.expr, syntaxErr.Expr)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorPointsAtColumn(t *testing.T) {
+	_, err := Compile("event.x @ 1")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	want := "syntax error at column 9: unexpected character '@'\n    event.x @ 1\n            ^"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestCompileCachesPrograms(t *testing.T) {
+	a, err := Compile("event.file.path == 'main.go'")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	b, err := Compile("event.file.path == 'main.go'")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if a != b {
+		t.Error("Expected the same program for the same source")
+	}
+	if a.Source != "event.file.path == 'main.go'" {
+		t.Errorf("Expected program source to be kept, got %q", a.Source)
+	}
+}
+
+func TestProgramEvalAgainstContexts(t *testing.T) {
+	program, err := Compile("format('{0}:{1}', env.NAME, length(event.files.*.path))")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		files []interface{}
+		want  string
+	}{
+		{"no files", map[string]string{"NAME": "a"}, []interface{}{}, "a:0"},
+		{"two files", map[string]string{"NAME": "b"}, []interface{}{
+			map[string]interface{}{"path": "x.go"},
+			map[string]interface{}{"path": "y.go"},
+		}, "b:2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext()
+			ctx.Env = tt.env
+			ctx.Event["files"] = tt.files
+
+			result, err := program.Eval(ctx)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expected %q, got %v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestLogicalOperatorsShortCircuit(t *testing.T) {
+	ctx := NewContext()
+
+	// The right side would fail if it were evaluated
+	for _, expr := range []string{"true || undefinedFn()", "false && undefinedFn()"} {
+		if _, err := ctx.Evaluate(expr); err != nil {
+			t.Errorf("Evaluate(%q) error = %v", expr, err)
+		}
+	}
+	if _, err := ctx.Evaluate("false || undefinedFn()"); err == nil {
+		t.Error("Expected error when the right side is evaluated")
+	}
+}
+
+func TestCompileWorkflow(t *testing.T) {
+	wf := &schema.Workflow{
+		Name: "test",
+		Env:  map[string]string{"TARGET": "${{ event.file.path }}"},
+		Steps: []schema.Step{
+			{Name: "ok", If: "event.file.path != ''", Run: "echo ${{ env.TARGET }}"},
+			{Name: "bad", Run: "echo ${{ format('{0}', env.TARGET }}"},
+		},
+	}
+
+	exprs := WorkflowExpressions(wf)
+	fields := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		fields = append(fields, e.Field)
+	}
+	want := "env.TARGET,steps.0.if,steps.0.run,steps.1.run"
+	if got := strings.Join(fields, ","); got != want {
+		t.Errorf("Expected fields %s, got %s", want, got)
+	}
+
+	err := CompileWorkflow(wf)
+	if err == nil {
+		t.Fatal("Expected error for invalid expression")
+	}
+	if !strings.HasPrefix(err.Error(), "steps.1.run: syntax error at column") {
+		t.Errorf("Expected error to name the field, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EvalError describes an expression that failed while being evaluated, such as a
+// call to an unknown function or a function given bad arguments
+type EvalError struct {
+	Expr   string // The expression source
+	Column int    // 1-based column of the node that failed
+	Err    error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("error at column %d: %v%s", e.Column, e.Err, pointer(e.Expr, e.Column))
+}
+
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
+// locatedError carries the position of the node that failed up to Program.Eval,
+// which knows the source and turns it into an EvalError
+type locatedError struct {
+	pos int
+	err error
+}
+
+func (e *locatedError) Error() string {
+	return e.err.Error()
+}
+
+// locate records where an evaluation error happened. The innermost node wins, so
+// an error inside a nested call points at that call rather than the outer one.
+func locate(n node, err error) error {
+	var located *locatedError
+	if errors.As(err, &located) {
+		return err
+	}
+	return &locatedError{pos: n.position(), err: err}
+}
+
+// pointer renders the line of source containing column with a caret under it.
+// The result starts with a newline so it can follow a one-line message.
+func pointer(source string, column int) string {
+	runes := []rune(source)
+	offset := column - 1
+	if offset < 0 || offset > len(runes) {
+		return ""
+	}
+
+	// Show only the line the column is on
+	start := offset
+	for start > 0 && runes[start-1] != '\n' {
+		start--
+	}
+	end := offset
+	for end < len(runes) && runes[end] != '\n' {
+		end++
+	}
+
+	// Keep tabs in the padding so the caret lines up however tabs are displayed
+	var pad strings.Builder
+	for _, r := range runes[start:offset] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return fmt.Sprintf("\n    %s\n    %s^", string(runes[start:end]), pad.String())
+}
+
+// didYouMean suggests the candidate closest to name, formatted to follow an error
+// message, or returns "" if none is close enough to be a likely typo
+func didYouMean(name string, candidates []string) string {
+	lower := strings.ToLower(name)
+	limit := 1
+	if len(name) > 4 {
+		limit = 2
+	}
+
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if d := editDistance(lower, strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean '%s'?", best)
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent characters needed to turn a into b
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d := min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d = min(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+		}
+	}
+	return rows[len(s)][len(t)]
+}
+
+// functionNames returns the names of every function available in a context
+func (ctx *Context) functionNames() []string {
+	names := make([]string, 0, len(ctx.Functions)+len(ctx.ContextFunctions))
+	for name := range ctx.Functions {
+		names = append(names, name)
+	}
+	for name := range ctx.ContextFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
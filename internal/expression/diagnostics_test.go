@@ -0,0 +1,90 @@
+package expression
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		column int
+		want   string
+	}{
+		{"first column", "foo(1)", 1, "\n    foo(1)\n    ^"},
+		{"middle", "event.x + )", 11, "\n    event.x + )\n              ^"},
+		{"end of expression", "1 +", 4, "\n    1 +\n       ^"},
+		{"multi-line", "a &&\n  bad(1)", 8, "\n      bad(1)\n      ^"},
+		{"tabs", "\tfoo(1)", 2, "\n    \tfoo(1)\n    \t^"},
+		{"out of range", "foo", 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointer(tt.source, tt.column); got != tt.want {
+				t.Errorf("pointer(%q, %d) = %q, want %q", tt.source, tt.column, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	candidates := []string{"contains", "startsWith", "endsWith", "format", "name", "path"}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"startswith", "; did you mean 'startsWith'?"},
+		{"contians", "; did you mean 'contains'?"},
+		{"nmae", "; did you mean 'name'?"},
+		{"pth", "; did you mean 'path'?"},
+		{"formatting", ""},
+		{"xyz", ""},
+		{"name", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := didYouMean(tt.name, candidates); got != tt.want {
+				t.Errorf("didYouMean(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalErrorPosition(t *testing.T) {
+	ctx := NewContext()
+
+	tests := []struct {
+		name   string
+		expr   string
+		column int
+		msg    string
+	}{
+		{"unknown function", "startswith('abc', 'a')", 1, "unknown function: startswith; did you mean 'startsWith'?"},
+		{"nested call", "contains(fromJSON('{'), 'a')", 10, "JSON"},
+		{"arithmetic", "event.count / 0", 13, "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctx.Evaluate(tt.expr)
+			var evalErr *EvalError
+			if !errors.As(err, &evalErr) {
+				t.Fatalf("Expected *EvalError, got %T: %v", err, err)
+			}
+			if evalErr.Column != tt.column {
+				t.Errorf("Expected column %d, got %d", tt.column, evalErr.Column)
+			}
+			if !strings.Contains(evalErr.Err.Error(), tt.msg) {
+				t.Errorf("Expected error containing %q, got %q", tt.msg, evalErr.Err.Error())
+			}
+			if !strings.Contains(err.Error(), "\n    "+tt.expr+"\n") {
+				t.Errorf("Expected error to show the expression, got %q", err.Error())
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@ package expression
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"os"
 	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 )
@@ -13,8 +16,14 @@ type Context struct {
 	Event            map[string]interface{}
 	Env              map[string]string
 	Steps            map[string]StepContext
+	Vars             map[string]interface{} // Shared configuration variables (vars.yml)
+	Secrets          map[string]string
+	Runner           map[string]interface{} // Host running agentic-ops (os, arch, temp, version)
+	Git              map[string]interface{} // Repository state (branch, remote, ahead, behind, sha, author)
 	Functions        map[string]Function
 	ContextFunctions map[string]ContextFunction
+	Cancelled        bool   // Set when the workflow run has been cancelled
+	WorkingDir       string // Base directory for file functions (defaults to the process cwd)
 }
 
 // StepContext holds the output of a previous step
@@ -35,6 +44,10 @@ func NewContext() *Context {
 		Event:            make(map[string]interface{}),
 		Env:              make(map[string]string),
 		Steps:            make(map[string]StepContext),
+		Vars:             make(map[string]interface{}),
+		Secrets:          make(map[string]string),
+		Runner:           RunnerContext(""),
+		Git:              make(map[string]interface{}),
 		Functions:        make(map[string]Function),
 		ContextFunctions: make(map[string]ContextFunction),
 	}
@@ -47,29 +60,67 @@ func NewContext() *Context {
 	ctx.Functions["toJSON"] = builtinToJSON
 	ctx.Functions["fromJSON"] = builtinFromJSON
 	ctx.Functions["always"] = builtinAlways
+	ctx.Functions["matches"] = builtinMatches
+	ctx.Functions["regexReplace"] = builtinRegexReplace
+	ctx.Functions["regexCapture"] = builtinRegexCapture
+	ctx.Functions["glob"] = builtinGlob
+	ctx.Functions["length"] = builtinLength
+	ctx.Functions["unique"] = builtinUnique
+	ctx.Functions["sort"] = builtinSort
 	// Register context-aware functions
 	ctx.ContextFunctions["success"] = builtinSuccess
 	ctx.ContextFunctions["failure"] = builtinFailure
 	ctx.ContextFunctions["cancelled"] = builtinCancelled
+	ctx.ContextFunctions["any"] = builtinAny
+	ctx.ContextFunctions["all"] = builtinAll
+	ctx.ContextFunctions["hashFiles"] = builtinHashFiles
+	ctx.ContextFunctions["fileExists"] = builtinFileExists
+	ctx.ContextFunctions["readFile"] = builtinReadFile
+	ctx.ContextFunctions["lineCount"] = builtinLineCount
 	return ctx
 }
 
+// RunnerContext returns the `runner` context for the current host. os and arch use
+// Go's names (linux, darwin, windows; amd64, arm64).
+func RunnerContext(version string) map[string]interface{} {
+	return map[string]interface{}{
+		"os":      runtime.GOOS,
+		"arch":    runtime.GOARCH,
+		"temp":    os.TempDir(),
+		"version": version,
+	}
+}
+
+// GitInfo is the repository state exposed as the `git` context
+type GitInfo struct {
+	Branch string
+	Remote string
+	Ahead  int
+	Behind int
+	SHA    string
+	Author string
+}
+
+// GitContext returns the `git` context for a repository. Numbers are int64 like
+// other numbers in expressions.
+func GitContext(info GitInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"branch": info.Branch,
+		"remote": info.Remote,
+		"ahead":  int64(info.Ahead),
+		"behind": int64(info.Behind),
+		"sha":    info.SHA,
+		"author": info.Author,
+	}
+}
+
 // Evaluate evaluates an expression string against the context
 func (ctx *Context) Evaluate(expr string) (interface{}, error) {
-	// Parse the expression
-	tokens, err := tokenize(expr)
+	program, err := Compile(expr)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create evaluator
-	eval := &evaluator{
-		tokens: tokens,
-		pos:    0,
-		ctx:    ctx,
-	}
-
-	return eval.evaluate()
+	return program.Eval(ctx)
 }
 
 // EvaluateString evaluates an expression and returns a string result
@@ -106,247 +157,8 @@ func (ctx *Context) EvaluateBool(expr string) (bool, error) {
 	return toBool(result), nil
 }
 
-// evaluator walks through tokens and evaluates expressions
-type evaluator struct {
-	tokens []Token
-	pos    int
-	ctx    *Context
-}
-
-func (e *evaluator) evaluate() (interface{}, error) {
-	return e.parseOr()
-}
-
-func (e *evaluator) parseOr() (interface{}, error) {
-	left, err := e.parseAnd()
-	if err != nil {
-		return nil, err
-	}
-
-	for e.check(TokenOperator) && e.peek().Value == "||" {
-		e.advance() // consume the ||
-		right, err := e.parseAnd()
-		if err != nil {
-			return nil, err
-		}
-		left = toBool(left) || toBool(right)
-	}
-
-	return left, nil
-}
-
-func (e *evaluator) parseAnd() (interface{}, error) {
-	left, err := e.parseEquality()
-	if err != nil {
-		return nil, err
-	}
-
-	for e.check(TokenOperator) && e.peek().Value == "&&" {
-		e.advance() // consume the &&
-		right, err := e.parseEquality()
-		if err != nil {
-			return nil, err
-		}
-		left = toBool(left) && toBool(right)
-	}
-
-	return left, nil
-}
-
-func (e *evaluator) parseEquality() (interface{}, error) {
-	left, err := e.parseComparison()
-	if err != nil {
-		return nil, err
-	}
-
-	for e.check(TokenOperator) && (e.peek().Value == "==" || e.peek().Value == "!=") {
-		op := e.advance().Value
-		right, err := e.parseComparison()
-		if err != nil {
-			return nil, err
-		}
-		if op == "==" {
-			left = equals(left, right)
-		} else {
-			left = !equals(left, right)
-		}
-	}
-
-	return left, nil
-}
-
-func (e *evaluator) parseComparison() (interface{}, error) {
-	left, err := e.parseUnary()
-	if err != nil {
-		return nil, err
-	}
-
-	for e.check(TokenOperator) {
-		op := e.peek().Value
-		if op != "<" && op != "<=" && op != ">" && op != ">=" {
-			break
-		}
-		e.advance()
-		right, err := e.parseUnary()
-		if err != nil {
-			return nil, err
-		}
-		leftNum := toNumber(left)
-		rightNum := toNumber(right)
-		switch op {
-		case "<":
-			left = leftNum < rightNum
-		case "<=":
-			left = leftNum <= rightNum
-		case ">":
-			left = leftNum > rightNum
-		case ">=":
-			left = leftNum >= rightNum
-		}
-	}
-
-	return left, nil
-}
-
-func (e *evaluator) parseUnary() (interface{}, error) {
-	if e.check(TokenOperator) && e.peek().Value == "!" {
-		e.advance()
-		right, err := e.parseUnary()
-		if err != nil {
-			return nil, err
-		}
-		return !toBool(right), nil
-	}
-
-	return e.parseCall()
-}
-
-func (e *evaluator) parseCall() (interface{}, error) {
-	expr, err := e.parsePrimary()
-	if err != nil {
-		return nil, err
-	}
-
-	for {
-		if e.match(TokenLeftParen) {
-			// Function call
-			name, ok := expr.(string)
-			if !ok {
-				return nil, fmt.Errorf("expected function name")
-			}
-			expr, err = e.finishCall(name)
-			if err != nil {
-				return nil, err
-			}
-		} else if e.match(TokenDot) {
-			// Property access
-			if !e.check(TokenIdentifier) {
-				return nil, fmt.Errorf("expected property name after '.'")
-			}
-			name := e.advance().Value
-			expr = e.getProperty(expr, name)
-		} else if e.match(TokenLeftBracket) {
-			// Index access
-			index, err := e.evaluate()
-			if err != nil {
-				return nil, err
-			}
-			if !e.match(TokenRightBracket) {
-				return nil, fmt.Errorf("expected ']' after index")
-			}
-			expr = e.getIndex(expr, index)
-		} else {
-			break
-		}
-	}
-
-	return expr, nil
-}
-
-func (e *evaluator) finishCall(name string) (interface{}, error) {
-	var args []interface{}
-
-	if !e.check(TokenRightParen) {
-		for {
-			arg, err := e.evaluate()
-			if err != nil {
-				return nil, err
-			}
-			args = append(args, arg)
-			if !e.match(TokenComma) {
-				break
-			}
-		}
-	}
-
-	if !e.match(TokenRightParen) {
-		return nil, fmt.Errorf("expected ')' after arguments")
-	}
-
-	// Check for context-aware functions first
-	if ctxFn, ok := e.ctx.ContextFunctions[name]; ok {
-		return ctxFn(e.ctx, args...)
-	}
-
-	fn, ok := e.ctx.Functions[name]
-	if !ok {
-		return nil, fmt.Errorf("unknown function: %s", name)
-	}
-
-	return fn(args...)
-}
-
-func (e *evaluator) parsePrimary() (interface{}, error) {
-	if e.match(TokenNumber) {
-		val := e.previous().Value
-		if strings.Contains(val, ".") || strings.Contains(val, "e") || strings.Contains(val, "E") {
-			f, _ := strconv.ParseFloat(val, 64)
-			return f, nil
-		}
-		i, _ := strconv.ParseInt(val, 10, 64)
-		return i, nil
-	}
-
-	if e.match(TokenString) {
-		return e.previous().Value, nil
-	}
-
-	if e.match(TokenIdentifier) {
-		name := e.previous().Value
-		// Check for keywords
-		switch name {
-		case "true":
-			return true, nil
-		case "false":
-			return false, nil
-		case "null":
-			return nil, nil
-		case "event":
-			return e.ctx.Event, nil
-		case "env":
-			return e.ctx.Env, nil
-		case "steps":
-			return e.ctx.Steps, nil
-		}
-		// Return identifier for potential function call
-		return name, nil
-	}
-
-	if e.match(TokenLeftParen) {
-		expr, err := e.evaluate()
-		if err != nil {
-			return nil, err
-		}
-		if !e.match(TokenRightParen) {
-			return nil, fmt.Errorf("expected ')' after expression")
-		}
-		return expr, nil
-	}
-
-	return nil, fmt.Errorf("unexpected token: %v", e.peek())
-}
-
-func (e *evaluator) getProperty(obj interface{}, name string) interface{} {
+// getProperty reads a named property from an object, returning nil if it has none
+func getProperty(obj interface{}, name string) interface{} {
 	if obj == nil {
 		return nil
 	}
@@ -358,10 +170,7 @@ func (e *evaluator) getProperty(obj interface{}, name string) interface{} {
 		return v[name]
 	case map[string]StepContext:
 		if step, ok := v[name]; ok {
-			return map[string]interface{}{
-				"outputs": step.Outputs,
-				"outcome": step.Outcome,
-			}
+			return stepContextToMap(step)
 		}
 		return nil
 	default:
@@ -380,61 +189,36 @@ func (e *evaluator) getProperty(obj interface{}, name string) interface{} {
 	}
 }
 
-func (e *evaluator) getIndex(obj interface{}, index interface{}) interface{} {
+// stepContextToMap converts a step to its expression representation
+func stepContextToMap(step StepContext) map[string]interface{} {
+	return map[string]interface{}{
+		"outputs": step.Outputs,
+		"outcome": step.Outcome,
+	}
+}
+
+// getIndex reads an element of an array or a key of an object, returning nil if absent
+func getIndex(obj interface{}, index interface{}) interface{} {
 	if obj == nil {
 		return nil
 	}
 
 	switch v := obj.(type) {
-	case []interface{}:
-		i := int(toNumber(index))
-		if i >= 0 && i < len(v) {
-			return v[i]
-		}
-		return nil
 	case map[string]interface{}:
 		return v[toString(index)]
 	case map[string]string:
 		return v[toString(index)]
 	default:
+		if list, ok := toList(obj); ok {
+			i := int(toNumber(index))
+			if i >= 0 && i < len(list) {
+				return list[i]
+			}
+		}
 		return nil
 	}
 }
 
-func (e *evaluator) match(t TokenType) bool {
-	if e.check(t) {
-		e.advance()
-		return true
-	}
-	return false
-}
-
-func (e *evaluator) check(t TokenType) bool {
-	if e.isAtEnd() {
-		return false
-	}
-	return e.peek().Type == t
-}
-
-func (e *evaluator) advance() Token {
-	if !e.isAtEnd() {
-		e.pos++
-	}
-	return e.previous()
-}
-
-func (e *evaluator) peek() Token {
-	return e.tokens[e.pos]
-}
-
-func (e *evaluator) previous() Token {
-	return e.tokens[e.pos-1]
-}
-
-func (e *evaluator) isAtEnd() bool {
-	return e.peek().Type == TokenEOF
-}
-
 // Type conversion helpers
 func toString(v interface{}) string {
 	if v == nil {
@@ -497,6 +281,115 @@ func toNumber(v interface{}) float64 {
 	}
 }
 
+// isNumeric reports whether a value takes part in arithmetic as a number.
+// Strings count when they parse as numbers, since step outputs are always strings.
+func isNumeric(v interface{}) bool {
+	switch val := v.(type) {
+	case nil, bool, int64, float64:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(val, 64)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// toInteger converts a value to int64 if it is an integer (including integer strings)
+func toInteger(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case nil:
+		return 0, true
+	case bool:
+		if val {
+			return 1, true
+		}
+		return 0, true
+	case int64:
+		return val, true
+	case string:
+		i, err := strconv.ParseInt(val, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// arithmetic applies a binary arithmetic operator. Operands are coerced with toNumber,
+// except that + concatenates when either operand is a non-numeric string; any other
+// non-numeric operand is a type error. Results stay
+// integers when both operands are integers and the operation is exact; results that
+// would overflow int64 are computed as float64 instead.
+//
+// Identifiers may contain '-' (as in steps.my-step), so subtraction needs whitespace
+// before the operator: a-1 is the identifier "a-1", while a - 1 subtracts.
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if op == "+" && (!isNumeric(left) || !isNumeric(right)) {
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			return toString(left) + toString(right), nil
+		}
+	}
+
+	for _, operand := range []interface{}{left, right} {
+		if !isNumeric(operand) {
+			return nil, fmt.Errorf("operator '%s' requires numbers, got non-numeric value '%s'", op, toString(operand))
+		}
+	}
+	if op == "/" && toNumber(right) == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	l, lInt := toInteger(left)
+	r, rInt := toInteger(right)
+	if lInt && rInt {
+		if result, ok := intArithmetic(op, l, r); ok {
+			return result, nil
+		}
+	}
+
+	lf, rf := toNumber(left), toNumber(right)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	default:
+		return nil, fmt.Errorf("unknown operator: %s", op)
+	}
+}
+
+// intArithmetic applies op to two integers. It reports false when the result is not
+// an exact int64, either because it overflows or because a division has a remainder.
+func intArithmetic(op string, l, r int64) (int64, bool) {
+	switch op {
+	case "+":
+		sum := l + r
+		return sum, (l >= 0) != (r >= 0) || (sum >= 0) == (l >= 0)
+	case "-":
+		diff := l - r
+		return diff, (l >= 0) == (r >= 0) || (diff >= 0) == (l >= 0)
+	case "*":
+		if l == 0 || r == 0 {
+			return 0, true
+		}
+		product := l * r
+		return product, product/r == l && !(l == -1 && r == math.MinInt64) && !(r == -1 && l == math.MinInt64)
+	case "/":
+		if l == math.MinInt64 && r == -1 {
+			return 0, false
+		}
+		return l / r, l%r == 0
+	default:
+		return 0, false
+	}
+}
+
 func equals(a, b interface{}) bool {
 	// Handle case-insensitive string comparison
 	aStr, aIsStr := a.(string)
@@ -504,9 +397,23 @@ func equals(a, b interface{}) bool {
 	if aIsStr && bIsStr {
 		return strings.EqualFold(aStr, bStr)
 	}
+	// Numbers compare by value regardless of int/float representation
+	if isNumber(a) && isNumber(b) {
+		return toNumber(a) == toNumber(b)
+	}
 	return reflect.DeepEqual(a, b)
 }
 
+// isNumber reports whether a value is a numeric literal type
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	default:
+		return false
+	}
+}
+
 // Built-in functions
 func builtinContains(args ...interface{}) (interface{}, error) {
 	if len(args) != 2 {
@@ -608,6 +515,9 @@ func builtinAlways(args ...interface{}) (interface{}, error) {
 
 func builtinSuccess(ctx *Context, args ...interface{}) (interface{}, error) {
 	// success() returns true if no previous steps have failed or been cancelled
+	if ctx.Cancelled {
+		return false, nil
+	}
 	for _, step := range ctx.Steps {
 		if step.Outcome == "failure" || step.Outcome == "cancelled" {
 			return false, nil
@@ -627,7 +537,10 @@ func builtinFailure(ctx *Context, args ...interface{}) (interface{}, error) {
 }
 
 func builtinCancelled(ctx *Context, args ...interface{}) (interface{}, error) {
-	// cancelled() returns true if any previous step has been cancelled
+	// cancelled() returns true if the run or any previous step has been cancelled
+	if ctx.Cancelled {
+		return true, nil
+	}
 	for _, step := range ctx.Steps {
 		if step.Outcome == "cancelled" {
 			return true, nil
@@ -1,6 +1,7 @@
 package expression
 
 import (
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -506,6 +507,149 @@ func TestComparisonOperators(t *testing.T) {
 	}
 }
 
+// TestArithmeticOperators tests +, -, *, / precedence and coercion
+func TestArithmeticOperators(t *testing.T) {
+	ctx := NewContext()
+	ctx.Env["COUNT"] = "3"
+	ctx.Env["RATIO"] = "1.5"
+	ctx.Steps["count"] = StepContext{Outputs: map[string]string{"n": "41"}, Outcome: "success"}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		// Integer arithmetic stays integral
+		{"addition", "1 + 2", int64(3)},
+		{"subtraction", "10 - 4", int64(6)},
+		{"multiplication", "6 * 7", int64(42)},
+		{"exact division", "10 / 2", int64(5)},
+		{"inexact division", "7 / 2", float64(3.5)},
+		{"float addition", "1.5 + 1", float64(2.5)},
+		// Precedence and associativity
+		{"multiplication before addition", "2 + 3 * 4", int64(14)},
+		{"division before subtraction", "10 - 6 / 2", int64(7)},
+		{"left associative subtraction", "10 - 3 - 2", int64(5)},
+		{"left associative division", "100 / 10 / 5", int64(2)},
+		{"parentheses", "(2 + 3) * 4", int64(20)},
+		{"arithmetic before comparison", "2 * 60 > 100", true},
+		{"arithmetic before equality", "1 + 1 == 2", true},
+		{"arithmetic before logical", "1 + 1 == 2 && 3 - 3 == 0", true},
+		// Unary minus
+		{"negative literal", "-5", int64(-5)},
+		{"negative expression", "-(2 + 3)", int64(-5)},
+		{"subtract negative", "1 - -1", int64(2)},
+		// Numeric coercion
+		{"numeric string output", "steps.count.outputs.n + 1", int64(42)},
+		{"numeric env", "env.COUNT * 2", int64(6)},
+		{"float env", "env.RATIO * 2", float64(3)},
+		{"boolean operand", "true + 1", int64(2)},
+		{"null operand", "null + 1", int64(1)},
+		{"mixed int float equality", "env.RATIO * 2 == 3", true},
+		// String concatenation
+		{"string concatenation", "'foo' + 'bar'", "foobar"},
+		{"string plus number", "'v' + 1", "v1"},
+		{"number plus string", "1 + 'x'", "1x"},
+		{"numeric strings add", "'3' + '4'", int64(7)},
+		// Overflow falls back to float
+		{"addition overflow", "9223372036854775807 + 1", float64(9223372036854775808)},
+		{"subtraction overflow", "-9223372036854775807 - 2", float64(-9223372036854775809)},
+		{"multiplication overflow", "4611686018427387904 * 2", float64(9223372036854775808)},
+		{"largest integer", "9223372036854775806 + 1", int64(9223372036854775807)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// TestHyphenatedSubtraction tests that '-' continues an identifier unless spaced
+func TestHyphenatedSubtraction(t *testing.T) {
+	ctx := NewContext()
+	ctx.Vars["a"] = "5"
+	ctx.Vars["a-1"] = "hyphenated"
+	ctx.Steps["count"] = StepContext{Outputs: map[string]string{"n": "5"}, Outcome: "success"}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"hyphenated identifier", "vars.a-1", "hyphenated"},
+		{"spaced subtraction", "vars.a - 1", int64(4)},
+		{"subtraction of negative literal", "vars.a -1", int64(4)},
+		{"unspaced step output is a property", "steps.count.outputs.n-1", ""},
+		{"spaced step output subtraction", "steps.count.outputs.n - 1", int64(4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// TestArithmeticErrors tests arithmetic error cases
+func TestArithmeticErrors(t *testing.T) {
+	ctx := NewContext()
+
+	tests := []struct {
+		name   string
+		expr   string
+		errMsg string
+	}{
+		{"division by zero", "1 / 0", "division by zero"},
+		{"division by zero float", "1.5 / 0.0", "division by zero"},
+		{"division by null", "1 / null", "division by zero"},
+		{"division by string", "10 / 'abc'", "requires numbers, got non-numeric value 'abc'"},
+		{"subtraction of string", "'abc' - 1", "requires numbers"},
+		{"multiplication of object", "fromJSON('{}') * 2", "requires numbers"},
+		{"missing right operand", "1 +", "unexpected token"},
+		{"missing left operand", "* 2", "unexpected token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctx.Evaluate(tt.expr)
+			if err == nil {
+				t.Fatalf("Evaluate(%q) expected error", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error message = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+// TestArithmeticInString tests arithmetic inside ${{ }} interpolation
+func TestArithmeticInString(t *testing.T) {
+	ctx := NewContext()
+	ctx.Steps["count"] = StepContext{Outputs: map[string]string{"n": "9"}, Outcome: "success"}
+
+	got, err := ctx.EvaluateString("next=${{ steps.count.outputs.n + 1 }} half=${{ steps.count.outputs.n / 2 }}")
+	if err != nil {
+		t.Fatalf("EvaluateString() error = %v", err)
+	}
+	if got != "next=10 half=4.5" {
+		t.Errorf("EvaluateString() = %q, want %q", got, "next=10 half=4.5")
+	}
+}
+
 // TestIndexAccess tests array and map index access
 func TestIndexAccess(t *testing.T) {
 	ctx := NewContext()
@@ -594,10 +738,11 @@ func TestPropertyAccess(t *testing.T) {
 // TestStepContextFunctions tests success(), failure(), cancelled() with step context
 func TestStepContextFunctions(t *testing.T) {
 	tests := []struct {
-		name     string
-		steps    map[string]StepContext
-		expr     string
-		want     bool
+		name      string
+		steps     map[string]StepContext
+		cancelled bool
+		expr      string
+		want      bool
 	}{
 		// success() tests
 		{
@@ -679,6 +824,20 @@ func TestStepContextFunctions(t *testing.T) {
 			expr: "cancelled()",
 			want: true,
 		},
+		{
+			name:      "cancelled when run is cancelled",
+			steps:     map[string]StepContext{"step1": {Outcome: "success"}},
+			cancelled: true,
+			expr:      "cancelled()",
+			want:      true,
+		},
+		{
+			name:      "success when run is cancelled",
+			steps:     map[string]StepContext{"step1": {Outcome: "success"}},
+			cancelled: true,
+			expr:      "success()",
+			want:      false,
+		},
 		// Combined conditions
 		{
 			name: "success or failure",
@@ -702,6 +861,7 @@ func TestStepContextFunctions(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := NewContext()
 			ctx.Steps = tt.steps
+			ctx.Cancelled = tt.cancelled
 			got, err := ctx.Evaluate(tt.expr)
 			if err != nil {
 				t.Errorf("Evaluate() error = %v", err)
@@ -752,6 +912,83 @@ func TestStepsPropertyAccess(t *testing.T) {
 	}
 }
 
+func TestVarsPropertyAccess(t *testing.T) {
+	ctx := NewContext()
+	ctx.Vars = map[string]interface{}{
+		"max_lines":       int64(500),
+		"test_command":    "go test ./...",
+		"protected_paths": []interface{}{"go.mod", ".github/**"},
+		"limits":          map[string]interface{}{"files": int64(20)},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"scalar", "vars.test_command", "go test ./..."},
+		{"number comparison", "vars.max_lines > 100", true},
+		{"nested", "vars.limits.files * 2", int64(40)},
+		{"list", "contains(vars.protected_paths, 'go.mod')", true},
+		{"list predicate", "any(vars.protected_paths, 'startsWith', '.github/')", true},
+		{"missing", "vars.nonexistent", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunnerAndGitContexts tests the runner and git contexts
+func TestRunnerAndGitContexts(t *testing.T) {
+	ctx := NewContext()
+	ctx.Runner = RunnerContext("1.2.3")
+	ctx.Git = map[string]interface{}{
+		"branch": "main",
+		"remote": "origin",
+		"ahead":  int64(2),
+		"behind": int64(0),
+		"sha":    "abc123",
+		"author": "dev@example.com",
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"runner os", "runner.os == '" + runtime.GOOS + "'", true},
+		{"runner arch", "runner.arch", runtime.GOARCH},
+		{"runner version", "runner.version", "1.2.3"},
+		{"runner temp", "runner.temp != ''", true},
+		{"branch and ahead", "git.branch == 'main' && git.ahead > 0", true},
+		{"behind", "git.behind > 0", false},
+		{"sha", "startsWith(git.sha, 'abc')", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestNestedFunctionCalls tests nested function calls
 func TestNestedFunctionCalls(t *testing.T) {
 	ctx := NewContext()
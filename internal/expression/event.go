@@ -0,0 +1,120 @@
+package expression
+
+import (
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+// NewContextForEvent creates an evaluation context with the event context populated
+func NewContextForEvent(event *schema.Event) *Context {
+	ctx := NewContext()
+	if event != nil {
+		ctx.Event = EventToMap(event)
+		ctx.WorkingDir = event.Cwd
+	}
+	return ctx
+}
+
+// EventToMap converts a runtime event into the map exposed as the `event` context
+func EventToMap(event *schema.Event) map[string]interface{} {
+	result := make(map[string]interface{})
+	if event == nil {
+		return result
+	}
+
+	result["cwd"] = event.Cwd
+	result["timestamp"] = event.Timestamp
+
+	if event.Hook != nil {
+		hook := map[string]interface{}{
+			"type": event.Hook.Type,
+			"cwd":  event.Hook.Cwd,
+		}
+		if event.Hook.Tool != nil {
+			tool := map[string]interface{}{
+				"name": event.Hook.Tool.Name,
+				"args": event.Hook.Tool.Args,
+			}
+			if event.Hook.Tool.Result != nil {
+				tool["result"] = toolResultToMap(event.Hook.Tool.Result)
+			}
+			hook["tool"] = tool
+		}
+		result["hook"] = hook
+	}
+
+	if event.Tool != nil {
+		tool := map[string]interface{}{
+			"name":      event.Tool.Name,
+			"args":      event.Tool.Args,
+			"hook_type": event.Tool.HookType,
+		}
+		if event.Tool.Result != nil {
+			tool["result"] = toolResultToMap(event.Tool.Result)
+		}
+		result["tool"] = tool
+	}
+
+	if event.File != nil {
+		result["file"] = map[string]interface{}{
+			"path":     event.File.Path,
+			"action":   event.File.Action,
+			"content":  event.File.Content,
+			"old_path": event.File.OldPath,
+		}
+	}
+
+	if event.Commit != nil {
+		files := make([]map[string]string, len(event.Commit.Files))
+		for i, f := range event.Commit.Files {
+			files[i] = map[string]string{"path": f.Path, "status": f.Status}
+		}
+		result["commit"] = map[string]interface{}{
+			"sha":         event.Commit.SHA,
+			"message":     event.Commit.Message,
+			"author":      event.Commit.Author,
+			"branch":      event.Commit.Branch,
+			"files":       files,
+			"amend":       event.Commit.Amend,
+			"fixup":       event.Commit.Fixup,
+			"allow_empty": event.Commit.AllowEmpty,
+			"no_verify":   event.Commit.NoVerify,
+		}
+	}
+
+	if event.Push != nil {
+		commits := make([]interface{}, len(event.Push.Commits))
+		for i, c := range event.Push.Commits {
+			files := make([]interface{}, len(c.Files))
+			for j, f := range c.Files {
+				files[j] = map[string]interface{}{"path": f.Path, "status": f.Status}
+			}
+			commits[i] = map[string]interface{}{
+				"sha":     c.SHA,
+				"message": c.Message,
+				"author":  c.Author,
+				"files":   files,
+			}
+		}
+		result["push"] = map[string]interface{}{
+			"ref":     event.Push.Ref,
+			"before":  event.Push.Before,
+			"after":   event.Push.After,
+			"commits": commits,
+		}
+	}
+
+	return result
+}
+
+// toolResultToMap converts a tool result to its expression representation
+func toolResultToMap(r *schema.ToolResult) map[string]interface{} {
+	var exitCode interface{}
+	if r.ExitCode != nil {
+		exitCode = int64(*r.ExitCode)
+	}
+	return map[string]interface{}{
+		"type":      r.Type,
+		"output":    r.Output,
+		"exit_code": exitCode,
+	}
+}
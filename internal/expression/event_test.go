@@ -0,0 +1,75 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+func TestNewContextForEvent(t *testing.T) {
+	exitCode := 2
+	tool := &schema.ToolEvent{
+		Name:     "bash",
+		Args:     map[string]interface{}{"command": "go test ./..."},
+		HookType: "postToolUse",
+		Result:   &schema.ToolResult{Type: "failure", Output: "FAIL", ExitCode: &exitCode},
+	}
+	event := &schema.Event{
+		Cwd:  "/repo",
+		Tool: tool,
+		Hook: &schema.HookEvent{Type: "postToolUse", Tool: tool, Cwd: "/repo"},
+		Commit: &schema.CommitEvent{
+			Message:  "feat: x",
+			Branch:   "main",
+			NoVerify: true,
+		},
+		Push: &schema.PushEvent{
+			Ref: "refs/heads/main",
+			Commits: []schema.CommitEvent{
+				{SHA: "abc", Files: []schema.FileStatus{{Path: "db/001.sql", Status: "added"}}},
+			},
+		},
+	}
+	ctx := NewContextForEvent(event)
+
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"event.cwd", "/repo"},
+		{"event.hook.type", "postToolUse"},
+		{"event.tool.hook_type", "postToolUse"},
+		{"event.tool.result.type", "failure"},
+		{"event.tool.result.output", "FAIL"},
+		{"event.tool.result.exit_code", int64(2)},
+		{"event.hook.tool.result.type", "failure"},
+		{"event.commit.branch", "main"},
+		{"event.commit.no_verify", true},
+		{"event.commit.amend", false},
+		{"event.push.commits[0].sha", "abc"},
+		{"event.push.commits[0].files[0].path", "db/001.sql"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate(%q) = %v (%T), want %v (%T)", tt.expr, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewContextForNilEvent(t *testing.T) {
+	ctx := NewContextForEvent(nil)
+	if ctx.Event == nil || len(ctx.Event) != 0 {
+		t.Errorf("Expected empty event context, got %v", ctx.Event)
+	}
+	got, err := ctx.Evaluate("event.tool.result.type")
+	if err != nil || got != nil {
+		t.Errorf("Expected nil for missing tool result, got %v (err %v)", got, err)
+	}
+}
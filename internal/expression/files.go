@@ -0,0 +1,242 @@
+package expression
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/htekdev/agentic-ops-cli/internal/glob"
+)
+
+// maxReadFileSize limits readFile and lineCount so a stray pattern can't pull a huge file into memory
+const maxReadFileSize = 1 << 20
+
+// resolvePath resolves a path argument against the context's working directory
+func (ctx *Context) resolvePath(path string) string {
+	if filepath.IsAbs(path) || ctx.WorkingDir == "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(ctx.WorkingDir, path)
+}
+
+// workingPath resolves a path argument that must stay inside the working directory.
+// Absolute paths and paths leading out through ".." are rejected.
+func (ctx *Context) workingPath(p string) (string, error) {
+	rel, err := cleanRelative(filepath.ToSlash(p))
+	if err != nil {
+		return "", err
+	}
+	return ctx.resolvePath(filepath.FromSlash(rel)), nil
+}
+
+// cleanRelative cleans a slash-separated path or pattern, so "./src/**" becomes
+// "src/**", and rejects it if it is absolute or escapes its base directory
+func cleanRelative(p string) (string, error) {
+	cleaned := path.Clean(p)
+	if path.IsAbs(cleaned) || filepath.IsAbs(filepath.FromSlash(p)) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%s is outside the working directory", p)
+	}
+	return cleaned, nil
+}
+
+// builtinHashFiles implements hashFiles(patterns...): a SHA-256 over the files under
+// the working directory matching any pattern. Patterns use trigger glob semantics and
+// a leading '!' excludes matches. Returns an empty string if no files match.
+func builtinHashFiles(ctx *Context, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("hashFiles requires at least 1 argument")
+	}
+
+	var includes, excludes []string
+	for _, arg := range args {
+		pattern := filepath.ToSlash(toString(arg))
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if pattern == "" {
+			continue
+		}
+		pattern, err := cleanRelative(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("hashFiles: pattern %w", err)
+		}
+		if exclude {
+			excludes = append(excludes, pattern)
+		} else {
+			includes = append(includes, pattern)
+		}
+	}
+
+	root := ctx.resolvePath(".")
+	files, err := matchFiles(root, includes, excludes)
+	if err != nil {
+		return nil, fmt.Errorf("hashFiles: %w", err)
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	// Hash of per-file hashes, in path order, so the result is independent of walk order
+	combined := sha256.New()
+	for _, rel := range files {
+		sum, err := hashFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, fmt.Errorf("hashFiles: %w", err)
+		}
+		combined.Write(sum)
+	}
+	return hex.EncodeToString(combined.Sum(nil)), nil
+}
+
+// matchFiles returns the sorted slash-separated paths under root matching any include
+// pattern and no exclude pattern. Patterns must already be cleaned with cleanRelative.
+// The .git directory is never searched.
+func matchFiles(root string, includes, excludes []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, pattern := range includes {
+		base := filepath.Join(root, filepath.FromSlash(globBase(pattern)))
+		err := filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if glob.Match(pattern, rel) {
+				seen[rel] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var files []string
+	for rel := range seen {
+		excluded := false
+		for _, pattern := range excludes {
+			if glob.Match(pattern, rel) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			files = append(files, rel)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// globBase returns the leading directories of a pattern that contain no wildcards,
+// which is where walking for matches can start
+func globBase(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	var base []string
+	for _, part := range parts[:len(parts)-1] {
+		if strings.ContainsAny(part, "*?[") {
+			break
+		}
+		base = append(base, part)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return strings.Join(base, "/")
+}
+
+// hashFile returns the SHA-256 of a file's contents
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// builtinFileExists implements fileExists(path): true if the file or directory exists
+// inside the working directory
+func builtinFileExists(ctx *Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("fileExists requires 1 argument")
+	}
+	path, err := ctx.workingPath(toString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("fileExists: %w", err)
+	}
+	_, err = os.Stat(path)
+	return err == nil, nil
+}
+
+// builtinReadFile implements readFile(path): the file's contents as a string
+func builtinReadFile(ctx *Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("readFile requires 1 argument")
+	}
+	data, err := ctx.readWorkingFile(toString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("readFile: %w", err)
+	}
+	return string(data), nil
+}
+
+// readWorkingFile reads a file inside the working directory, up to maxReadFileSize
+func (ctx *Context) readWorkingFile(p string) ([]byte, error) {
+	path, err := ctx.workingPath(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxReadFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, maxReadFileSize)
+	}
+	return os.ReadFile(path)
+}
+
+// builtinLineCount implements lineCount(path): the number of lines in a file.
+// A final line without a trailing newline still counts.
+func builtinLineCount(ctx *Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("lineCount requires 1 argument")
+	}
+	data, err := ctx.readWorkingFile(toString(args[0]))
+	if err != nil {
+		return nil, fmt.Errorf("lineCount: %w", err)
+	}
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+	return int64(lines), nil
+}
@@ -0,0 +1,178 @@
+package expression
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+// newFilesContext creates a context whose working directory holds a small project
+func newFilesContext(t *testing.T) *Context {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module example\n",
+		"go.sum":                 "example v1.0.0 h1:abc=\n",
+		"main.go":                "package main\n\nfunc main() {}",
+		"internal/util/util.go":  "package util\n",
+		"internal/util/util.txt": "notes\n",
+		".git/config":            "[core]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewContextForEvent(&schema.Event{Cwd: dir})
+}
+
+// TestFileFunctions tests fileExists, readFile and lineCount
+func TestFileFunctions(t *testing.T) {
+	ctx := newFilesContext(t)
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		{"fileExists file", "fileExists('go.mod')", true},
+		{"fileExists directory", "fileExists('internal/util')", true},
+		{"fileExists missing", "fileExists('Makefile')", false},
+		{"readFile", "readFile('go.mod')", "module example\n"},
+		{"readFile in condition", "contains(readFile('go.mod'), 'module example')", true},
+		{"lineCount with trailing newline", "lineCount('go.sum')", int64(1)},
+		{"lineCount without trailing newline", "lineCount('main.go')", int64(3)},
+		{"lineCount in arithmetic", "lineCount('main.go') * 2 > 5", true},
+		{"readFile with leading dot", "readFile('./go.mod')", "module example\n"},
+		{"readFile with inner dot-dot", "readFile('internal/../go.mod')", "module example\n"},
+		{"fileExists with inner dot-dot", "fileExists('internal/../go.mod')", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLineCountSizeLimit tests that lineCount refuses files larger than readFile would
+func TestLineCountSizeLimit(t *testing.T) {
+	ctx := newFilesContext(t)
+	big := bytes.Repeat([]byte("line\n"), maxReadFileSize/5+1)
+	if err := os.WriteFile(filepath.Join(ctx.WorkingDir, "big.txt"), big, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expr := range []string{"lineCount('big.txt')", "readFile('big.txt')"} {
+		_, err := ctx.Evaluate(expr)
+		if err == nil || !strings.Contains(err.Error(), "larger than") {
+			t.Errorf("Evaluate(%q) error = %v, want size limit error", expr, err)
+		}
+	}
+}
+
+// TestHashFiles tests hashFiles pattern matching and stability
+func TestHashFiles(t *testing.T) {
+	ctx := newFilesContext(t)
+
+	hash := func(expr string) string {
+		t.Helper()
+		got, err := ctx.Evaluate(expr)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) error = %v", expr, err)
+		}
+		return got.(string)
+	}
+
+	goSum := hash("hashFiles('go.sum')")
+	if len(goSum) != 64 {
+		t.Fatalf("Expected a SHA-256 hex digest, got %q", goSum)
+	}
+	if again := hash("hashFiles('go.sum')"); again != goSum {
+		t.Errorf("Expected stable hash, got %q and %q", goSum, again)
+	}
+
+	allGo := hash("hashFiles('**/*.go')")
+	if allGo == goSum || allGo == "" {
+		t.Errorf("Expected a different hash for Go sources, got %q", allGo)
+	}
+	if got := hash("hashFiles('main.go', 'internal/**/*.go')"); got != allGo {
+		t.Errorf("Expected equivalent pattern sets to hash equally, got %q and %q", got, allGo)
+	}
+	if got := hash("hashFiles('**/*.go', '!internal/**')"); got != hash("hashFiles('main.go')") {
+		t.Errorf("Expected exclusion to drop internal files, got %q", got)
+	}
+	if got := hash("hashFiles('./main.go', './internal/**/*.go')"); got != allGo {
+		t.Errorf("Expected leading ./ to be ignored, got %q and %q", got, allGo)
+	}
+	if got := hash("hashFiles('**/*.go', '!./internal/**')"); got != hash("hashFiles('main.go')") {
+		t.Errorf("Expected exclusion with leading ./ to drop internal files, got %q", got)
+	}
+	if got := hash("hashFiles('**/config')"); got != "" {
+		t.Errorf("Expected .git to be skipped, got %q", got)
+	}
+	if got := hash("hashFiles('*.rb')"); got != "" {
+		t.Errorf("Expected empty hash with no matches, got %q", got)
+	}
+
+	// Changing a file changes the hash
+	if err := os.WriteFile(filepath.Join(ctx.WorkingDir, "go.sum"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash("hashFiles('go.sum')"); got == goSum {
+		t.Error("Expected hash to change after the file changed")
+	}
+}
+
+// TestFileFunctionErrors tests error cases of the file functions
+func TestFileFunctionErrors(t *testing.T) {
+	ctx := newFilesContext(t)
+
+	tests := []struct {
+		name   string
+		expr   string
+		errMsg string
+	}{
+		{"hashFiles no args", "hashFiles()", "requires at least 1 argument"},
+		{"fileExists no args", "fileExists()", "requires 1 argument"},
+		{"readFile missing", "readFile('missing.txt')", "readFile"},
+		{"readFile directory", "readFile('internal')", "is a directory"},
+		{"lineCount missing", "lineCount('missing.txt')", "lineCount"},
+		{"hashFiles parent", "hashFiles('../**')", "outside the working directory"},
+		{"hashFiles absolute", "hashFiles('/etc/*')", "outside the working directory"},
+		{"hashFiles excluded parent", "hashFiles('**', '!../x')", "outside the working directory"},
+		{"readFile parent", "readFile('../go.mod')", "outside the working directory"},
+		{"readFile nested parent", "readFile('internal/../../go.mod')", "outside the working directory"},
+		{"readFile absolute", "readFile('/etc/hostname')", "outside the working directory"},
+		{"lineCount parent", "lineCount('../go.mod')", "outside the working directory"},
+		{"lineCount absolute", "lineCount('/etc/hostname')", "outside the working directory"},
+		{"fileExists parent", "fileExists('../go.mod')", "outside the working directory"},
+		{"fileExists absolute", "fileExists('/etc/hostname')", "outside the working directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctx.Evaluate(tt.expr)
+			if err == nil {
+				t.Fatalf("Evaluate(%q) expected error", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error message = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
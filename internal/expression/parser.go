@@ -13,6 +13,7 @@ var ExpressionPattern = regexp.MustCompile(`\$\{\{\s*(.*?)\s*\}\}`)
 type Token struct {
 	Type  TokenType
 	Value string
+	Pos   int // Offset of the token in the expression, in runes
 }
 
 // TokenType identifies the type of token
@@ -128,27 +129,27 @@ func tokenize(expr string) ([]Token, error) {
 		// Single-character tokens
 		switch ch {
 		case '(':
-			tokens = append(tokens, Token{Type: TokenLeftParen, Value: "("})
+			tokens = append(tokens, Token{Type: TokenLeftParen, Value: "(", Pos: i})
 			i++
 			continue
 		case ')':
-			tokens = append(tokens, Token{Type: TokenRightParen, Value: ")"})
+			tokens = append(tokens, Token{Type: TokenRightParen, Value: ")", Pos: i})
 			i++
 			continue
 		case '[':
-			tokens = append(tokens, Token{Type: TokenLeftBracket, Value: "["})
+			tokens = append(tokens, Token{Type: TokenLeftBracket, Value: "[", Pos: i})
 			i++
 			continue
 		case ']':
-			tokens = append(tokens, Token{Type: TokenRightBracket, Value: "]"})
+			tokens = append(tokens, Token{Type: TokenRightBracket, Value: "]", Pos: i})
 			i++
 			continue
 		case '.':
-			tokens = append(tokens, Token{Type: TokenDot, Value: "."})
+			tokens = append(tokens, Token{Type: TokenDot, Value: ".", Pos: i})
 			i++
 			continue
 		case ',':
-			tokens = append(tokens, Token{Type: TokenComma, Value: ","})
+			tokens = append(tokens, Token{Type: TokenComma, Value: ",", Pos: i})
 			i++
 			continue
 		}
@@ -157,7 +158,7 @@ func tokenize(expr string) ([]Token, error) {
 		if isOperatorStart(ch) {
 			op, length := readOperator(runes[i:])
 			if op != "" {
-				tokens = append(tokens, Token{Type: TokenOperator, Value: op})
+				tokens = append(tokens, Token{Type: TokenOperator, Value: op, Pos: i})
 				i += length
 				continue
 			}
@@ -167,9 +168,9 @@ func tokenize(expr string) ([]Token, error) {
 		if ch == '\'' {
 			str, length, err := readString(runes[i:])
 			if err != nil {
-				return nil, err
+				return nil, &SyntaxError{Expr: expr, Column: i + 1, Msg: err.Error()}
 			}
-			tokens = append(tokens, Token{Type: TokenString, Value: str})
+			tokens = append(tokens, Token{Type: TokenString, Value: str, Pos: i})
 			i += length
 			continue
 		}
@@ -177,7 +178,7 @@ func tokenize(expr string) ([]Token, error) {
 		// Numbers
 		if isDigit(ch) || (ch == '-' && i+1 < len(runes) && isDigit(runes[i+1])) {
 			num, length := readNumber(runes[i:])
-			tokens = append(tokens, Token{Type: TokenNumber, Value: num})
+			tokens = append(tokens, Token{Type: TokenNumber, Value: num, Pos: i})
 			i += length
 			continue
 		}
@@ -185,15 +186,15 @@ func tokenize(expr string) ([]Token, error) {
 		// Identifiers
 		if isIdentifierStart(ch) {
 			ident, length := readIdentifier(runes[i:])
-			tokens = append(tokens, Token{Type: TokenIdentifier, Value: ident})
+			tokens = append(tokens, Token{Type: TokenIdentifier, Value: ident, Pos: i})
 			i += length
 			continue
 		}
 
-		return nil, fmt.Errorf("unexpected character '%c' at position %d", ch, i)
+		return nil, &SyntaxError{Expr: expr, Column: i + 1, Msg: fmt.Sprintf("unexpected character '%c'", ch)}
 	}
 
-	tokens = append(tokens, Token{Type: TokenEOF, Value: ""})
+	tokens = append(tokens, Token{Type: TokenEOF, Value: "", Pos: len(runes)})
 	return tokens, nil
 }
 
@@ -304,6 +305,9 @@ func isIdentifierStart(ch rune) bool {
 	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '_'
 }
 
+// isIdentifierChar allows '-' inside identifiers so hyphenated step ids and keys can be
+// referenced directly. A '-' directly after an identifier therefore continues it, so
+// subtraction needs a space before the operator: steps.x.outputs.n - 1, not n-1.
 func isIdentifierChar(ch rune) bool {
 	return isIdentifierStart(ch) || isDigit(ch) || ch == '-'
 }
@@ -0,0 +1,114 @@
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/htekdev/agentic-ops-cli/internal/glob"
+)
+
+// maxCachedRegexps bounds the compiled-regex cache; it is reset when full
+const maxCachedRegexps = 256
+
+// regexCache holds compiled patterns so hooks evaluating the same policy
+// expressions repeatedly only compile each pattern once
+var regexCache = struct {
+	sync.Mutex
+	patterns map[string]*regexp.Regexp
+}{patterns: make(map[string]*regexp.Regexp)}
+
+// compileRegex returns a compiled regular expression, using the cache when possible
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if re, ok := regexCache.patterns[pattern]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression '%s': %w", pattern, err)
+	}
+	if len(regexCache.patterns) >= maxCachedRegexps {
+		regexCache.patterns = make(map[string]*regexp.Regexp)
+	}
+	regexCache.patterns[pattern] = re
+	return re, nil
+}
+
+// builtinMatches implements matches(str, regex): true if the regex matches anywhere in str
+func builtinMatches(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("matches requires 2 arguments")
+	}
+	re, err := compileRegex(toString(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(toString(args[0])), nil
+}
+
+// builtinRegexReplace implements regexReplace(str, regex, replacement).
+// The replacement may reference capture groups as $1 or ${name}.
+func builtinRegexReplace(args ...interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("regexReplace requires 3 arguments")
+	}
+	re, err := compileRegex(toString(args[1]))
+	if err != nil {
+		return nil, err
+	}
+	return re.ReplaceAllString(toString(args[0]), toString(args[2])), nil
+}
+
+// builtinRegexCapture implements regexCapture(str, regex[, group]).
+// Without a group it returns the first capture group, or the whole match if the
+// regex has no groups. The group may be an index or a named group. Returns null
+// when the regex does not match.
+func builtinRegexCapture(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("regexCapture requires 2 or 3 arguments")
+	}
+	re, err := compileRegex(toString(args[1]))
+	if err != nil {
+		return nil, err
+	}
+
+	match := re.FindStringSubmatch(toString(args[0]))
+	if match == nil {
+		return nil, nil
+	}
+
+	if len(args) == 2 {
+		if len(match) > 1 {
+			return match[1], nil
+		}
+		return match[0], nil
+	}
+
+	var index int
+	switch group := args[2].(type) {
+	case string:
+		index = re.SubexpIndex(group)
+		if index < 0 {
+			return nil, fmt.Errorf("regexCapture: no group named '%s'", group)
+		}
+	default:
+		index = int(toNumber(group))
+		if index < 0 || index >= len(match) {
+			return nil, fmt.Errorf("regexCapture: group %d out of range (regex has %d groups)", index, len(match)-1)
+		}
+	}
+	return match[index], nil
+}
+
+// builtinGlob implements glob(path, pattern) using the same "**"-aware
+// semantics as trigger path filters
+func builtinGlob(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("glob requires 2 arguments")
+	}
+	return glob.Match(toString(args[1]), toString(args[0])), nil
+}
@@ -0,0 +1,103 @@
+package expression
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPatternFunctions tests matches, regexReplace, regexCapture and glob
+func TestPatternFunctions(t *testing.T) {
+	ctx := NewContext()
+	ctx.Event = map[string]interface{}{
+		"tool": map[string]interface{}{
+			"args": map[string]interface{}{"command": "git push --force origin main"},
+		},
+		"file":   map[string]interface{}{"path": "internal/runner/runner_test.go"},
+		"commit": map[string]interface{}{"message": "feat(runner): add outputs\n\nCloses #42"},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+	}{
+		// matches
+		{"matches command", "matches(event.tool.args.command, '^git push.*--force')", true},
+		{"matches no match", "matches(event.tool.args.command, '^git commit')", false},
+		{"matches is case sensitive", "matches('ABC', 'abc')", false},
+		{"matches case insensitive flag", "matches('ABC', '(?i)abc')", true},
+		{"matches conventional commit", "matches(event.commit.message, '^(feat|fix)(\\(\\w+\\))?: ')", true},
+		// regexReplace
+		{"regexReplace", "regexReplace('a1b22c333', '[0-9]+', '#')", "a#b#c#"},
+		{"regexReplace with groups", "regexReplace('main.go', '^(\\w+)\\.go$', '${1}_test.go')", "main_test.go"},
+		// regexCapture
+		{"regexCapture first group", "regexCapture(event.commit.message, '^(\\w+)\\(')", "feat"},
+		{"regexCapture whole match", "regexCapture(event.commit.message, '#[0-9]+')", "#42"},
+		{"regexCapture index", "regexCapture(event.commit.message, '^(\\w+)\\((\\w+)\\)', 2)", "runner"},
+		{"regexCapture named", "regexCapture(event.commit.message, 'Closes #(?P<issue>[0-9]+)', 'issue')", "42"},
+		{"regexCapture no match", "regexCapture('abc', '[0-9]+')", nil},
+		// glob
+		{"glob double star", "glob(event.file.path, '**/*_test.go')", true},
+		{"glob prefix", "glob(event.file.path, 'internal/**')", true},
+		{"glob no match", "glob(event.file.path, 'cmd/**')", false},
+		{"glob single star", "glob('README.md', '*.md')", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctx.Evaluate(tt.expr)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPatternFunctionErrors tests argument and pattern errors
+func TestPatternFunctionErrors(t *testing.T) {
+	ctx := NewContext()
+
+	tests := []struct {
+		name   string
+		expr   string
+		errMsg string
+	}{
+		{"matches wrong args", "matches('a')", "requires 2 arguments"},
+		{"matches invalid regex", "matches('a', '(')", "invalid regular expression"},
+		{"regexReplace wrong args", "regexReplace('a', 'b')", "requires 3 arguments"},
+		{"regexCapture wrong args", "regexCapture('a')", "requires 2 or 3 arguments"},
+		{"regexCapture unknown group", "regexCapture('a', '(a)', 'name')", "no group named"},
+		{"regexCapture group out of range", "regexCapture('a', '(a)', 2)", "out of range"},
+		{"glob wrong args", "glob('a')", "requires 2 arguments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ctx.Evaluate(tt.expr)
+			if err == nil {
+				t.Fatalf("Evaluate(%q) expected error", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error message = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+// TestCompileRegexCache tests that compiled patterns are reused
+func TestCompileRegexCache(t *testing.T) {
+	first, err := compileRegex(`^cache-test-\d+$`)
+	if err != nil {
+		t.Fatalf("compileRegex() error = %v", err)
+	}
+	second, err := compileRegex(`^cache-test-\d+$`)
+	if err != nil {
+		t.Fatalf("compileRegex() error = %v", err)
+	}
+	if first != second {
+		t.Error("Expected the cached regex to be reused")
+	}
+}
@@ -0,0 +1,193 @@
+package expression
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+// WorkflowExpression is an expression found in a workflow definition
+type WorkflowExpression struct {
+	Field string // Where the expression appears, e.g. "steps.0.if"
+	Expr  string // The expression source, without the ${{ }} wrapper
+	Step  int    // Index of the step the expression belongs to, or -1
+}
+
+// WorkflowExpressions returns every expression in a workflow. Conditions (if:) may be
+// written with or without ${{ }}; everywhere else only ${{ }} expressions are evaluated.
+func WorkflowExpressions(wf *schema.Workflow) []WorkflowExpression {
+	var exprs []WorkflowExpression
+
+	addCondition := func(step int, field, cond string) {
+		if cond == "" {
+			return
+		}
+		// EvaluateBool only evaluates the first ${{ }} expression of a condition
+		if ContainsExpression(cond) {
+			if inner := ExtractExpressions(cond); len(inner) > 0 {
+				exprs = append(exprs, WorkflowExpression{Field: field, Expr: inner[0], Step: step})
+			}
+			return
+		}
+		exprs = append(exprs, WorkflowExpression{Field: field, Expr: cond, Step: step})
+	}
+	addTemplate := func(step int, field, text string) {
+		for _, inner := range ExtractExpressions(text) {
+			exprs = append(exprs, WorkflowExpression{Field: field, Expr: inner, Step: step})
+		}
+	}
+	addMap := func(step int, prefix string, m map[string]string) {
+		for _, k := range sortedKeys(m) {
+			addTemplate(step, fmt.Sprintf("%s.%s", prefix, k), m[k])
+		}
+	}
+
+	if wf.Concurrency != nil {
+		addTemplate(-1, "concurrency.group", wf.Concurrency.Group)
+	}
+	if wf.On.Tool != nil {
+		addCondition(-1, "on.tool.if", wf.On.Tool.If)
+	}
+	for i, tool := range wf.On.Tools {
+		addCondition(-1, fmt.Sprintf("on.tools.%d.if", i), tool.If)
+	}
+	addMap(-1, "env", wf.Env)
+
+	for i, step := range wf.Steps {
+		prefix := fmt.Sprintf("steps.%d", i)
+		addCondition(i, prefix+".if", step.If)
+		addTemplate(i, prefix+".run", step.Run)
+		addTemplate(i, prefix+".working-directory", step.WorkingDirectory)
+		addMap(i, prefix+".with", step.With)
+		addMap(i, prefix+".env", step.Env)
+	}
+
+	return exprs
+}
+
+// CompileWorkflow compiles every expression in a workflow, so evaluating the
+// workflow later reuses the cached programs. It returns the first syntax error,
+// prefixed with the field it was found in.
+func CompileWorkflow(wf *schema.Workflow) error {
+	for _, e := range WorkflowExpressions(wf) {
+		if _, err := Compile(e.Expr); err != nil {
+			return fmt.Errorf("%s: %w", e.Field, err)
+		}
+	}
+	return nil
+}
+
+// CheckWorkflow statically checks every expression in a workflow and returns a
+// description of each problem, naming the field and step it was found in and
+// pointing at the offending part of the expression.
+// It has the signature of a schema.WorkflowCheck.
+func CheckWorkflow(wf *schema.Workflow) []string {
+	var details []string
+	for _, e := range WorkflowExpressions(wf) {
+		location := expressionLocation(wf, e)
+
+		program, err := Compile(e.Expr)
+		if err != nil {
+			// Syntax errors already point at the problem in the expression
+			details = append(details, fmt.Sprintf("%s: %v", location, err))
+			continue
+		}
+		for _, checkErr := range program.Check(workflowScope(wf, e)) {
+			details = append(details, fmt.Sprintf("%s: %v%s", location, checkErr, pointer(e.Expr, checkErr.Column)))
+		}
+	}
+	return append(details, SecretLeaks(wf)...)
+}
+
+// SecretLeaks reports expressions that put secrets where they can be echoed into
+// the permission decision reason: step commands and working directories, whose
+// output and errors are quoted when a step fails, and concurrency groups, which
+// are named in timeout messages. Secrets should reach steps through env or with.
+func SecretLeaks(wf *schema.Workflow) []string {
+	var details []string
+	for _, e := range WorkflowExpressions(wf) {
+		if !exposesValue(e.Field) {
+			continue
+		}
+		program, err := Compile(e.Expr)
+		if err != nil || !program.UsesContext("secrets") {
+			continue
+		}
+		location := expressionLocation(wf, e)
+		details = append(details, fmt.Sprintf("%s: secrets may be echoed into the permission decision reason here, pass them through env instead, in '${{ %s }}'", location, e.Expr))
+	}
+	return details
+}
+
+// WorkflowUsesContext reports whether any expression in the workflow refers to a
+// top-level context. Expressions that do not compile are ignored.
+func WorkflowUsesContext(wf *schema.Workflow, name string) bool {
+	for _, e := range WorkflowExpressions(wf) {
+		if program, err := Compile(e.Expr); err == nil && program.UsesContext(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// exposesValue reports whether the evaluated value of a field can appear in results
+func exposesValue(field string) bool {
+	return field == "concurrency.group" ||
+		strings.HasSuffix(field, ".run") ||
+		strings.HasSuffix(field, ".working-directory")
+}
+
+// expressionLocation describes where an expression is, naming its step if it has one
+func expressionLocation(wf *schema.Workflow, e WorkflowExpression) string {
+	if e.Step < 0 {
+		return e.Field
+	}
+	return fmt.Sprintf("%s (step '%s')", e.Field, stepName(wf.Steps[e.Step], e.Step))
+}
+
+// workflowScope returns the steps an expression can refer to. Steps see the steps
+// before them; workflow env is evaluated for every step so it may use any step.
+// Triggers and concurrency groups are evaluated before any step runs.
+func workflowScope(wf *schema.Workflow, e WorkflowExpression) *CheckScope {
+	last := e.Step
+	switch {
+	case e.Step < 0 && strings.HasPrefix(e.Field, "env."):
+		last = len(wf.Steps)
+	case e.Step < 0:
+		last = 0
+	}
+
+	steps := make(map[string]bool)
+	for i := 0; i < last; i++ {
+		steps[stepKey(wf.Steps[i], i)] = true
+	}
+	return &CheckScope{Steps: steps}
+}
+
+// stepName returns the display name of a step, as the runner reports it
+func stepName(step schema.Step, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+	return fmt.Sprintf("Step %d", index+1)
+}
+
+// stepKey returns the key of a step in the steps context, as the runner sets it
+func stepKey(step schema.Step, index int) string {
+	if step.ID != "" {
+		return step.ID
+	}
+	return stepName(step, index)
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+// Package glob implements the path patterns used by workflow triggers and expressions.
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether path matches a glob pattern.
+// Besides the filepath.Match syntax, "**" matches across directories.
+func Match(pattern, path string) bool {
+	// Normalize path separators
+	pattern = filepath.ToSlash(pattern)
+	path = filepath.ToSlash(path)
+
+	// Handle ** patterns
+	if strings.Contains(pattern, "**") {
+		return matchDoubleGlob(pattern, path)
+	}
+
+	// Use filepath.Match for simple patterns
+	matched, _ := filepath.Match(pattern, path)
+	return matched
+}
+
+// matchDoubleGlob handles ** patterns that match across directories
+func matchDoubleGlob(pattern, path string) bool {
+	parts := strings.Split(pattern, "**")
+	if len(parts) == 1 {
+		matched, _ := filepath.Match(pattern, path)
+		return matched
+	}
+
+	// For patterns like **/*.js
+	if parts[0] == "" {
+		suffix := strings.TrimPrefix(parts[1], "/")
+		// Match suffix against any path segment
+		pathParts := strings.Split(path, "/")
+		for i := range pathParts {
+			subpath := strings.Join(pathParts[i:], "/")
+			if matched, _ := filepath.Match(suffix, subpath); matched {
+				return true
+			}
+		}
+		// Also try matching just the filename
+		if matched, _ := filepath.Match(suffix, filepath.Base(path)); matched {
+			return true
+		}
+		return false
+	}
+
+	// For patterns like src/**/test.js
+	prefix := strings.TrimSuffix(parts[0], "/")
+	suffix := strings.TrimPrefix(parts[1], "/")
+
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+
+	remaining := strings.TrimPrefix(path, prefix)
+	remaining = strings.TrimPrefix(remaining, "/")
+
+	if suffix == "" {
+		return true
+	}
+
+	// Match suffix against remaining path
+	pathParts := strings.Split(remaining, "/")
+	for i := range pathParts {
+		subpath := strings.Join(pathParts[i:], "/")
+		if matched, _ := filepath.Match(suffix, subpath); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchBelow reports whether pattern could match dir or a path below it. dir may
+// hold glob characters itself, like the * of `rm -rf *`, and stands for every path
+// it matches. When both sides of a path segment are globs they are taken to
+// overlap, so the answer errs towards a match.
+func MatchBelow(pattern, dir string) bool {
+	dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")
+	if dir == "." || dir == "" {
+		// Every path is below the current directory
+		return true
+	}
+	patternParts := strings.Split(strings.Trim(filepath.ToSlash(pattern), "/"), "/")
+	return matchBelow(patternParts, strings.Split(dir, "/"))
+}
+
+// matchBelow matches pattern segments against the segments of a directory, where
+// running out of directory segments means the rest of the pattern may match below
+func matchBelow(pattern, dir []string) bool {
+	if len(dir) == 0 {
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if strings.Contains(pattern[0], "**") {
+		return matchBelow(pattern[1:], dir) || matchBelow(pattern, dir[1:])
+	}
+	return segmentsOverlap(pattern[0], dir[0]) && matchBelow(pattern[1:], dir[1:])
+}
+
+// segmentsOverlap reports whether two path segments, either of which may be a
+// glob, could name the same file
+func segmentsOverlap(a, b string) bool {
+	aGlob, bGlob := strings.ContainsAny(a, "*?["), strings.ContainsAny(b, "*?[")
+	switch {
+	case aGlob && bGlob:
+		return true
+	case aGlob:
+		matched, _ := filepath.Match(a, b)
+		return matched
+	case bGlob:
+		matched, _ := filepath.Match(b, a)
+		return matched
+	}
+	return a == b
+}
@@ -0,0 +1,61 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "cmd/agentic-ops/main.go", true},
+		{"**/*.go", "main.go", true},
+		{"internal/**", "internal/glob/glob.go", true},
+		{"internal/**/*_test.go", "internal/glob/glob_test.go", true},
+		{"internal/**/*_test.go", "internal/glob/glob.go", false},
+		{"docs/*.md", "docs/guide.md", true},
+		{"docs/*.md", "docs/api/guide.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.path, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.path); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchBelow(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{"config/secrets/**", "config", true},
+		{"config/secrets/**", "config/secrets", true},
+		{"config/secrets/**", "config/secrets/keys", true},
+		{"config/secrets/**", "docs", false},
+		{"config/*.yml", "config", true},
+		{"config/*.yml", "config/nested", false},
+		{"**/*.pem", "anything/below", true},
+		{"*.md", "docs", false},
+		{"config/secrets/**", "*", true},
+		{"config/secrets/**", "c*/s*", true},
+		{"config/secrets/**", "big/*.js", false},
+		{"src/*.go", "s*/*.js", true}, // Two globs are taken to overlap
+		{"src/*.go", "src/*", true},
+		{"README.md", ".", true},
+		{"README.md", "./docs/..", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.dir, func(t *testing.T) {
+			if got := MatchBelow(tt.pattern, tt.dir); got != tt.want {
+				t.Errorf("MatchBelow(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// OutputEnvVar is the environment variable pointing at a step's output file
+	OutputEnvVar = "AGENTIC_OPS_OUTPUT"
+
+	// githubOutputEnvVar is also set so scripts written for GitHub Actions work unchanged
+	githubOutputEnvVar = "GITHUB_OUTPUT"
+)
+
+// createOutputFile creates an empty temp file a step can write outputs to
+func createOutputFile() (string, error) {
+	f, err := os.CreateTemp("", "agentic-ops-output-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	path := f.Name()
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	return path, nil
+}
+
+// readOutputFile reads and parses a step output file
+func readOutputFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+	return parseOutputs(string(data))
+}
+
+// parseOutputs parses GITHUB_OUTPUT-style content.
+// Supports single-line "name=value" entries and multiline heredoc entries:
+//
+//	name<<DELIMITER
+//	line 1
+//	line 2
+//	DELIMITER
+func parseOutputs(content string) (map[string]string, error) {
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		eqIdx := strings.Index(line, "=")
+		hdIdx := strings.Index(line, "<<")
+
+		// Heredoc form: the "<<" must come before any "="
+		if hdIdx > 0 && (eqIdx < 0 || hdIdx < eqIdx) {
+			name := strings.TrimSpace(line[:hdIdx])
+			delimiter := strings.TrimSpace(line[hdIdx+2:])
+			if delimiter == "" {
+				return nil, fmt.Errorf("line %d: missing heredoc delimiter for output '%s'", lineNum, name)
+			}
+
+			var valueLines []string
+			terminated := false
+			for scanner.Scan() {
+				lineNum++
+				l := strings.TrimSuffix(scanner.Text(), "\r")
+				if l == delimiter {
+					terminated = true
+					break
+				}
+				valueLines = append(valueLines, l)
+			}
+			if !terminated {
+				return nil, fmt.Errorf("line %d: unterminated heredoc for output '%s' (expected '%s')", lineNum, name, delimiter)
+			}
+			outputs[name] = strings.Join(valueLines, "\n")
+			continue
+		}
+
+		if eqIdx <= 0 {
+			return nil, fmt.Errorf("line %d: invalid output format (expected name=value or name<<DELIMITER)", lineNum)
+		}
+		outputs[strings.TrimSpace(line[:eqIdx])] = line[eqIdx+1:]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,220 @@
+package runner
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/htekdev/agentic-ops-cli/internal/schema"
+)
+
+// TestParseOutputs tests parsing of GITHUB_OUTPUT-style content
+func TestParseOutputs(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:     "empty content",
+			content:  "",
+			expected: map[string]string{},
+		},
+		{
+			name:     "single value",
+			content:  "count=3\n",
+			expected: map[string]string{"count": "3"},
+		},
+		{
+			name:     "value containing equals",
+			content:  "query=a=b\n",
+			expected: map[string]string{"query": "a=b"},
+		},
+		{
+			name:     "empty value",
+			content:  "empty=\n",
+			expected: map[string]string{"empty": ""},
+		},
+		{
+			name:     "later value overrides earlier",
+			content:  "x=1\nx=2\n",
+			expected: map[string]string{"x": "2"},
+		},
+		{
+			name:     "heredoc value",
+			content:  "files<<EOF\na.go\nb.go\nEOF\nnext=ok\n",
+			expected: map[string]string{"files": "a.go\nb.go", "next": "ok"},
+		},
+		{
+			name:     "windows line endings",
+			content:  "a=1\r\nb<<END\r\nx\r\nEND\r\n",
+			expected: map[string]string{"a": "1", "b": "x"},
+		},
+		{
+			name:      "unterminated heredoc",
+			content:   "files<<EOF\na.go\n",
+			expectErr: true,
+		},
+		{
+			name:      "missing delimiter",
+			content:   "files<<\n",
+			expectErr: true,
+		},
+		{
+			name:      "line without separator",
+			content:   "garbage\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := parseOutputs(tt.content)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseOutputs() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(outputs) != len(tt.expected) {
+				t.Fatalf("Expected %d outputs, got %d: %v", len(tt.expected), len(outputs), outputs)
+			}
+			for k, v := range tt.expected {
+				if outputs[k] != v {
+					t.Errorf("Expected output %s=%q, got %q", k, v, outputs[k])
+				}
+			}
+		})
+	}
+}
+
+// TestStepOutputsAvailableToLaterSteps tests that outputs flow into later steps
+func TestStepOutputsAvailableToLaterSteps(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-outputs",
+		Steps: []schema.Step{
+			{
+				Name:  "produce",
+				Shell: "bash",
+				Run:   "echo \"count=3\" >> \"$AGENTIC_OPS_OUTPUT\"; printf 'files<<EOF\\na.go\\nb.go\\nEOF\\n' >> \"$GITHUB_OUTPUT\"",
+			},
+			{
+				Name:  "consume",
+				Shell: "bash",
+				If:    "steps.produce.outputs.count == '3'",
+				Env:   map[string]string{"FILES": "${{ steps.produce.outputs.files }}"},
+				Run:   "echo \"count=${{ steps.produce.outputs.count }}\"; echo \"$FILES\"",
+			},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	if results[0].Outputs["count"] != "3" {
+		t.Errorf("Expected count output '3', got %q", results[0].Outputs["count"])
+	}
+	if results[0].Outputs["files"] != "a.go\nb.go" {
+		t.Errorf("Expected multiline files output, got %q", results[0].Outputs["files"])
+	}
+
+	if !results[1].Success {
+		t.Fatalf("Consumer step should succeed, got error: %v", results[1].Error)
+	}
+	if !strings.Contains(results[1].Output, "count=3") {
+		t.Errorf("Expected output to contain 'count=3', got: %s", results[1].Output)
+	}
+	if !strings.Contains(results[1].Output, "a.go\nb.go") {
+		t.Errorf("Expected output to contain file list, got: %s", results[1].Output)
+	}
+}
+
+// TestStepInvalidOutputsFailsStep tests that a malformed output file fails the step
+func TestStepInvalidOutputsFailsStep(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-invalid-outputs",
+		Steps: []schema.Step{
+			{
+				Name:  "bad-output",
+				Shell: "bash",
+				Run:   "echo 'not-an-output' >> \"$AGENTIC_OPS_OUTPUT\"",
+			},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	results, _ := runner.Run(context.Background())
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if results[0].Success {
+		t.Error("Expected step with invalid outputs to fail")
+	}
+	if results[0].Error == nil || !strings.Contains(results[0].Error.Error(), "invalid step outputs") {
+		t.Errorf("Expected invalid step outputs error, got: %v", results[0].Error)
+	}
+}
+
+// TestStepsContextKeyedByID tests that steps with an id are referenced by id, not name
+func TestStepsContextKeyedByID(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-step-ids",
+		Steps: []schema.Step{
+			{
+				ID:    "count",
+				Name:  "Count files (v1.2)",
+				Shell: "bash",
+				Run:   "echo \"n=7\" >> \"$AGENTIC_OPS_OUTPUT\"",
+			},
+			{
+				Name:  "by id",
+				Shell: "bash",
+				Run:   "echo \"id=${{ steps.count.outputs.n }}\"",
+			},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, ".")
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if results[0].Name != "Count files (v1.2)" {
+		t.Errorf("Expected result name to remain the display name, got %q", results[0].Name)
+	}
+	if !strings.Contains(results[1].Output, "id=7") {
+		t.Errorf("Expected output to contain 'id=7', got: %s", results[1].Output)
+	}
+	if _, ok := runner.exprCtx.Steps["count"]; !ok {
+		t.Error("Expected step to be registered under its id")
+	}
+	if _, ok := runner.exprCtx.Steps["Count files (v1.2)"]; ok {
+		t.Error("Expected step with id not to be registered under its name")
+	}
+	if _, ok := runner.exprCtx.Steps["by id"]; !ok {
+		t.Error("Expected step without id to be registered under its name")
+	}
+}
@@ -3,16 +3,20 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/htekdev/agentic-ops-cli/internal/expression"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
+	"github.com/htekdev/agentic-ops-cli/internal/secrets"
 )
 
 // Runner executes workflow steps
@@ -22,6 +26,7 @@ type Runner struct {
 	exprCtx    *expression.Context
 	workingDir string
 	env        map[string]string
+	masker     *secrets.Masker // Hides secret values in results and logs
 }
 
 // StepResult contains the result of running a step
@@ -29,69 +34,14 @@ type StepResult struct {
 	Name     string
 	Success  bool
 	Output   string
+	Outputs  map[string]string // Values written to the step's output file
 	Error    error
 	Duration time.Duration
 }
 
 // NewRunner creates a new step runner
 func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string) *Runner {
-	exprCtx := expression.NewContext()
-
-	// Populate event context
-	if event != nil {
-		exprCtx.Event["cwd"] = event.Cwd
-		exprCtx.Event["timestamp"] = event.Timestamp
-
-		if event.Hook != nil {
-			exprCtx.Event["hook"] = map[string]interface{}{
-				"type": event.Hook.Type,
-				"cwd":  event.Hook.Cwd,
-			}
-			if event.Hook.Tool != nil {
-				exprCtx.Event["hook"].(map[string]interface{})["tool"] = map[string]interface{}{
-					"name": event.Hook.Tool.Name,
-					"args": event.Hook.Tool.Args,
-				}
-			}
-		}
-
-		if event.Tool != nil {
-			exprCtx.Event["tool"] = map[string]interface{}{
-				"name":      event.Tool.Name,
-				"args":      event.Tool.Args,
-				"hook_type": event.Tool.HookType,
-			}
-		}
-
-		if event.File != nil {
-			exprCtx.Event["file"] = map[string]interface{}{
-				"path":    event.File.Path,
-				"action":  event.File.Action,
-				"content": event.File.Content,
-			}
-		}
-
-		if event.Commit != nil {
-			files := make([]map[string]string, len(event.Commit.Files))
-			for i, f := range event.Commit.Files {
-				files[i] = map[string]string{"path": f.Path, "status": f.Status}
-			}
-			exprCtx.Event["commit"] = map[string]interface{}{
-				"sha":     event.Commit.SHA,
-				"message": event.Commit.Message,
-				"author":  event.Commit.Author,
-				"files":   files,
-			}
-		}
-
-		if event.Push != nil {
-			exprCtx.Event["push"] = map[string]interface{}{
-				"ref":    event.Push.Ref,
-				"before": event.Push.Before,
-				"after":  event.Push.After,
-			}
-		}
-	}
+	exprCtx := expression.NewContextForEvent(event)
 
 	// Merge workflow env with event env
 	env := make(map[string]string)
@@ -99,6 +49,8 @@ func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string
 		env[k] = v
 	}
 	exprCtx.Env = env
+	// File functions resolve paths where the steps run
+	exprCtx.WorkingDir = workingDir
 
 	return &Runner{
 		workflow:   workflow,
@@ -109,6 +61,32 @@ func NewRunner(workflow *schema.Workflow, event *schema.Event, workingDir string
 	}
 }
 
+// WithVars sets the vars context available to step expressions
+func (r *Runner) WithVars(vars map[string]interface{}) *Runner {
+	r.exprCtx.Vars = vars
+	return r
+}
+
+// WithSecrets sets the secrets context available to step expressions. Secret values
+// are masked in step output, denial reasons and log files.
+func (r *Runner) WithSecrets(values map[string]string) *Runner {
+	r.exprCtx.Secrets = values
+	r.masker = secrets.NewMasker(values)
+	return r
+}
+
+// WithRunnerContext sets the runner context available to step expressions
+func (r *Runner) WithRunnerContext(values map[string]interface{}) *Runner {
+	r.exprCtx.Runner = values
+	return r
+}
+
+// WithGit sets the git context available to step expressions
+func (r *Runner) WithGit(values map[string]interface{}) *Runner {
+	r.exprCtx.Git = values
+	return r
+}
+
 // Run executes all steps in the workflow
 func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 	var results []StepResult
@@ -119,13 +97,34 @@ func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 		if stepName == "" {
 			stepName = fmt.Sprintf("Step %d", i+1)
 		}
+		stepKey := stepContextKey(step, stepName)
 
 		// Update step context for expressions
-		r.exprCtx.Steps[stepName] = expression.StepContext{
+		r.exprCtx.Steps[stepKey] = expression.StepContext{
 			Outputs: make(map[string]string),
 			Outcome: "pending",
 		}
 
+		// Once the run is cancelled, only steps that check status with always() or
+		// cancelled() still run, and they run outside the cancelled context
+		stepCtx := ctx
+		if ctx.Err() == context.Canceled {
+			r.exprCtx.Cancelled = true
+			if !hasStatusCheck(step.If) {
+				results = append(results, StepResult{
+					Name:    stepName,
+					Success: false,
+					Output:  "Skipped (workflow cancelled)",
+				})
+				r.exprCtx.Steps[stepKey] = expression.StepContext{
+					Outputs: make(map[string]string),
+					Outcome: "skipped",
+				}
+				continue
+			}
+			stepCtx = context.WithoutCancel(ctx)
+		}
+
 		// Check if condition
 		if step.If != "" {
 			// Evaluate if condition
@@ -134,7 +133,7 @@ func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 				results = append(results, StepResult{
 					Name:    stepName,
 					Success: false,
-					Error:   fmt.Errorf("failed to evaluate if condition: %w", err),
+					Error:   fmt.Errorf("failed to evaluate if condition (%s): %w", r.location(i, "if"), err),
 				})
 				if !step.ContinueOnError {
 					prevStepFailed = true
@@ -151,8 +150,8 @@ func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 			}
 		}
 
-		// If previous step failed and this doesn't have always(), skip
-		if prevStepFailed && !strings.Contains(step.If, "always()") {
+		// If previous step failed and this doesn't have always() or cancelled(), skip
+		if prevStepFailed && !hasStatusCheck(step.If) {
 			results = append(results, StepResult{
 				Name:    stepName,
 				Success: false,
@@ -162,31 +161,85 @@ func (r *Runner) Run(ctx context.Context) ([]StepResult, error) {
 		}
 
 		// Execute the step
-		result := r.runStep(ctx, step, stepName)
+		result := r.runStep(stepCtx, i, step, stepName)
+		cancelled := !result.Success && stepCtx.Err() == context.Canceled
+		if cancelled {
+			r.exprCtx.Cancelled = true
+			result.Error = fmt.Errorf("step cancelled")
+		}
 		results = append(results, result)
 
 		// Update step context
 		outcome := "success"
 		if !result.Success {
 			outcome = "failure"
-			if !step.ContinueOnError {
+			if cancelled {
+				outcome = "cancelled"
+			}
+			if !step.ContinueOnError || cancelled {
 				prevStepFailed = true
 			}
 		}
-		r.exprCtx.Steps[stepName] = expression.StepContext{
-			Outputs: make(map[string]string),
+		outputs := result.Outputs
+		if outputs == nil {
+			outputs = make(map[string]string)
+		}
+		r.exprCtx.Steps[stepKey] = expression.StepContext{
+			Outputs: outputs,
 			Outcome: outcome,
 		}
 	}
 
+	for i := range results {
+		results[i] = r.maskResult(results[i])
+	}
 	return results, nil
 }
 
+// maskResult hides secret values in a step's output and error
+func (r *Runner) maskResult(result StepResult) StepResult {
+	if r.masker == nil {
+		return result
+	}
+	result.Output = r.masker.Mask(result.Output)
+	if result.Error != nil {
+		if masked := r.masker.Mask(result.Error.Error()); masked != result.Error.Error() {
+			result.Error = errors.New(masked)
+		}
+	}
+	return result
+}
+
+// hasStatusCheck reports whether a step condition opts into running after a
+// failure or cancellation
+func hasStatusCheck(condition string) bool {
+	return strings.Contains(condition, "always()") || strings.Contains(condition, "cancelled()")
+}
+
+// stepContextKey returns the key a step is registered under in the steps context.
+// Steps are keyed by id; the display name is only used when no id is set.
+func stepContextKey(step schema.Step, displayName string) string {
+	if step.ID != "" {
+		return step.ID
+	}
+	return displayName
+}
+
 // RunWithBlocking executes all steps and returns a WorkflowResult based on blocking mode
 // If blocking=true and any step fails, returns a deny result with detailed logs
 // If blocking=false, returns an allow result even if steps fail (logs warnings instead)
 func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
+	result := r.runWithBlocking(ctx)
+	result.PermissionDecisionReason = r.masker.Mask(result.PermissionDecisionReason)
+	return result
+}
+
+// runWithBlocking implements RunWithBlocking before secrets are masked in the reason
+func (r *Runner) runWithBlocking(ctx context.Context) *schema.WorkflowResult {
 	results, err := r.Run(ctx)
+	if r.exprCtx.Cancelled {
+		return schema.NewCancelledResult(fmt.Sprintf("workflow '%s' was cancelled", r.workflow.Name))
+	}
 	if err != nil {
 		if r.workflow.IsBlocking() {
 			return schema.NewDenyResult(fmt.Sprintf("workflow execution error: %v", err))
@@ -196,16 +249,15 @@ func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
 	}
 
 	// Check if any step failed
-	anyStepFailed := false
+	var failedSteps []string
 	for _, result := range results {
 		if !result.Success {
-			anyStepFailed = true
-			break
+			failedSteps = append(failedSteps, result.Name)
 		}
 	}
 
 	// If no failures, always allow
-	if !anyStepFailed {
+	if len(failedSteps) == 0 {
 		return schema.NewAllowResult()
 	}
 
@@ -214,6 +266,7 @@ func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
 		// Blocking mode: deny on any failure with detailed logs
 		logFile, reason := r.buildDenialWithLogs(results)
 		result := schema.NewDenyResult(reason)
+		result.FailedSteps = failedSteps
 		if logFile != "" {
 			result.LogFile = logFile
 		}
@@ -226,7 +279,9 @@ func (r *Runner) RunWithBlocking(ctx context.Context) *schema.WorkflowResult {
 			log.Printf("Warning: step '%s' failed (non-blocking): %v", result.Name, result.Error)
 		}
 	}
-	return schema.NewAllowResult()
+	result := schema.NewAllowResult()
+	result.FailedSteps = failedSteps
+	return result
 }
 
 // buildDenialWithLogs creates a detailed log file and returns the path and denial reason
@@ -272,7 +327,7 @@ func (r *Runner) buildDenialWithLogs(results []StepResult) (logFile string, reas
 	}
 	defer func() { _ = tmpFile.Close() }()
 
-	_, err = tmpFile.WriteString(logContent.String())
+	_, err = tmpFile.WriteString(r.masker.Mask(logContent.String()))
 	if err != nil {
 		return "", fmt.Sprintf("workflow '%s' blocked due to step failures: %s", r.workflow.Name, strings.Join(failedSteps, ", "))
 	}
@@ -305,8 +360,37 @@ func (r *Runner) buildDenialWithLogs(results []StepResult) (logFile string, reas
 	return logFile, reasonBuilder.String()
 }
 
-// runStep executes a single step
-func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) StepResult {
+// location describes where a step field is defined, for errors. The workflow file
+// is shown relative to the working directory when it is inside it.
+func (r *Runner) location(index int, field string) string {
+	return r.fieldLocation(fmt.Sprintf("steps.%d.%s", index, field))
+}
+
+// fieldLocation describes where a workflow field, given by its dotted path, is defined
+func (r *Runner) fieldLocation(field string) string {
+	path := r.workflow.Path
+	if path == "" {
+		return field
+	}
+	if rel, err := filepath.Rel(r.workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	return fmt.Sprintf("%s: %s", filepath.ToSlash(path), field)
+}
+
+// sortedKeys returns the keys of an env map in order, so the first failing
+// expression is reported consistently
+func sortedKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runStep executes the step at index in the workflow
+func (r *Runner) runStep(ctx context.Context, index int, step schema.Step, name string) StepResult {
 	start := time.Now()
 
 	// Handle timeout
@@ -318,12 +402,12 @@ func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) Ste
 
 	// Check for uses: action
 	if step.Uses != "" {
-		return r.runAction(ctx, step, name, start)
+		return r.runAction(ctx, index, step, name, start)
 	}
 
 	// Execute run: command
 	if step.Run != "" {
-		return r.runCommand(ctx, step, name, start)
+		return r.runCommand(ctx, index, step, name, start)
 	}
 
 	return StepResult{
@@ -335,14 +419,14 @@ func (r *Runner) runStep(ctx context.Context, step schema.Step, name string) Ste
 }
 
 // runCommand executes a shell command
-func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string, start time.Time) StepResult {
+func (r *Runner) runCommand(ctx context.Context, index int, step schema.Step, name string, start time.Time) StepResult {
 	// Evaluate expressions in command
 	command, err := r.exprCtx.EvaluateString(step.Run)
 	if err != nil {
 		return StepResult{
 			Name:     name,
 			Success:  false,
-			Error:    fmt.Errorf("failed to evaluate command: %w", err),
+			Error:    fmt.Errorf("failed to evaluate command (%s): %w", r.location(index, "run"), err),
 			Duration: time.Since(start),
 		}
 	}
@@ -372,23 +456,61 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 	workDir := r.workingDir
 	if step.WorkingDirectory != "" {
 		wd, err := r.exprCtx.EvaluateString(step.WorkingDirectory)
-		if err == nil {
-			workDir = wd
+		if err != nil {
+			return StepResult{
+				Name:     name,
+				Success:  false,
+				Error:    fmt.Errorf("failed to evaluate working directory (%s): %w", r.location(index, "working-directory"), err),
+				Duration: time.Since(start),
+			}
 		}
+		workDir = wd
 	}
 	cmd.Dir = workDir
 
 	// Set environment
 	cmd.Env = os.Environ()
-	for k, v := range r.env {
-		val, _ := r.exprCtx.EvaluateString(v)
+	for _, k := range sortedKeys(r.env) {
+		val, err := r.exprCtx.EvaluateString(r.env[k])
+		if err != nil {
+			return StepResult{
+				Name:     name,
+				Success:  false,
+				Error:    fmt.Errorf("failed to evaluate env (%s): %w", r.fieldLocation("env."+k), err),
+				Duration: time.Since(start),
+			}
+		}
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, val))
 	}
-	for k, v := range step.Env {
-		val, _ := r.exprCtx.EvaluateString(v)
+	for _, k := range sortedKeys(step.Env) {
+		val, err := r.exprCtx.EvaluateString(step.Env[k])
+		if err != nil {
+			return StepResult{
+				Name:     name,
+				Success:  false,
+				Error:    fmt.Errorf("failed to evaluate env (%s): %w", r.location(index, "env."+k), err),
+				Duration: time.Since(start),
+			}
+		}
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, val))
 	}
 
+	// Provide a file the step can write outputs to
+	outputFile, err := createOutputFile()
+	if err != nil {
+		return StepResult{
+			Name:     name,
+			Success:  false,
+			Error:    err,
+			Duration: time.Since(start),
+		}
+	}
+	defer func() { _ = os.Remove(outputFile) }()
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("%s=%s", OutputEnvVar, outputFile),
+		fmt.Sprintf("%s=%s", githubOutputEnvVar, outputFile),
+	)
+
 	// Capture output
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -402,12 +524,16 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 		output += "\n" + stderr.String()
 	}
 
+	// Outputs are collected even when the step fails so later steps can inspect them
+	outputs, outputErr := readOutputFile(outputFile)
+
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			return StepResult{
 				Name:     name,
 				Success:  false,
 				Output:   output,
+				Outputs:  outputs,
 				Error:    fmt.Errorf("step timed out after %d seconds", step.Timeout),
 				Duration: time.Since(start),
 			}
@@ -416,21 +542,33 @@ func (r *Runner) runCommand(ctx context.Context, step schema.Step, name string,
 			Name:     name,
 			Success:  false,
 			Output:   output,
+			Outputs:  outputs,
 			Error:    err,
 			Duration: time.Since(start),
 		}
 	}
 
+	if outputErr != nil {
+		return StepResult{
+			Name:     name,
+			Success:  false,
+			Output:   output,
+			Error:    fmt.Errorf("invalid step outputs: %w", outputErr),
+			Duration: time.Since(start),
+		}
+	}
+
 	return StepResult{
 		Name:     name,
 		Success:  true,
 		Output:   output,
+		Outputs:  outputs,
 		Duration: time.Since(start),
 	}
 }
 
 // runAction executes a reusable action
-func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, start time.Time) StepResult {
+func (r *Runner) runAction(ctx context.Context, index int, step schema.Step, name string, start time.Time) StepResult {
 	// Parse the uses: string
 	parsed, err := parseUsesString(step.Uses)
 	if err != nil {
@@ -470,7 +608,7 @@ func (r *Runner) runAction(ctx context.Context, step schema.Step, name string, s
 		return StepResult{
 			Name:     name,
 			Success:  false,
-			Error:    fmt.Errorf("failed to evaluate inputs: %w", err),
+			Error:    fmt.Errorf("failed to evaluate inputs (%s): %w", r.location(index, "with"), err),
 			Duration: time.Since(start),
 		}
 	}
@@ -3,7 +3,9 @@ package runner
 import (
 	"context"
 	"os"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
@@ -127,6 +129,13 @@ func TestRunWithBlockingMultipleStepFailures(t *testing.T) {
 	if !contains(result.PermissionDecisionReason, "fail-step-1") {
 		t.Errorf("Expected reason to mention fail-step-1, got: %s", result.PermissionDecisionReason)
 	}
+	// fail-step-2 is skipped after fail-step-1 fails, and skipped steps count as failed
+	if len(result.FailedSteps) != 2 || result.FailedSteps[0] != "fail-step-1" {
+		t.Errorf("Expected FailedSteps [fail-step-1 fail-step-2], got %v", result.FailedSteps)
+	}
+	if result.LogFile != "" {
+		_ = os.Remove(result.LogFile)
+	}
 }
 
 // Helper function to create a bool pointer
@@ -210,3 +219,88 @@ func TestRunWithBlockingCreatesLogFile(t *testing.T) {
 		_ = os.Remove(result.LogFile)
 	}
 }
+
+// TestRunWithBlockingCancelled tests that cancelling a run stops the current step,
+// skips later steps, and still runs steps guarded by cancelled()
+func TestRunWithBlockingCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	workflow := &schema.Workflow{
+		Name:     "test-cancel",
+		Blocking: ptrBool(true),
+		Steps: []schema.Step{
+			{
+				Name:  "slow",
+				Shell: "bash",
+				Run:   "sleep 5",
+			},
+			{
+				Name:  "after",
+				Shell: "bash",
+				Run:   "echo 'should not run'",
+			},
+			{
+				Name:  "cleanup",
+				Shell: "bash",
+				If:    "cancelled()",
+				Run:   "echo 'cleaned up'",
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	r := NewRunner(workflow, nil, ".")
+	start := time.Now()
+	result := r.RunWithBlocking(ctx)
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected cancellation to stop the running step, took %v", elapsed)
+	}
+
+	// The run that superseded it decides for the latest state
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected cancelled blocking run to allow, got %s", result.PermissionDecision)
+	}
+	if !result.Cancelled {
+		t.Error("Expected result to be marked cancelled")
+	}
+
+	if got := r.exprCtx.Steps["slow"].Outcome; got != "cancelled" {
+		t.Errorf("Expected slow step outcome cancelled, got %s", got)
+	}
+	if got := r.exprCtx.Steps["after"].Outcome; got != "skipped" {
+		t.Errorf("Expected after step outcome skipped, got %s", got)
+	}
+	if got := r.exprCtx.Steps["cleanup"].Outcome; got != "success" {
+		t.Errorf("Expected cleanup step to run, got outcome %s", got)
+	}
+}
+
+func TestRunWithBlockingCancelledNonBlocking(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping bash test on Windows")
+	}
+
+	workflow := &schema.Workflow{
+		Name:     "test-cancel-non-blocking",
+		Blocking: ptrBool(false),
+		Steps: []schema.Step{
+			{
+				Name:  "slow",
+				Shell: "bash",
+				Run:   "sleep 5",
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	result := NewRunner(workflow, nil, ".").RunWithBlocking(ctx)
+	if result.PermissionDecision != "allow" || !result.Cancelled {
+		t.Errorf("Expected cancelled non-blocking run to allow, got %s (cancelled %v)", result.PermissionDecision, result.Cancelled)
+	}
+}
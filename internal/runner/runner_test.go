@@ -2,11 +2,13 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/htekdev/agentic-ops-cli/internal/expression"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
@@ -2023,3 +2025,154 @@ func TestDurationCapture(t *testing.T) {
 		t.Errorf("Expected duration <= 5 seconds, got %v", result.Duration)
 	}
 }
+
+// TestFileFunctionsResolveAgainstWorkingDir tests that file expression functions
+// look at the runner's working directory
+func TestFileFunctionsResolveAgainstWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/go.sum", []byte("a\nb\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workflow := &schema.Workflow{
+		Name: "test-file-functions",
+		Steps: []schema.Step{
+			{
+				Name: "has-go-sum",
+				If:   "fileExists('go.sum') && lineCount('go.sum') == 2",
+				Run:  "echo 'found'",
+			},
+			{
+				Name: "missing-file",
+				If:   "fileExists('package.json')",
+				Run:  "echo 'should not run'",
+			},
+		},
+	}
+
+	runner := NewRunner(workflow, &schema.Event{Cwd: "/elsewhere"}, dir)
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if !strings.Contains(results[0].Output, "found") {
+		t.Errorf("Expected first step to run, got output: %s", results[0].Output)
+	}
+	if results[1].Output != "Skipped (condition not met)" {
+		t.Errorf("Expected second step to be skipped, got output: %s", results[1].Output)
+	}
+}
+
+// TestSecretsAreMasked tests that secret values never appear in step output, the
+// denial reason or the log file
+func TestSecretsAreMasked(t *testing.T) {
+	workflow := &schema.Workflow{
+		Name: "test-secrets",
+		Steps: []schema.Step{
+			{
+				Name: "leak",
+				Run:  "echo \"token is $TOKEN\" && exit 1",
+				Env:  map[string]string{"TOKEN": "${{ secrets.API_TOKEN }}"},
+			},
+		},
+	}
+
+	runner := NewRunner(workflow, nil, t.TempDir()).WithSecrets(map[string]string{"API_TOKEN": "s3cr3t-value"})
+	result := runner.RunWithBlocking(context.Background())
+	if result.LogFile != "" {
+		defer func() { _ = os.Remove(result.LogFile) }()
+	}
+
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	if strings.Contains(result.PermissionDecisionReason, "s3cr3t-value") {
+		t.Errorf("Expected secret to be masked in reason, got: %s", result.PermissionDecisionReason)
+	}
+	if !strings.Contains(result.PermissionDecisionReason, "token is ***") {
+		t.Errorf("Expected masked output in reason, got: %s", result.PermissionDecisionReason)
+	}
+
+	content, err := os.ReadFile(result.LogFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if strings.Contains(string(content), "s3cr3t-value") {
+		t.Errorf("Expected secret to be masked in log file, got: %s", content)
+	}
+
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(results[0].Output, "token is ***") {
+		t.Errorf("Expected masked step output, got: %s", results[0].Output)
+	}
+}
+
+// TestExpressionErrorsNameTheirLocation tests that expression errors say which
+// workflow file, step and field they came from
+func TestExpressionErrorsNameTheirLocation(t *testing.T) {
+	dir := t.TempDir()
+	workflow := &schema.Workflow{
+		Name: "test-locations",
+		Path: dir + "/.github/agent-workflows/lint.yml",
+		Steps: []schema.Step{
+			{Name: "condition", If: "startswith('a', 'a')", Run: "echo never"},
+			{Name: "command", If: "always()", Run: "echo ${{ fromJSON('{') }}"},
+			{Name: "env", If: "always()", Run: "echo env", Env: map[string]string{"OK": "fine", "BAD": "${{ fromJSON('{') }}"}},
+			{Name: "directory", If: "always()", Run: "echo dir", WorkingDirectory: "${{ fromJSON('{') }}"},
+		},
+	}
+
+	results, err := NewRunner(workflow, nil, dir).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	want := []string{
+		"failed to evaluate if condition (.github/agent-workflows/lint.yml: steps.0.if)",
+		"failed to evaluate command (.github/agent-workflows/lint.yml: steps.1.run)",
+		"failed to evaluate env (.github/agent-workflows/lint.yml: steps.2.env.BAD)",
+		"failed to evaluate working directory (.github/agent-workflows/lint.yml: steps.3.working-directory)",
+	}
+	for i, prefix := range want {
+		if results[i].Error == nil || !strings.HasPrefix(results[i].Error.Error(), prefix) {
+			t.Errorf("Expected error starting with %q, got: %v", prefix, results[i].Error)
+		}
+	}
+	if !strings.Contains(results[0].Error.Error(), "did you mean 'startsWith'?") {
+		t.Errorf("Expected a suggestion in the error, got: %v", results[0].Error)
+	}
+}
+
+// TestWorkflowEnvErrorsNameTheirLocation tests that a workflow env expression that
+// fails to evaluate fails the step and names the workflow field
+func TestWorkflowEnvErrorsNameTheirLocation(t *testing.T) {
+	dir := t.TempDir()
+	workflow := &schema.Workflow{
+		Name:  "test-env-location",
+		Path:  dir + "/.github/agent-workflows/lint.yml",
+		Env:   map[string]string{"BAD": "${{ fromJSON('{') }}"},
+		Steps: []schema.Step{{Name: "echo", Run: "echo hi"}},
+	}
+
+	results, err := NewRunner(workflow, nil, dir).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	prefix := "failed to evaluate env (.github/agent-workflows/lint.yml: env.BAD)"
+	if len(results) != 1 || results[0].Error == nil || !strings.HasPrefix(results[0].Error.Error(), prefix) {
+		t.Errorf("Expected error starting with %q, got: %v", prefix, results)
+	}
+	var evalErr *expression.EvalError
+	if !errors.As(results[0].Error, &evalErr) {
+		t.Errorf("Expected the error to wrap *expression.EvalError, got %T", results[0].Error)
+	}
+}
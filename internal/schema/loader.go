@@ -20,6 +20,7 @@ func LoadWorkflow(filePath string) (*Workflow, error) {
 	if err := yaml.Unmarshal(data, &workflow); err != nil {
 		return nil, fmt.Errorf("failed to parse workflow YAML: %w", err)
 	}
+	workflow.Path = filePath
 
 	return &workflow, nil
 }
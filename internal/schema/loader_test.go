@@ -3,6 +3,7 @@ package schema
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -343,6 +344,51 @@ func TestValidateWorkflow_StepMissingAction(t *testing.T) {
 	assertHasValidationError(t, result)
 }
 
+func TestValidateWorkflow_ValidStepIDs(t *testing.T) {
+	result := ValidateWorkflow("../../testdata/workflows/valid/step-ids.yml")
+	if !result.Valid {
+		t.Errorf("Expected valid workflow with step ids, got errors: %v", result.Errors)
+	}
+}
+
+func TestValidateWorkflow_InvalidStepID(t *testing.T) {
+	result := ValidateWorkflow("../../testdata/workflows/invalid/invalid-step-id.yml")
+	if result.Valid {
+		t.Fatal("Expected invalid workflow for step id that is not an identifier")
+	}
+	assertHasValidationError(t, result)
+	found := false
+	for _, err := range result.Errors {
+		for _, detail := range err.Details {
+			if strings.Contains(detail, "steps.0.id: 'changed.files' is not a valid identifier") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected invalid step id detail, got: %v", result.Errors)
+	}
+}
+
+func TestValidateWorkflow_DuplicateStepID(t *testing.T) {
+	result := ValidateWorkflow("../../testdata/workflows/invalid/duplicate-step-id.yml")
+	if result.Valid {
+		t.Fatal("Expected invalid workflow for duplicate step ids")
+	}
+	assertHasValidationError(t, result)
+	found := false
+	for _, err := range result.Errors {
+		for _, detail := range err.Details {
+			if strings.Contains(detail, "duplicate step id 'lint'") && strings.Contains(detail, "steps.1.id") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected duplicate step id detail, got: %v", result.Errors)
+	}
+}
+
 // ============================================================================
 // Additional Property Validation Tests
 // ============================================================================
@@ -792,3 +838,30 @@ func assertHasValidationError(t *testing.T, result *ValidationResult) {
 	}
 }
 
+
+func TestValidateWorkflow_AdditionalChecks(t *testing.T) {
+	var checked *Workflow
+	check := func(workflow *Workflow) []string {
+		checked = workflow
+		return []string{"steps.0.run: something is wrong"}
+	}
+
+	result := ValidateWorkflow("../../testdata/workflows/valid/simple.yml", check)
+	if checked == nil || checked.Name != "Lint JavaScript Files" {
+		t.Fatalf("Expected check to receive the parsed workflow, got %v", checked)
+	}
+	if result.Valid {
+		t.Fatal("Expected check failures to make the workflow invalid")
+	}
+	assertHasValidationError(t, result)
+	if details := result.Errors[0].Details; len(details) != 1 || details[0] != "steps.0.run: something is wrong" {
+		t.Errorf("Expected check details to be reported, got %v", details)
+	}
+
+	// Checks only run on workflows that pass schema validation
+	checked = nil
+	ValidateWorkflow("../../testdata/workflows/invalid/missing-required.yml", check)
+	if checked != nil {
+		t.Error("Expected checks to be skipped for schema-invalid workflows")
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/htekdev/agentic-ops-cli/internal/vars"
 	"github.com/xeipuuv/gojsonschema"
 	"gopkg.in/yaml.v3"
 )
@@ -28,8 +30,13 @@ type ValidationResult struct {
 	Errors []ValidationError
 }
 
-// ValidateWorkflow validates a single workflow file against the schema
-func ValidateWorkflow(filePath string) *ValidationResult {
+// WorkflowCheck is an additional semantic check run on a parsed workflow. It returns
+// a description of each problem found.
+type WorkflowCheck func(workflow *Workflow) []string
+
+// ValidateWorkflow validates a single workflow file against the schema, then runs
+// any additional checks on the parsed workflow
+func ValidateWorkflow(filePath string, checks ...WorkflowCheck) *ValidationResult {
 	result := &ValidationResult{
 		Valid:  true,
 		Errors: []ValidationError{},
@@ -115,13 +122,61 @@ func ValidateWorkflow(filePath string) *ValidationResult {
 			Message: "Workflow validation failed",
 			Details: details,
 		})
+		return result
+	}
+
+	// Semantic checks that JSON schema cannot express
+	var workflow Workflow
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			File:    filePath,
+			Message: fmt.Sprintf("Failed to parse workflow: %v", err),
+		})
+		return result
+	}
+	details := validateStepIDs(&workflow)
+	for _, check := range checks {
+		details = append(details, check(&workflow)...)
+	}
+	if len(details) > 0 {
+		result.Valid = false
+		result.Errors = append(result.Errors, ValidationError{
+			File:    filePath,
+			Message: "Workflow validation failed",
+			Details: details,
+		})
 	}
 
 	return result
 }
 
+// stepIDPattern matches valid step identifiers
+var stepIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// validateStepIDs checks that step ids are valid identifiers and unique within the workflow
+func validateStepIDs(workflow *Workflow) []string {
+	var details []string
+	seen := make(map[string]int)
+	for i, step := range workflow.Steps {
+		if step.ID == "" {
+			continue
+		}
+		if !stepIDPattern.MatchString(step.ID) {
+			details = append(details, fmt.Sprintf("steps.%d.id: '%s' is not a valid identifier (must start with a letter or '_' and contain only letters, digits, '_' or '-')", i, step.ID))
+			continue
+		}
+		if first, ok := seen[step.ID]; ok {
+			details = append(details, fmt.Sprintf("steps.%d.id: duplicate step id '%s' (already used by steps.%d)", i, step.ID, first))
+			continue
+		}
+		seen[step.ID] = i
+	}
+	return details
+}
+
 // ValidateWorkflowsInDir validates all workflow files in a directory
-func ValidateWorkflowsInDir(dir string) *ValidationResult {
+func ValidateWorkflowsInDir(dir string, checks ...WorkflowCheck) *ValidationResult {
 	result := &ValidationResult{
 		Valid:  true,
 		Errors: []ValidationError{},
@@ -153,8 +208,20 @@ func ValidateWorkflowsInDir(dir string) *ValidationResult {
 			return nil
 		}
 
+		// The shared variables file only needs to be a valid mapping
+		if vars.IsVarsFile(dir, path) {
+			if _, err := vars.LoadFile(path); err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					File:    path,
+					Message: fmt.Sprintf("Invalid vars file: %v", err),
+				})
+			}
+			return nil
+		}
+
 		// Validate this file
-		fileResult := ValidateWorkflow(path)
+		fileResult := ValidateWorkflow(path, checks...)
 		if !fileResult.Valid {
 			result.Valid = false
 			result.Errors = append(result.Errors, fileResult.Errors...)
@@ -3,6 +3,7 @@ package schema
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -134,6 +135,39 @@ func TestValidateWorkflowsInDir_WithErrors(t *testing.T) {
 	}
 }
 
+func TestValidateWorkflowsInDir_VarsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"mapping", "max_lines: 500\nprotected:\n  - go.mod\n", true},
+		{"not a mapping", "- go.mod\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workflowDir := filepath.Join(tmpDir, ".github", "agent-workflows")
+			if err := os.MkdirAll(workflowDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(workflowDir, "vars.yml"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// vars.yml is not a workflow, so it is never checked against the workflow schema
+			result := ValidateWorkflowsInDir(tmpDir)
+			if result.Valid != tt.valid {
+				t.Errorf("Expected valid=%v, got errors: %v", tt.valid, result.Errors)
+			}
+			if !tt.valid && (len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "Invalid vars file")) {
+				t.Errorf("Expected an invalid vars file error, got: %v", result.Errors)
+			}
+		})
+	}
+}
+
 func TestValidateWorkflowsInDir_NoWorkflowDir(t *testing.T) {
 	// Create a temporary directory without workflows
 	tmpDir, err := os.MkdirTemp("", "agentic-ops-test-empty")
@@ -1,5 +1,10 @@
 package schema
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Workflow represents a complete agent workflow definition
 type Workflow struct {
 	Name        string            `yaml:"name" json:"name"`
@@ -9,6 +14,7 @@ type Workflow struct {
 	On          OnConfig          `yaml:"on" json:"on"`
 	Env         map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
 	Steps       []Step            `yaml:"steps" json:"steps"`
+	Path        string            `yaml:"-" json:"-"` // File the workflow was loaded from, if any
 }
 
 // IsBlocking returns whether the workflow should block on failure (default: true)
@@ -23,6 +29,9 @@ func (w *Workflow) IsBlocking() bool {
 type ConcurrencyConfig struct {
 	Group       string `yaml:"group" json:"group"`
 	MaxParallel int    `yaml:"max-parallel,omitempty" json:"max-parallel,omitempty"` // Default: 1
+	Timeout     int    `yaml:"timeout,omitempty" json:"timeout,omitempty"`           // Seconds to wait for a slot. Default: 60
+	// CancelInProgress cancels runs already holding the group when a new run starts
+	CancelInProgress bool `yaml:"cancel-in-progress,omitempty" json:"cancel-in-progress,omitempty"`
 }
 
 // OnConfig defines all trigger types
@@ -88,7 +97,7 @@ type ToolTrigger struct {
 
 // FileTrigger matches file create/edit events
 type FileTrigger struct {
-	Types       []string `yaml:"types,omitempty" json:"types,omitempty"`             // create, edit
+	Types       []string `yaml:"types,omitempty" json:"types,omitempty"`             // create, edit, delete, rename
 	Paths       []string `yaml:"paths,omitempty" json:"paths,omitempty"`             // Include patterns
 	PathsIgnore []string `yaml:"paths-ignore,omitempty" json:"paths-ignore,omitempty"` // Exclude patterns
 }
@@ -113,6 +122,7 @@ type PushTrigger struct {
 
 // Step represents a single step in a workflow
 type Step struct {
+	ID              string            `yaml:"id,omitempty" json:"id,omitempty"` // Key for the steps context
 	Name            string            `yaml:"name,omitempty" json:"name,omitempty"`
 	If              string            `yaml:"if,omitempty" json:"if,omitempty"`
 	Run             string            `yaml:"run,omitempty" json:"run,omitempty"`
@@ -148,13 +158,23 @@ type ToolEvent struct {
 	Name     string                 `json:"name"`
 	Args     map[string]interface{} `json:"args"`
 	HookType string                 `json:"hook_type,omitempty"`
+	Result   *ToolResult            `json:"result,omitempty"` // Only set for postToolUse
+}
+
+// ToolResult contains the outcome of a tool invocation (postToolUse)
+type ToolResult struct {
+	Type     string `json:"type"`                // success, failure, denied
+	Output   string `json:"output,omitempty"`    // Text result returned to the agent
+	ExitCode *int   `json:"exit_code,omitempty"` // Exit status for shell tools, when known
 }
 
 // FileEvent contains file change data
 type FileEvent struct {
 	Path    string `json:"path"`
-	Action  string `json:"action"` // create, edit
+	Action  string `json:"action"` // create, edit, delete, rename
 	Content string `json:"content,omitempty"`
+	OldPath string `json:"old_path,omitempty"` // Path before a rename
+	Tree    bool   `json:"tree,omitempty"`     // Path and OldPath are directories or globs with too many files to list; the event stands for every file below them
 }
 
 // CommitEvent contains git commit data
@@ -162,7 +182,13 @@ type CommitEvent struct {
 	SHA     string       `json:"sha"`
 	Message string       `json:"message"`
 	Author  string       `json:"author"`
+	Branch  string       `json:"branch,omitempty"` // Branch the commit is made on
 	Files   []FileStatus `json:"files"`
+
+	Amend      bool `json:"amend,omitempty"`       // Rewrites the previous commit (--amend)
+	Fixup      bool `json:"fixup,omitempty"`       // Made with --fixup, to be squashed into another commit
+	AllowEmpty bool `json:"allow_empty,omitempty"` // Records no changes (--allow-empty)
+	NoVerify   bool `json:"no_verify,omitempty"`   // Skips the pre-commit and commit-msg hooks (--no-verify)
 }
 
 // PushEvent contains git push data
@@ -171,6 +197,8 @@ type PushEvent struct {
 	Before  string        `json:"before"`
 	After   string        `json:"after"`
 	Commits []CommitEvent `json:"commits"`
+
+	CommitsUnknown bool `json:"commits_unknown,omitempty"` // The pushed commits could not be determined
 }
 
 // FileStatus represents a file's status in a commit
@@ -181,9 +209,22 @@ type FileStatus struct {
 
 // WorkflowResult represents the outcome of running a workflow
 type WorkflowResult struct {
-	PermissionDecision       string `json:"permissionDecision"` // allow, deny
-	PermissionDecisionReason string `json:"permissionDecisionReason,omitempty"`
-	LogFile                  string `json:"logFile,omitempty"` // Path to detailed log file
+	PermissionDecision       string            `json:"permissionDecision"` // allow, deny
+	PermissionDecisionReason string            `json:"permissionDecisionReason,omitempty"`
+	LogFile                  string            `json:"logFile,omitempty"`     // Path to detailed log file
+	FailedSteps              []string          `json:"failedSteps,omitempty"` // Names of steps that failed
+	Workflows                []WorkflowSummary `json:"workflows,omitempty"`   // Per-workflow outcomes of a combined result
+	Cancelled                bool              `json:"cancelled,omitempty"`   // Superseded by a newer run in its concurrency group
+}
+
+// WorkflowSummary describes the outcome of a single workflow within a combined result
+type WorkflowSummary struct {
+	Name               string   `json:"name"`
+	PermissionDecision string   `json:"permissionDecision"`
+	Reason             string   `json:"reason,omitempty"`
+	FailedSteps        []string `json:"failedSteps,omitempty"`
+	LogFile            string   `json:"logFile,omitempty"`
+	Cancelled          bool     `json:"cancelled,omitempty"`
 }
 
 // NewAllowResult creates an allow result
@@ -198,3 +239,72 @@ func NewDenyResult(reason string) *WorkflowResult {
 		PermissionDecisionReason: reason,
 	}
 }
+
+// NewCancelledResult creates a result for a run that was cancelled before it finished.
+// Cancelled runs allow: a run superseded in its concurrency group was checking stale
+// state, and the newer run that replaced it gives the verdict on the latest state.
+func NewCancelledResult(reason string) *WorkflowResult {
+	return &WorkflowResult{
+		PermissionDecision:       "allow",
+		PermissionDecisionReason: reason,
+		Cancelled:                true,
+	}
+}
+
+// NewWorkflowSummary summarizes a single workflow's result
+func NewWorkflowSummary(name string, result *WorkflowResult) WorkflowSummary {
+	return WorkflowSummary{
+		Name:               name,
+		PermissionDecision: result.PermissionDecision,
+		Reason:             result.PermissionDecisionReason,
+		FailedSteps:        result.FailedSteps,
+		LogFile:            result.LogFile,
+		Cancelled:          result.Cancelled,
+	}
+}
+
+// CombineResults merges per-workflow outcomes into a single result.
+// The combined decision is deny if any workflow denied, and the reason lists
+// every denial so the agent sees everything it must fix at once.
+func CombineResults(summaries []WorkflowSummary) *WorkflowResult {
+	if len(summaries) == 0 {
+		return NewAllowResult()
+	}
+
+	var denied []WorkflowSummary
+	for _, s := range summaries {
+		if s.PermissionDecision == "deny" {
+			denied = append(denied, s)
+		}
+	}
+
+	var result *WorkflowResult
+	switch len(denied) {
+	case 0:
+		result = NewAllowResult()
+		if len(summaries) == 1 && summaries[0].Cancelled {
+			result = NewCancelledResult(summaries[0].Reason)
+		}
+	case 1:
+		// A single denial keeps its original reason and log file
+		result = NewDenyResult(denied[0].Reason)
+		result.FailedSteps = denied[0].FailedSteps
+		result.LogFile = denied[0].LogFile
+		result.Cancelled = denied[0].Cancelled
+	default:
+		var reason strings.Builder
+		fmt.Fprintf(&reason, "%d of %d workflows blocked.\n", len(denied), len(summaries))
+		for _, d := range denied {
+			fmt.Fprintf(&reason, "\n[%s]\n%s\n", d.Name, strings.TrimSpace(d.Reason))
+		}
+		result = NewDenyResult(strings.TrimRight(reason.String(), "\n"))
+		for _, d := range denied {
+			result.FailedSteps = append(result.FailedSteps, d.FailedSteps...)
+		}
+	}
+
+	if len(summaries) > 1 {
+		result.Workflows = summaries
+	}
+	return result
+}
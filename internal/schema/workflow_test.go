@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCombineResults_NoWorkflows(t *testing.T) {
+	result := CombineResults(nil)
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected allow for no workflows, got %s", result.PermissionDecision)
+	}
+	if result.Workflows != nil {
+		t.Errorf("Expected no workflow summaries, got %v", result.Workflows)
+	}
+}
+
+func TestCombineResults_AllAllow(t *testing.T) {
+	result := CombineResults([]WorkflowSummary{
+		{Name: "lint", PermissionDecision: "allow"},
+		{Name: "test", PermissionDecision: "allow"},
+	})
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected allow, got %s", result.PermissionDecision)
+	}
+	if len(result.Workflows) != 2 {
+		t.Errorf("Expected 2 workflow summaries, got %d", len(result.Workflows))
+	}
+}
+
+func TestCombineResults_SingleDenyKeepsReason(t *testing.T) {
+	result := CombineResults([]WorkflowSummary{
+		{Name: "lint", PermissionDecision: "allow"},
+		{Name: "test", PermissionDecision: "deny", Reason: "Workflow 'test' blocked.", FailedSteps: []string{"go test"}, LogFile: "/tmp/test.log"},
+	})
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	if result.PermissionDecisionReason != "Workflow 'test' blocked." {
+		t.Errorf("Expected original reason, got %q", result.PermissionDecisionReason)
+	}
+	if result.LogFile != "/tmp/test.log" {
+		t.Errorf("Expected log file of denying workflow, got %q", result.LogFile)
+	}
+	if len(result.FailedSteps) != 1 || result.FailedSteps[0] != "go test" {
+		t.Errorf("Expected failed steps from denying workflow, got %v", result.FailedSteps)
+	}
+}
+
+func TestCombineResults_MultipleDenials(t *testing.T) {
+	result := CombineResults([]WorkflowSummary{
+		{Name: "lint", PermissionDecision: "deny", Reason: "lint failed", FailedSteps: []string{"eslint"}, LogFile: "/tmp/lint.log"},
+		{Name: "format", PermissionDecision: "allow"},
+		{Name: "test", PermissionDecision: "deny", Reason: "tests failed", FailedSteps: []string{"go test"}, LogFile: "/tmp/test.log"},
+	})
+	if result.PermissionDecision != "deny" {
+		t.Fatalf("Expected deny, got %s", result.PermissionDecision)
+	}
+	reason := result.PermissionDecisionReason
+	for _, want := range []string{"2 of 3 workflows blocked", "[lint]", "lint failed", "[test]", "tests failed"} {
+		if !strings.Contains(reason, want) {
+			t.Errorf("Expected reason to contain %q, got: %s", want, reason)
+		}
+	}
+	if result.LogFile != "" {
+		t.Errorf("Expected no top-level log file for multiple denials, got %q", result.LogFile)
+	}
+	if len(result.FailedSteps) != 2 {
+		t.Errorf("Expected failed steps from both workflows, got %v", result.FailedSteps)
+	}
+	if len(result.Workflows) != 3 || result.Workflows[2].LogFile != "/tmp/test.log" {
+		t.Errorf("Expected per-workflow summaries with log files, got %+v", result.Workflows)
+	}
+}
+
+func TestCombineResults_SingleCancelled(t *testing.T) {
+	result := CombineResults([]WorkflowSummary{
+		NewWorkflowSummary("test", NewCancelledResult("superseded")),
+	})
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected allow, got %s", result.PermissionDecision)
+	}
+	if !result.Cancelled {
+		t.Error("Expected combined result to be cancelled")
+	}
+	if result.PermissionDecisionReason != "superseded" {
+		t.Errorf("Expected reason to be kept, got %q", result.PermissionDecisionReason)
+	}
+}
+
+func TestCombineResults_CancelledWithOthers(t *testing.T) {
+	result := CombineResults([]WorkflowSummary{
+		NewWorkflowSummary("gate", NewCancelledResult("superseded")),
+		NewWorkflowSummary("other", NewAllowResult()),
+	})
+	if result.PermissionDecision != "allow" {
+		t.Errorf("Expected a cancelled workflow to leave the decision to others, got %s", result.PermissionDecision)
+	}
+
+	result = CombineResults([]WorkflowSummary{
+		NewWorkflowSummary("gate", NewCancelledResult("superseded")),
+		NewWorkflowSummary("other", NewDenyResult("blocked")),
+	})
+	if result.PermissionDecision != "deny" || result.PermissionDecisionReason != "blocked" {
+		t.Errorf("Expected the other workflow's denial, got %s: %q", result.PermissionDecision, result.PermissionDecisionReason)
+	}
+}
+
+func TestNewWorkflowSummary(t *testing.T) {
+	result := NewDenyResult("blocked")
+	result.FailedSteps = []string{"step"}
+	result.LogFile = "/tmp/x.log"
+
+	summary := NewWorkflowSummary("wf", result)
+	if summary.Name != "wf" || summary.PermissionDecision != "deny" || summary.Reason != "blocked" ||
+		len(summary.FailedSteps) != 1 || summary.LogFile != "/tmp/x.log" {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
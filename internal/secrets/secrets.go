@@ -0,0 +1,150 @@
+// Package secrets loads the values exposed to expressions as the secrets context
+// and masks them in anything agentic-ops reports.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPrefix is the prefix of environment variables loaded as secrets
+const DefaultPrefix = "AGENTIC_OPS_SECRET_"
+
+// Mask replaces secret values in reported text
+const Mask = "***"
+
+// MinMaskLength is the shortest value that is masked. Masking a value like "1"
+// would redact that character from all output, so shorter values are left alone.
+const MinMaskLength = 4
+
+// RepoFile returns the path of the local secrets file. It must not be committed.
+func RepoFile(dir string) string {
+	return filepath.Join(dir, ".agentic-ops", "secrets.yml")
+}
+
+// Load reads secrets from the local secrets file and from environment variables
+// starting with prefix (the prefix is stripped from the name). Environment
+// variables override the file. A missing file is not an error.
+func Load(dir, prefix string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	values, err := LoadFile(RepoFile(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for k, v := range values {
+		result[k] = v
+	}
+
+	if prefix != "" {
+		for _, kv := range os.Environ() {
+			name, value, ok := strings.Cut(kv, "=")
+			if !ok || !strings.HasPrefix(name, prefix) || name == prefix {
+				continue
+			}
+			result[strings.TrimPrefix(name, prefix)] = value
+		}
+	}
+
+	return result, nil
+}
+
+// LoadFile reads a secrets file: a YAML mapping of names to scalar values
+func LoadFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	result := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("failed to parse %s: secret '%s' must be a string", path, k)
+		case nil:
+			result[k] = ""
+		default:
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result, nil
+}
+
+// IsTracked reports whether git tracks the file, meaning its secrets are committed
+// to the repository. Outside a git repository it returns false.
+func IsTracked(dir, path string) bool {
+	cmd := exec.Command("git", "ls-files", "--error-unmatch", path)
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
+
+// Masker replaces secret values with Mask
+type Masker struct {
+	values []string
+}
+
+// NewMasker creates a masker for a set of secrets. Each line of a multi-line
+// secret is also masked on its own, since output is often reported line by line.
+// Values shorter than MinMaskLength are not masked; see ShortSecrets.
+func NewMasker(secrets map[string]string) *Masker {
+	seen := make(map[string]bool)
+	var values []string
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if len(v) >= MinMaskLength && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	for _, secret := range secrets {
+		add(secret)
+		if strings.Contains(secret, "\n") {
+			for _, line := range strings.Split(secret, "\n") {
+				add(line)
+			}
+		}
+	}
+
+	// Longest first, so a secret containing another is masked as a whole
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	return &Masker{values: values}
+}
+
+// ShortSecrets returns the sorted names of non-empty secrets too short to be masked
+func ShortSecrets(secrets map[string]string) []string {
+	var names []string
+	for name, value := range secrets {
+		if v := strings.TrimSpace(value); v != "" && len(v) < MinMaskLength {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mask replaces every secret value in text. A nil masker returns text unchanged.
+func (m *Masker) Mask(text string) string {
+	if m == nil {
+		return text
+	}
+	for _, v := range m.values {
+		text = strings.ReplaceAll(text, v, Mask)
+	}
+	return text
+}
@@ -0,0 +1,127 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, RepoFile(dir), "API_TOKEN: from-file\nPORT: 8080\n")
+	t.Setenv("TEST_SECRET_API_TOKEN", "from-env")
+	t.Setenv("TEST_SECRET_", "ignored")
+
+	got, err := Load(dir, "TEST_SECRET_")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got["API_TOKEN"] != "from-env" {
+		t.Errorf("Expected API_TOKEN from env, got %q", got["API_TOKEN"])
+	}
+	if got["PORT"] != "8080" {
+		t.Errorf("Expected PORT 8080, got %q", got["PORT"])
+	}
+	if _, ok := got[""]; ok {
+		t.Errorf("Expected the bare prefix to be ignored, got %v", got)
+	}
+}
+
+func TestLoadWithoutPrefix(t *testing.T) {
+	t.Setenv("TEST_SECRET_API_TOKEN", "from-env")
+
+	got, err := Load(t.TempDir(), "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected no secrets, got %v", got)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"nested value", "db:\n  password: x\n", "secret 'db' must be a string"},
+		{"invalid yaml", "a: [b\n", "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := RepoFile(t.TempDir())
+			writeFile(t, path, tt.content)
+
+			_, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMasker(t *testing.T) {
+	masker := NewMasker(map[string]string{
+		"TOKEN":   "abc123",
+		"LONGER":  "abc123xyz",
+		"KEY":     "line-one\nline-two\n",
+		"EMPTY":   "",
+		"PADDING": "  spaced  ",
+		"SHORT":   "a",
+		"PIN":     "123",
+		"LINES":   "ok\nlong-enough",
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"single", "token=abc123", "token=***"},
+		{"containing another", "abc123xyz and abc123", "*** and ***"},
+		{"multi-line", "got line-one\nline-two", "got ***"},
+		{"single line of multi-line", "error near line-two", "error near ***"},
+		{"trimmed", "value: spaced.", "value: ***."},
+		{"no secrets", "all good", "all good"},
+		{"short values are not masked", "a total of 123 ok", "a total of 123 ok"},
+		{"long line of multi-line with a short line", "got long-enough", "got ***"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := masker.Mask(tt.text); got != tt.want {
+				t.Errorf("Mask(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	var nilMasker *Masker
+	if got := nilMasker.Mask("abc123"); got != "abc123" {
+		t.Errorf("Expected nil masker to return text unchanged, got %q", got)
+	}
+}
+
+func TestShortSecrets(t *testing.T) {
+	got := ShortSecrets(map[string]string{
+		"TOKEN": "abc123",
+		"PIN":   "123",
+		"FLAG":  " 1 ",
+		"EMPTY": "",
+	})
+	if strings.Join(got, ",") != "FLAG,PIN" {
+		t.Errorf("ShortSecrets() = %v, want [FLAG PIN]", got)
+	}
+}
@@ -1,15 +1,24 @@
 package trigger
 
 import (
-	"path/filepath"
+	"fmt"
+	"path"
 	"strings"
 
+	"github.com/htekdev/agentic-ops-cli/internal/expression"
+	"github.com/htekdev/agentic-ops-cli/internal/glob"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
 // Matcher determines if a workflow should be triggered by an event
 type Matcher struct {
 	workflow *schema.Workflow
+	exprCtx  *expression.Context // Context for trigger `if:` conditions (built from the event if nil)
+	vars     map[string]interface{}
+	secrets  map[string]string
+	runner   map[string]interface{}
+	git      map[string]interface{}
+	dir      string
 }
 
 // NewMatcher creates a new trigger matcher for a workflow
@@ -17,22 +26,82 @@ func NewMatcher(workflow *schema.Workflow) *Matcher {
 	return &Matcher{workflow: workflow}
 }
 
-// Match checks if the event matches any of the workflow's triggers
+// WithContext sets the expression context used to evaluate trigger `if:` conditions.
+// When not set, a context is built from the event being matched.
+func (m *Matcher) WithContext(ctx *expression.Context) *Matcher {
+	m.exprCtx = ctx
+	return m
+}
+
+// WithVars sets the vars context for trigger `if:` conditions when the context is
+// built from the event
+func (m *Matcher) WithVars(vars map[string]interface{}) *Matcher {
+	m.vars = vars
+	return m
+}
+
+// WithSecrets sets the secrets context for trigger `if:` conditions when the context
+// is built from the event
+func (m *Matcher) WithSecrets(secrets map[string]string) *Matcher {
+	m.secrets = secrets
+	return m
+}
+
+// WithRunnerContext sets the runner context for trigger `if:` conditions when the
+// context is built from the event
+func (m *Matcher) WithRunnerContext(values map[string]interface{}) *Matcher {
+	m.runner = values
+	return m
+}
+
+// WithGit sets the git context for trigger `if:` conditions when the context is
+// built from the event
+func (m *Matcher) WithGit(values map[string]interface{}) *Matcher {
+	m.git = values
+	return m
+}
+
+// WithWorkingDir sets the directory file functions such as hashFiles resolve paths
+// against when the context is built from the event. It should be the directory the
+// workflow's steps run in, so a condition sees the same files as its steps.
+func (m *Matcher) WithWorkingDir(dir string) *Matcher {
+	m.dir = dir
+	return m
+}
+
+// Match checks if the event matches any of the workflow's triggers.
+// Trigger conditions that fail to evaluate are treated as not matching;
+// use MatchWithError to find out why.
 func (m *Matcher) Match(event *schema.Event) bool {
+	matched, _ := m.MatchWithError(event)
+	return matched
+}
+
+// MatchWithError checks if the event matches any of the workflow's triggers,
+// returning an error if a trigger `if:` condition cannot be evaluated
+func (m *Matcher) MatchWithError(event *schema.Event) (bool, error) {
 	on := m.workflow.On
 
 	// Check tool trigger (most specific)
 	if on.Tool != nil && event.Tool != nil {
-		if m.matchToolTrigger(on.Tool, event.Tool) {
-			return true
+		matched, err := m.matchToolTrigger(on.Tool, event)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
 		}
 	}
 
 	// Check tools array
 	if len(on.Tools) > 0 && event.Tool != nil {
-		for _, toolTrigger := range on.Tools {
-			if m.matchToolTrigger(&toolTrigger, event.Tool) {
-				return true
+		for i := range on.Tools {
+			matched, err := m.matchToolTrigger(&on.Tools[i], event)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
 			}
 		}
 	}
@@ -40,55 +109,98 @@ func (m *Matcher) Match(event *schema.Event) bool {
 	// Check hooks trigger
 	if on.Hooks != nil && event.Hook != nil {
 		if m.matchHooksTrigger(on.Hooks, event.Hook) {
-			return true
+			return true, nil
 		}
 	}
 
 	// Check file trigger
 	if on.File != nil && event.File != nil {
 		if m.matchFileTrigger(on.File, event.File) {
-			return true
+			return true, nil
 		}
 	}
 
 	// Check commit trigger
 	if on.Commit != nil && event.Commit != nil {
 		if m.matchCommitTrigger(on.Commit, event.Commit) {
-			return true
+			return true, nil
 		}
 	}
 
 	// Check push trigger
 	if on.Push != nil && event.Push != nil {
 		if m.matchPushTrigger(on.Push, event.Push) {
-			return true
+			return true, nil
 		}
 	}
 
-	return false
+	return false, nil
 }
 
 // matchToolTrigger checks if a tool event matches a tool trigger
-func (m *Matcher) matchToolTrigger(trigger *schema.ToolTrigger, event *schema.ToolEvent) bool {
+func (m *Matcher) matchToolTrigger(trigger *schema.ToolTrigger, event *schema.Event) (bool, error) {
+	tool := event.Tool
+
+	// Tool triggers gate tool use, so they only fire before the tool runs.
+	// Use a hooks trigger with types: [postToolUse] to react to results.
+	if tool.HookType == "postToolUse" {
+		return false, nil
+	}
+
 	// Check tool name
-	if trigger.Name != event.Name {
-		return false
+	if trigger.Name != tool.Name {
+		return false, nil
 	}
 
 	// Check args patterns
 	for argName, pattern := range trigger.Args {
-		argValue, ok := event.Args[argName]
+		argValue, ok := tool.Args[argName]
 		if !ok {
-			return false
+			return false, nil
 		}
 		argStr, _ := argValue.(string)
 		if !matchGlob(pattern, argStr) {
-			return false
+			return false, nil
 		}
 	}
 
-	// Note: trigger.If expression is evaluated separately by expression engine
-	return true
+	// Check the if condition last, once the cheap filters have passed
+	if trigger.If != "" {
+		matched, err := m.expressionContext(event).EvaluateBool(trigger.If)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate tool trigger if condition for '%s': %w", trigger.Name, err)
+		}
+		return matched, nil
+	}
+
+	return true, nil
+}
+
+// expressionContext returns the context for trigger conditions, building it from the event if needed
+func (m *Matcher) expressionContext(event *schema.Event) *expression.Context {
+	if m.exprCtx != nil {
+		return m.exprCtx
+	}
+	ctx := expression.NewContextForEvent(event)
+	for k, v := range m.workflow.Env {
+		ctx.Env[k] = v
+	}
+	if m.vars != nil {
+		ctx.Vars = m.vars
+	}
+	if m.secrets != nil {
+		ctx.Secrets = m.secrets
+	}
+	if m.runner != nil {
+		ctx.Runner = m.runner
+	}
+	if m.git != nil {
+		ctx.Git = m.git
+	}
+	if m.dir != "" {
+		ctx.WorkingDir = m.dir
+	}
+	return ctx
 }
 
 // matchHooksTrigger checks if a hook event matches a hooks trigger
@@ -140,10 +252,64 @@ func (m *Matcher) matchFileTrigger(trigger *schema.FileTrigger, event *schema.Fi
 		}
 	}
 
+	// A rename matches when either its old or its new path does, so moving a file
+	// out of a protected directory is caught
+	match := matchFileTriggerPath
+	if event.Tree {
+		match = matchFileTriggerTree
+	}
+	if event.OldPath != "" && match(trigger, event.OldPath) {
+		return true
+	}
+	return match(trigger, event.Path)
+}
+
+// matchFileTriggerTree applies the filters of a file trigger to a directory or glob
+// standing for files that were not listed. It matches when a pattern could match
+// any file below it. paths-ignore only rejects it when a pattern such as dir/**
+// ignores every file below; negated patterns are not applied, since some of the
+// files may not be excluded.
+func matchFileTriggerTree(trigger *schema.FileTrigger, dir string) bool {
+	for _, pattern := range trigger.PathsIgnore {
+		if coversTree(pattern, dir) {
+			return false
+		}
+	}
+	if len(trigger.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range trigger.Paths {
+		if !strings.HasPrefix(pattern, "!") && glob.MatchBelow(pattern, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversTree reports whether a pattern matches every path below dir: it is ** or
+// ends in /**, and the rest matches dir or one of its parents
+func coversTree(pattern, dir string) bool {
+	if pattern == "**" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/**")
+	if !ok || strings.ContainsAny(dir, "*?[") {
+		return false
+	}
+	for ; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+		if matchGlob(prefix, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFileTriggerPath applies the paths and paths-ignore filters of a file trigger
+func matchFileTriggerPath(trigger *schema.FileTrigger, path string) bool {
 	// Check paths-ignore first
 	if len(trigger.PathsIgnore) > 0 {
 		for _, pattern := range trigger.PathsIgnore {
-			if matchGlob(pattern, event.Path) {
+			if matchGlob(pattern, path) {
 				return false
 			}
 		}
@@ -155,10 +321,10 @@ func (m *Matcher) matchFileTrigger(trigger *schema.FileTrigger, event *schema.Fi
 		for _, pattern := range trigger.Paths {
 			// Handle negation
 			if strings.HasPrefix(pattern, "!") {
-				if matchGlob(pattern[1:], event.Path) {
+				if matchGlob(pattern[1:], path) {
 					matched = false
 				}
-			} else if matchGlob(pattern, event.Path) {
+			} else if matchGlob(pattern, path) {
 				matched = true
 			}
 		}
@@ -172,50 +338,14 @@ func (m *Matcher) matchFileTrigger(trigger *schema.FileTrigger, event *schema.Fi
 
 // matchCommitTrigger checks if a commit event matches a commit trigger
 func (m *Matcher) matchCommitTrigger(trigger *schema.CommitTrigger, event *schema.CommitEvent) bool {
-	// Check branches - would need branch info from context
-	// For now, focus on path matching
-
-	// Check paths-ignore
-	if len(trigger.PathsIgnore) > 0 {
-		allIgnored := true
-		for _, file := range event.Files {
-			ignored := false
-			for _, pattern := range trigger.PathsIgnore {
-				if matchGlob(pattern, file.Path) {
-					ignored = true
-					break
-				}
-			}
-			if !ignored {
-				allIgnored = false
-				break
-			}
-		}
-		if allIgnored {
-			return false
-		}
+	// Check branches
+	if !matchBranchFilters(trigger.Branches, trigger.BranchesIgnore, event.Branch) {
+		return false
 	}
 
 	// Check paths
-	if len(trigger.Paths) > 0 {
-		matched := false
-		for _, file := range event.Files {
-			for _, pattern := range trigger.Paths {
-				if strings.HasPrefix(pattern, "!") {
-					continue
-				}
-				if matchGlob(pattern, file.Path) {
-					matched = true
-					break
-				}
-			}
-			if matched {
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
+	if !matchFilePaths(trigger.Paths, trigger.PathsIgnore, event.Files) {
+		return false
 	}
 
 	return true
@@ -223,35 +353,21 @@ func (m *Matcher) matchCommitTrigger(trigger *schema.CommitTrigger, event *schem
 
 // matchPushTrigger checks if a push event matches a push trigger
 func (m *Matcher) matchPushTrigger(trigger *schema.PushTrigger, event *schema.PushEvent) bool {
-	// Check branches
-	if len(trigger.Branches) > 0 {
-		branch := extractBranch(event.Ref)
-		if branch != "" {
-			matched := false
-			for _, pattern := range trigger.Branches {
-				if strings.HasPrefix(pattern, "!") {
-					if matchGlob(pattern[1:], branch) {
-						matched = false
-					}
-				} else if matchGlob(pattern, branch) {
-					matched = true
-				}
-			}
-			if !matched {
-				return false
-			}
-		}
+	// Check branches; tag pushes are filtered by tags instead
+	if extractTag(event.Ref) == "" && !matchBranchFilters(trigger.Branches, trigger.BranchesIgnore, extractBranch(event.Ref)) {
+		return false
 	}
 
-	// Check branches-ignore
-	if len(trigger.BranchesIgnore) > 0 {
-		branch := extractBranch(event.Ref)
-		if branch != "" {
-			for _, pattern := range trigger.BranchesIgnore {
-				if matchGlob(pattern, branch) {
-					return false
-				}
-			}
+	// Check paths against every file touched by the pushed commits. When the commits
+	// could not be determined the filters are not applied, so a path policy still
+	// runs for a push that may touch its paths.
+	if !event.CommitsUnknown && (len(trigger.Paths) > 0 || len(trigger.PathsIgnore) > 0) {
+		var files []schema.FileStatus
+		for _, commit := range event.Commits {
+			files = append(files, commit.Files...)
+		}
+		if !matchFilePaths(trigger.Paths, trigger.PathsIgnore, files) {
+			return false
 		}
 	}
 
@@ -291,73 +407,94 @@ func (m *Matcher) matchPushTrigger(trigger *schema.PushTrigger, event *schema.Pu
 	return true
 }
 
-// matchGlob performs glob pattern matching
-func matchGlob(pattern, path string) bool {
-	// Normalize path separators
-	pattern = filepath.ToSlash(pattern)
-	path = filepath.ToSlash(path)
-
-	// Handle ** patterns
-	if strings.Contains(pattern, "**") {
-		return matchDoubleGlob(pattern, path)
+// matchFilePaths applies paths / paths-ignore filters to a set of changed files.
+// paths-ignore rejects the event only when every file is ignored; paths requires
+// at least one file to match a (non-negated) pattern, so an empty file list never
+// passes a paths filter.
+func matchFilePaths(paths, pathsIgnore []string, files []schema.FileStatus) bool {
+	// Check paths-ignore
+	if len(pathsIgnore) > 0 {
+		allIgnored := true
+		for _, file := range files {
+			ignored := false
+			for _, pattern := range pathsIgnore {
+				if matchGlob(pattern, file.Path) {
+					ignored = true
+					break
+				}
+			}
+			if !ignored {
+				allIgnored = false
+				break
+			}
+		}
+		if allIgnored {
+			return false
+		}
 	}
 
-	// Use filepath.Match for simple patterns
-	matched, _ := filepath.Match(pattern, path)
-	return matched
-}
-
-// matchDoubleGlob handles ** patterns that match across directories
-func matchDoubleGlob(pattern, path string) bool {
-	parts := strings.Split(pattern, "**")
-	if len(parts) == 1 {
-		matched, _ := filepath.Match(pattern, path)
-		return matched
-	}
-
-	// For patterns like **/*.js
-	if parts[0] == "" {
-		suffix := strings.TrimPrefix(parts[1], "/")
-		// Match suffix against any path segment
-		pathParts := strings.Split(path, "/")
-		for i := range pathParts {
-			subpath := strings.Join(pathParts[i:], "/")
-			if matched, _ := filepath.Match(suffix, subpath); matched {
-				return true
+	// Check paths
+	if len(paths) > 0 {
+		matched := false
+		for _, file := range files {
+			for _, pattern := range paths {
+				if strings.HasPrefix(pattern, "!") {
+					continue
+				}
+				if matchGlob(pattern, file.Path) {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
 			}
 		}
-		// Also try matching just the filename
-		if matched, _ := filepath.Match(suffix, filepath.Base(path)); matched {
-			return true
+		if !matched {
+			return false
 		}
-		return false
 	}
 
-	// For patterns like src/**/test.js
-	prefix := strings.TrimSuffix(parts[0], "/")
-	suffix := strings.TrimPrefix(parts[1], "/")
+	return true
+}
 
-	if !strings.HasPrefix(path, prefix) {
-		return false
+// matchBranchFilters applies branches / branches-ignore filters to a branch name.
+// Patterns in branches prefixed with "!" exclude branches matched by earlier patterns.
+// An unknown (empty) branch, such as a detached HEAD, passes every filter, so a
+// branch policy still runs when the branch cannot be determined.
+func matchBranchFilters(branches, branchesIgnore []string, branch string) bool {
+	if branch == "" {
+		return true
 	}
 
-	remaining := strings.TrimPrefix(path, prefix)
-	remaining = strings.TrimPrefix(remaining, "/")
-
-	if suffix == "" {
-		return true
+	if len(branches) > 0 {
+		matched := false
+		for _, pattern := range branches {
+			if strings.HasPrefix(pattern, "!") {
+				if matchGlob(pattern[1:], branch) {
+					matched = false
+				}
+			} else if matchGlob(pattern, branch) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
 	}
 
-	// Match suffix against remaining path
-	pathParts := strings.Split(remaining, "/")
-	for i := range pathParts {
-		subpath := strings.Join(pathParts[i:], "/")
-		if matched, _ := filepath.Match(suffix, subpath); matched {
-			return true
+	for _, pattern := range branchesIgnore {
+		if matchGlob(pattern, branch) {
+			return false
 		}
 	}
 
-	return false
+	return true
+}
+
+// matchGlob performs glob pattern matching
+func matchGlob(pattern, path string) bool {
+	return glob.Match(pattern, path)
 }
 
 // extractBranch extracts branch name from a ref
@@ -1,8 +1,11 @@
 package trigger
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/htekdev/agentic-ops-cli/internal/expression"
 	"github.com/htekdev/agentic-ops-cli/internal/schema"
 )
 
@@ -235,6 +238,116 @@ func TestMatchFileTrigger(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			name: "match delete",
+			trigger: &schema.FileTrigger{
+				Types: []string{"delete"},
+				Paths: []string{"config/*.yml"},
+			},
+			event: &schema.FileEvent{
+				Path:   "config/prod.yml",
+				Action: "delete",
+			},
+			want: true,
+		},
+		{
+			name: "rename out of protected path",
+			trigger: &schema.FileTrigger{
+				Paths: []string{"config/*.yml"},
+			},
+			event: &schema.FileEvent{
+				Path:    "tmp/prod.yml",
+				Action:  "rename",
+				OldPath: "config/prod.yml",
+			},
+			want: true,
+		},
+		{
+			name: "rename into protected path",
+			trigger: &schema.FileTrigger{
+				Paths: []string{"config/*.yml"},
+			},
+			event: &schema.FileEvent{
+				Path:    "config/prod.yml",
+				Action:  "rename",
+				OldPath: "tmp/prod.yml",
+			},
+			want: true,
+		},
+		{
+			name: "rename with both paths ignored",
+			trigger: &schema.FileTrigger{
+				PathsIgnore: []string{"tmp/**"},
+			},
+			event: &schema.FileEvent{
+				Path:    "tmp/b.txt",
+				Action:  "rename",
+				OldPath: "tmp/a.txt",
+			},
+			want: false,
+		},
+		{
+			name: "directory holding a protected path",
+			trigger: &schema.FileTrigger{
+				Paths: []string{"config/secrets/**"},
+			},
+			event: &schema.FileEvent{
+				Path:   "config",
+				Action: "delete",
+				Tree:   true,
+			},
+			want: true,
+		},
+		{
+			name: "directory renamed away from a protected path",
+			trigger: &schema.FileTrigger{
+				Paths: []string{"config/secrets/**"},
+			},
+			event: &schema.FileEvent{
+				Path:    "cfg2",
+				Action:  "rename",
+				OldPath: "config",
+				Tree:    true,
+			},
+			want: true,
+		},
+		{
+			name: "glob holding a protected path",
+			trigger: &schema.FileTrigger{
+				Paths:       []string{"config/secrets/**"},
+				PathsIgnore: []string{"config/secrets/*.md"},
+			},
+			event: &schema.FileEvent{
+				Path:   "*",
+				Action: "delete",
+				Tree:   true,
+			},
+			want: true,
+		},
+		{
+			name: "directory outside the protected paths",
+			trigger: &schema.FileTrigger{
+				Paths: []string{"config/secrets/**"},
+			},
+			event: &schema.FileEvent{
+				Path:   "node_modules",
+				Action: "delete",
+				Tree:   true,
+			},
+			want: false,
+		},
+		{
+			name: "directory ignored as a whole",
+			trigger: &schema.FileTrigger{
+				PathsIgnore: []string{"node_modules/**"},
+			},
+			event: &schema.FileEvent{
+				Path:   "node_modules/pkg",
+				Action: "delete",
+				Tree:   true,
+			},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -460,6 +573,18 @@ func TestMatchCommitTrigger(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "empty commit does not match paths",
+			trigger: &schema.CommitTrigger{
+				Paths: []string{"src/**"},
+			},
+			event: &schema.CommitEvent{
+				SHA:        "pending",
+				Message:    "chore: trigger build",
+				AllowEmpty: true,
+			},
+			want: false,
+		},
 		{
 			name: "empty trigger matches all",
 			trigger: &schema.CommitTrigger{},
@@ -1139,6 +1264,21 @@ func TestPushTriggerTagsIgnoreWithNoTag(t *testing.T) {
 	}
 }
 
+// TestPushTriggerBranchesWithUnknownRef tests that a push whose branch is unknown,
+// such as from a detached HEAD, still runs branch policies
+func TestPushTriggerBranchesWithUnknownRef(t *testing.T) {
+	for _, trigger := range []*schema.PushTrigger{
+		{Branches: []string{"main"}},
+		{BranchesIgnore: []string{"temp/**"}},
+	} {
+		workflow := &schema.Workflow{On: schema.OnConfig{Push: trigger}}
+		event := &schema.Event{Push: &schema.PushEvent{Ref: ""}}
+		if !NewMatcher(workflow).Match(event) {
+			t.Errorf("Expected unknown ref to match %+v", trigger)
+		}
+	}
+}
+
 // TestPushTriggerBranchesIgnoreWithNoMatchingBranch tests branches-ignore edge cases
 func TestPushTriggerBranchesIgnoreWithNoMatchingBranch(t *testing.T) {
 	trigger := &schema.PushTrigger{
@@ -1171,4 +1311,280 @@ func TestPushTriggerBranchesIgnoreWithNoMatchingBranch(t *testing.T) {
 	if !matcher.Match(event2) {
 		t.Error("Expected non-ignored branch to match")
 	}
+	// An unknown ref still runs the policy
+	event3 := &schema.Event{
+		Push: &schema.PushEvent{
+			Ref: "",
+		},
+	}
+	if !matcher.Match(event3) {
+		t.Error("Expected unknown ref to pass branches-ignore filter")
+	}
+}
+
+// TestMatchWithWorkingDir tests that file functions in conditions resolve against
+// the working directory the steps run in rather than the event's cwd
+func TestMatchWithWorkingDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "deploy.lock"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			Tool: &schema.ToolTrigger{Name: "bash", If: "fileExists('deploy.lock')"},
+		},
+	}
+	event := &schema.Event{
+		Cwd:  t.TempDir(),
+		Tool: &schema.ToolEvent{Name: "bash"},
+	}
+
+	if NewMatcher(workflow).Match(event) {
+		t.Error("Expected the condition to resolve against the event cwd by default")
+	}
+	if !NewMatcher(workflow).WithWorkingDir(dir).Match(event) {
+		t.Error("Expected the condition to resolve against the working directory")
+	}
+}
+
+func TestMatchToolTriggerIfCondition(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger schema.ToolTrigger
+		command string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "if condition true",
+			trigger: schema.ToolTrigger{Name: "bash", If: "${{ contains(event.tool.args.command, 'rm -rf') }}"},
+			command: "rm -rf build",
+			want:    true,
+		},
+		{
+			name:    "if condition false",
+			trigger: schema.ToolTrigger{Name: "bash", If: "${{ contains(event.tool.args.command, 'rm -rf') }}"},
+			command: "ls -la",
+			want:    false,
+		},
+		{
+			name:    "if condition without braces",
+			trigger: schema.ToolTrigger{Name: "bash", If: "startsWith(event.tool.args.command, 'git')"},
+			command: "git status",
+			want:    true,
+		},
+		{
+			name:    "if condition can use workflow env",
+			trigger: schema.ToolTrigger{Name: "bash", If: "event.tool.args.command == env.BLOCKED"},
+			command: "make deploy",
+			want:    true,
+		},
+		{
+			name:    "if condition not evaluated when name differs",
+			trigger: schema.ToolTrigger{Name: "edit", If: "unknownFn()"},
+			command: "ls",
+			want:    false,
+		},
+		{
+			name:    "if condition error is reported",
+			trigger: schema.ToolTrigger{Name: "bash", If: "unknownFn(event.tool.name)"},
+			command: "ls",
+			want:    false,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := tt.trigger
+			workflow := &schema.Workflow{
+				Env: map[string]string{"BLOCKED": "make deploy"},
+				On: schema.OnConfig{
+					Tool: &trigger,
+				},
+			}
+			event := &schema.Event{
+				Tool: &schema.ToolEvent{
+					Name: "bash",
+					Args: map[string]interface{}{"command": tt.command},
+				},
+			}
+			got, err := NewMatcher(workflow).MatchWithError(event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MatchWithError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("MatchWithError() = %v, want %v", got, tt.want)
+			}
+			if NewMatcher(workflow).Match(event) != tt.want {
+				t.Errorf("Match() disagrees with MatchWithError()")
+			}
+		})
+	}
+}
+
+func TestMatchToolsArrayIfCondition(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			Tools: []schema.ToolTrigger{
+				{Name: "edit", If: "endsWith(event.tool.args.path, '.go')"},
+				{Name: "create", If: "endsWith(event.tool.args.path, '.go')"},
+			},
+		},
+	}
+	matcher := NewMatcher(workflow)
+
+	goEdit := &schema.Event{Tool: &schema.ToolEvent{Name: "create", Args: map[string]interface{}{"path": "main.go"}}}
+	if !matcher.Match(goEdit) {
+		t.Error("Expected create of .go file to match")
+	}
+	mdEdit := &schema.Event{Tool: &schema.ToolEvent{Name: "edit", Args: map[string]interface{}{"path": "README.md"}}}
+	if matcher.Match(mdEdit) {
+		t.Error("Expected edit of .md file not to match")
+	}
+}
+
+func TestMatcherWithContext(t *testing.T) {
+	workflow := &schema.Workflow{
+		On: schema.OnConfig{
+			Tool: &schema.ToolTrigger{Name: "bash", If: "env.STRICT == 'true'"},
+		},
+	}
+	event := &schema.Event{Tool: &schema.ToolEvent{Name: "bash", Args: map[string]interface{}{}}}
+
+	ctx := expression.NewContextForEvent(event)
+	ctx.Env["STRICT"] = "true"
+	if !NewMatcher(workflow).WithContext(ctx).Match(event) {
+		t.Error("Expected match using provided context")
+	}
+	if NewMatcher(workflow).Match(event) {
+		t.Error("Expected no match without STRICT in env")
+	}
+}
+
+func TestMatchCommitTriggerBranches(t *testing.T) {
+	tests := []struct {
+		name           string
+		branches       []string
+		branchesIgnore []string
+		branch         string
+		want           bool
+	}{
+		{"no filters", nil, nil, "feature/x", true},
+		{"exact branch match", []string{"main"}, nil, "main", true},
+		{"branch mismatch", []string{"main"}, nil, "develop", false},
+		{"glob branch match", []string{"release/*"}, nil, "release/1.0", true},
+		{"negated branch excluded", []string{"feature/**", "!feature/wip-*"}, nil, "feature/wip-x", false},
+		{"negated branch not excluded", []string{"feature/**", "!feature/wip-*"}, nil, "feature/login", true},
+		{"branches-ignore excludes", nil, []string{"main"}, "main", false},
+		{"branches-ignore allows others", nil, []string{"main"}, "develop", true},
+		{"unknown branch runs branches policy", []string{"main"}, nil, "", true},
+		{"unknown branch runs branches-ignore policy", nil, []string{"temp/*"}, "", true},
+		{"unknown branch without filters", nil, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					Commit: &schema.CommitTrigger{
+						Branches:       tt.branches,
+						BranchesIgnore: tt.branchesIgnore,
+					},
+				},
+			}
+			event := &schema.Event{
+				Commit: &schema.CommitEvent{
+					Branch: tt.branch,
+					Files:  []schema.FileStatus{{Path: "main.go", Status: "modified"}},
+				},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPushTriggerPaths(t *testing.T) {
+	commits := []schema.CommitEvent{
+		{SHA: "a1", Files: []schema.FileStatus{{Path: "README.md", Status: "modified"}}},
+		{SHA: "b2", Files: []schema.FileStatus{{Path: "migrations/002_users.sql", Status: "added"}}},
+	}
+
+	tests := []struct {
+		name        string
+		paths       []string
+		pathsIgnore []string
+		commits     []schema.CommitEvent
+		unknown     bool
+		want        bool
+	}{
+		{"no path filters", nil, nil, commits, false, true},
+		{"paths match in later commit", []string{"migrations/**"}, nil, commits, false, true},
+		{"paths no match", []string{"src/**"}, nil, commits, false, false},
+		{"paths-ignore with other files", nil, []string{"**/*.md"}, commits, false, true},
+		{"paths-ignore all files", nil, []string{"**/*.md", "migrations/**"}, commits, false, false},
+		{"paths with branch delete", []string{"migrations/**"}, nil, []schema.CommitEvent{}, false, false},
+		{"paths with up-to-date push", []string{"migrations/**"}, nil, nil, false, false},
+		{"paths with unknown commits", []string{"migrations/**"}, nil, nil, true, true},
+		{"paths-ignore with unknown commits", nil, []string{"**/*.md"}, nil, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := &schema.Workflow{
+				On: schema.OnConfig{
+					Push: &schema.PushTrigger{
+						Paths:       tt.paths,
+						PathsIgnore: tt.pathsIgnore,
+					},
+				},
+			}
+			event := &schema.Event{
+				Push: &schema.PushEvent{
+					Ref:            "refs/heads/main",
+					Commits:        tt.commits,
+					CommitsUnknown: tt.unknown,
+				},
+			}
+			if got := NewMatcher(workflow).Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPostToolUseEvents(t *testing.T) {
+	tool := &schema.ToolEvent{
+		Name:     "edit",
+		Args:     map[string]interface{}{"path": "main.go"},
+		HookType: "postToolUse",
+		Result:   &schema.ToolResult{Type: "success"},
+	}
+	event := &schema.Event{
+		Tool: tool,
+		Hook: &schema.HookEvent{Type: "postToolUse", Tool: tool},
+	}
+
+	toolWorkflow := &schema.Workflow{
+		On: schema.OnConfig{Tool: &schema.ToolTrigger{Name: "edit"}},
+	}
+	if NewMatcher(toolWorkflow).Match(event) {
+		t.Error("Tool trigger should not fire for postToolUse events")
+	}
+
+	hookWorkflow := &schema.Workflow{
+		On: schema.OnConfig{Hooks: &schema.HooksTrigger{Types: []string{"postToolUse"}, Tools: []string{"edit"}}},
+	}
+	if !NewMatcher(hookWorkflow).Match(event) {
+		t.Error("Hooks trigger with postToolUse should match")
+	}
+
+	preWorkflow := &schema.Workflow{
+		On: schema.OnConfig{Hooks: &schema.HooksTrigger{Types: []string{"preToolUse"}}},
+	}
+	if NewMatcher(preWorkflow).Match(event) {
+		t.Error("Hooks trigger with preToolUse should not match postToolUse event")
+	}
 }
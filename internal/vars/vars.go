@@ -0,0 +1,149 @@
+// Package vars loads the configuration variables exposed to expressions as the vars context.
+package vars
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the variables file, both in the repository's
+// workflow directory and in the user's configuration directory
+const FileName = "vars.yml"
+
+// AltFileName is the alternative name of the variables file, used when no file named
+// FileName exists
+const AltFileName = "vars.yaml"
+
+// RepoFile returns the path of the repository-level variables file
+func RepoFile(dir string) string {
+	return fileIn(filepath.Join(dir, ".github", "agent-workflows"))
+}
+
+// IsVarsFile reports whether a path found under the workflow directory of dir is a
+// repository variables file rather than a workflow. Both names count, so a vars.yaml
+// is never run as a workflow even when vars.yml shadows it.
+func IsVarsFile(dir, path string) bool {
+	workflowDir := filepath.Join(dir, ".github", "agent-workflows")
+	path = filepath.Clean(path)
+	return path == filepath.Join(workflowDir, FileName) || path == filepath.Join(workflowDir, AltFileName)
+}
+
+// fileIn returns the path of the variables file in dir: FileName, or AltFileName if
+// only that exists
+func fileIn(dir string) string {
+	path := filepath.Join(dir, FileName)
+	if alt := filepath.Join(dir, AltFileName); !fileExists(path) && fileExists(alt) {
+		return alt
+	}
+	return path
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// UserFile returns the path of the user-level variables file, or "" if the user's
+// configuration directory cannot be determined
+func UserFile() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return fileIn(filepath.Join(configDir, "agentic-ops"))
+}
+
+// Load reads the user-level and repository variables files and merges them.
+// Repository values override user values; nested mappings are merged key by key.
+// Missing files are not an error.
+func Load(dir string) (map[string]interface{}, error) {
+	return loadFiles(UserFile(), RepoFile(dir))
+}
+
+// loadFiles reads and merges variables files in order, later files taking precedence
+func loadFiles(paths ...string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		values, err := LoadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		merge(result, values)
+	}
+	return result, nil
+}
+
+// LoadFile reads a single variables file. The file must be a YAML mapping.
+func LoadFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if raw == nil {
+		return map[string]interface{}{}, nil
+	}
+	values, ok := normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: expected a mapping of variable names to values", path)
+	}
+	return values, nil
+}
+
+// normalize converts decoded YAML to the value types expressions use:
+// integers become int64 and mappings get string keys
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return int64(val)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			result[k] = normalize(item)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			result[fmt.Sprint(k)] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = normalize(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// merge copies src into dst, merging nested mappings rather than replacing them
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			merged := make(map[string]interface{}, len(dstMap))
+			merge(merged, dstMap)
+			merge(merged, srcMap)
+			dst[k] = merged
+			continue
+		}
+		dst[k] = v
+	}
+}
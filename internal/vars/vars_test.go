@@ -0,0 +1,168 @@
+package vars
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFilesMergesRepoOverUser(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "user", FileName)
+	repoFile := filepath.Join(dir, "repo", FileName)
+
+	writeFile(t, userFile, `
+test_command: make test
+max_file_size: 1000
+limits:
+  lines: 500
+  files: 20
+editor: vim
+`)
+	writeFile(t, repoFile, `
+test_command: go test ./...
+limits:
+  lines: 800
+protected_paths:
+  - go.mod
+  - .github/**
+ratio: 0.5
+`)
+
+	got, err := loadFiles(userFile, repoFile)
+	if err != nil {
+		t.Fatalf("loadFiles() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"test_command":    "go test ./...",
+		"max_file_size":   int64(1000),
+		"editor":          "vim",
+		"limits":          map[string]interface{}{"lines": int64(800), "files": int64(20)},
+		"protected_paths": []interface{}{"go.mod", ".github/**"},
+		"ratio":           0.5,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadFiles() = %#v, want %#v", got, want)
+	}
+}
+
+func TestLoadFilesMissing(t *testing.T) {
+	dir := t.TempDir()
+	got, err := loadFiles("", filepath.Join(dir, "missing.yml"))
+	if err != nil {
+		t.Fatalf("loadFiles() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected no vars, got %v", got)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not a mapping", "- a\n- b\n", "expected a mapping"},
+		{"invalid yaml", "a: [b\n", "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			writeFile(t, path, tt.content)
+
+			_, err := LoadFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadFileEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, "# nothing yet\n")
+
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if got == nil || len(got) != 0 {
+		t.Errorf("Expected empty vars, got %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	writeFile(t, RepoFile(dir), "threshold: 3\n")
+
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got["threshold"] != int64(3) {
+		t.Errorf("Expected threshold 3, got %v", got["threshold"])
+	}
+}
+
+func TestRepoFileYAMLExtension(t *testing.T) {
+	dir := t.TempDir()
+	workflowDir := filepath.Join(dir, ".github", "agent-workflows")
+	if got, want := RepoFile(dir), filepath.Join(workflowDir, FileName); got != want {
+		t.Errorf("RepoFile() = %q, want %q when no file exists", got, want)
+	}
+
+	writeFile(t, filepath.Join(workflowDir, AltFileName), "threshold: 3\n")
+	if got, want := RepoFile(dir), filepath.Join(workflowDir, AltFileName); got != want {
+		t.Errorf("RepoFile() = %q, want %q", got, want)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got["threshold"] != int64(3) {
+		t.Errorf("Expected threshold 3 from %s, got %v", AltFileName, got["threshold"])
+	}
+
+	// vars.yml takes precedence when both exist
+	writeFile(t, filepath.Join(workflowDir, FileName), "threshold: 5\n")
+	if got, want := RepoFile(dir), filepath.Join(workflowDir, FileName); got != want {
+		t.Errorf("RepoFile() = %q, want %q", got, want)
+	}
+}
+
+func TestIsVarsFile(t *testing.T) {
+	dir := filepath.Join("repo")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(dir, ".github", "agent-workflows", "vars.yml"), true},
+		{filepath.Join(dir, ".github", "agent-workflows", "vars.yaml"), true},
+		{filepath.Join(dir, ".github", "agent-workflows", "vars.json"), false},
+		{filepath.Join(dir, ".github", "agent-workflows", "lint.yml"), false},
+		{filepath.Join(dir, ".github", "agent-workflows", "nested", "vars.yml"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsVarsFile(dir, tt.path); got != tt.want {
+			t.Errorf("IsVarsFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}